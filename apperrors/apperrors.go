@@ -0,0 +1,115 @@
+// Package apperrors define el error estructurado de la API y su representación
+// como application/problem+json (RFC 7807).
+package apperrors
+
+import (
+	"net/http"
+)
+
+// Media types de las respuestas de error
+const (
+	ProblemContentType    = "application/problem+json"
+	ProblemXMLContentType = "application/problem+xml"
+)
+
+// FieldError describe un problema asociado a un campo concreto de la petición
+// @Description Error de validación de un campo
+type FieldError struct {
+	Field   string `json:"field" xml:"field" example:"email"`              // Ruta del campo (ej. "email")
+	Rule    string `json:"rule" xml:"rule" example:"required"`             // Regla incumplida
+	Message string `json:"message" xml:"message" example:"es obligatorio"` // Descripción legible
+}
+
+// Error es un error de la API con un código estable para clientes, el status HTTP
+// con el que se responde y, opcionalmente, los campos que lo causaron
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Fields  []FieldError
+	// Err es la causa original; no se expone al cliente
+	Err error
+}
+
+// New crea un error de la API
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// Wrap crea un error de la API cuyo mensaje y causa son err
+func Wrap(err error, status int, code string) *Error {
+	return &Error{Status: status, Code: code, Message: err.Error(), Err: err}
+}
+
+// Error implementa la interfaz error
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap expone la causa para errors.Is y errors.As
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Problem representa una respuesta de error RFC 7807 con las extensiones code, trace_id y errors
+// @Description Error de la API (application/problem+json)
+type Problem struct {
+	Type     string       `json:"type" xml:"type" example:"/problems/user_not_found"`                      // Identificador del tipo de problema
+	Title    string       `json:"title" xml:"title" example:"Not Found"`                                   // Resumen del status HTTP
+	Status   int          `json:"status" xml:"status" example:"404"`                                       // Status HTTP
+	Detail   string       `json:"detail,omitempty" xml:"detail,omitempty" example:"usuario no encontrado"` // Explicación de esta ocurrencia
+	Instance string       `json:"instance,omitempty" xml:"instance,omitempty" example:"/api/v1/users/42"`  // Recurso de la petición
+	Code     string       `json:"code" xml:"code" example:"user_not_found"`                                // Código estable para clientes
+	TraceID  string       `json:"trace_id,omitempty" xml:"trace_id,omitempty" example:"3f2b9c0e4a1d4e8f"`  // Identificador para correlacionar con los logs
+	Errors   []FieldError `json:"errors,omitempty" xml:"errors>error,omitempty"`                           // Errores por campo
+}
+
+// Problem construye la representación RFC 7807 del error
+func (e *Error) Problem(instance, traceID string) Problem {
+	title := http.StatusText(e.Status)
+	if title == "" {
+		title = e.Code
+	}
+	return Problem{
+		Type:     "/problems/" + e.Code,
+		Title:    title,
+		Status:   e.Status,
+		Detail:   e.Message,
+		Instance: instance,
+		Code:     e.Code,
+		TraceID:  traceID,
+		Errors:   e.Fields,
+	}
+}
+
+// Códigos de error estables expuestos en el campo code de las respuestas
+const (
+	CodeInvalidBody          = "invalid_body"
+	CodeInvalidPatch         = "invalid_patch"
+	CodeBodyTooLarge         = "body_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeInvalidQuery         = "invalid_query"
+	CodeInvalidLimit         = "invalid_limit"
+	CodeInvalidOffset        = "invalid_offset"
+	CodeInvalidCursor        = "invalid_cursor"
+	CodeInvalidSort          = "invalid_sort"
+	CodeInvalidFields        = "invalid_fields"
+	CodeInvalidAgeRange      = "invalid_age_range"
+	CodeInvalidDateRange     = "invalid_date_range"
+	CodeInvalidBatch         = "invalid_batch"
+	CodeBatchAborted         = "batch_aborted"
+	CodeInvalidImport        = "invalid_import"
+	CodeValidationFailed     = "validation_failed"
+	CodeInvalidIfMatch       = "invalid_if_match"
+	CodeUserNotFound         = "user_not_found"
+	CodeEmailAlreadyExists   = "email_already_exists"
+	CodeVersionConflict      = "version_conflict"
+	CodePatchConflict        = "patch_conflict"
+	CodePreconditionFailed   = "precondition_failed"
+	CodeRouteNotFound        = "route_not_found"
+	CodeMethodNotAllowed     = "method_not_allowed"
+	CodeNotAcceptable        = "not_acceptable"
+	CodeRequestCanceled      = "request_canceled"
+	CodeTimeout              = "timeout"
+	CodeInternal             = "internal_error"
+)
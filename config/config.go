@@ -1,20 +1,66 @@
 package config
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// Drivers de almacenamiento soportados
+const (
+	StorageDriverMySQL    = "mysql"
+	StorageDriverMemory   = "memory"
+	StorageDriverSQLite   = "sqlite"
+	StorageDriverPostgres = "postgres"
+)
+
 // Config contiene la configuración de la aplicación
 type Config struct {
-	Port       string
+	Port           string
+	StorageDriver  string
+	MigrateOnStart bool
+	PurgeRetention time.Duration
+	// ShutdownDelay es el tiempo entre marcar la instancia como no lista y dejar de aceptar conexiones
+	ShutdownDelay time.Duration
+	// ShutdownTimeout es el tiempo máximo para drenar las peticiones en curso al apagar el servidor
+	ShutdownTimeout time.Duration
+	// HealthCheckTimeout es el tiempo máximo de cada verificación de /readyz
+	HealthCheckTimeout time.Duration
+	// HealthCacheTTL es el tiempo durante el que se reutiliza el resultado de /readyz
+	HealthCacheTTL time.Duration
+	// MaxBodyBytes es el tamaño máximo del cuerpo de las peticiones
+	MaxBodyBytes int64
+	// MaxImportBytes es el tamaño máximo de los archivos de POST /users/import
+	MaxImportBytes int64
+	// EmailBlocklistFile es la ruta de la lista de dominios de email rechazados; vacía la deshabilita
+	EmailBlocklistFile string
+	// TracingExporter es el destino de las trazas de OpenTelemetry: none, stdout u otlp
+	TracingExporter string
+	// TracingSampleRatio es la fracción de trazas iniciadas por la API que se registran (0 a 1)
+	TracingSampleRatio float64
+	// LogFormat es el formato de los logs: json o text
+	LogFormat string
+	// LogLevel es el nivel mínimo de los logs
+	LogLevel   slog.Level
 	DBHost     string
 	DBPort     string
 	DBUser     string
 	DBPassword string
 	DBName     string
+	SQLitePath string
+	PGHost     string
+	PGPort     string
+	PGUser     string
+	PGPassword string
+	PGName     string
+	PGSSLMode  string
+	// DBMaxOpenConns es el máximo de conexiones abiertas del pool de MySQL y PostgreSQL; 0 = sin límite
+	DBMaxOpenConns int
 }
 
 // LoadConfig carga la configuración desde variables de entorno o valores por defecto
@@ -22,13 +68,70 @@ type Config struct {
 func LoadConfig() *Config {
 	// Intentar cargar .env (no falla si no existe)
 	if err := godotenv.Load(); err != nil {
-		log.Println("No se encontró archivo .env, usando variables de entorno del sistema")
+		slog.Info("No se encontró archivo .env, usando variables de entorno del sistema")
 	}
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
+	storageDriver := os.Getenv("STORAGE_DRIVER")
+	if storageDriver == "" {
+		storageDriver = StorageDriverMySQL
+	}
+
+	// Las migraciones se aplican al iniciar salvo que se deshabilite explícitamente
+	migrateOnStart := true
+	if value := os.Getenv("MIGRATE_ON_START"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			slog.Warn("Valor inválido en la configuración", "variable", "MIGRATE_ON_START", "value", value, "default", true)
+		} else {
+			migrateOnStart = parsed
+		}
+	}
+
+	// Tiempo que se conservan los usuarios eliminados antes de poder purgarlos
+	purgeRetention := durationFromEnv("PURGE_RETENTION", 30*24*time.Hour)
+
+	// Apagado ordenado: la suma de ambos debe ser menor al tiempo que el orquestador espera
+	// antes de forzar la terminación (10s en Docker, 30s en Kubernetes por defecto)
+	shutdownDelay := durationFromEnv("SHUTDOWN_DELAY", 0)
+	shutdownTimeout := durationFromEnv("SHUTDOWN_TIMEOUT", 20*time.Second)
+
+	// Verificaciones de /readyz: timeout de cada una y reutilización del resultado entre sondas
+	healthCheckTimeout := durationFromEnv("HEALTH_CHECK_TIMEOUT", 2*time.Second)
+	healthCacheTTL := durationFromEnv("HEALTH_CACHE_TTL", 5*time.Second)
+
+	// Tamaños máximos del cuerpo de las peticiones y de los archivos de importación, en bytes
+	maxBodyBytes := bytesFromEnv("MAX_BODY_BYTES", 1<<20)
+	maxImportBytes := bytesFromEnv("MAX_IMPORT_BYTES", 32<<20)
+
+	emailBlocklistFile := os.Getenv("EMAIL_BLOCKLIST_FILE")
+
+	// Trazas: el endpoint OTLP y el nombre del servicio se leen de las variables estándar
+	// OTEL_EXPORTER_OTLP_ENDPOINT y OTEL_SERVICE_NAME
+	tracingExporter := os.Getenv("TRACING_EXPORTER")
+	if tracingExporter == "" {
+		tracingExporter = "none"
+	}
+	tracingSampleRatio := ratioFromEnv("TRACING_SAMPLE_RATIO", 1)
+
+	// Logs: JSON para los sistemas de recolección, text para leerlos en la consola
+	logFormat := os.Getenv("LOG_FORMAT")
+	switch logFormat {
+	case "":
+		logFormat = "json"
+	case "json", "text":
+	default:
+		slog.Warn("Valor inválido en la configuración", "variable", "LOG_FORMAT", "value", logFormat, "default", "json")
+		logFormat = "json"
+	}
+	logLevel := levelFromEnv("LOG_LEVEL", slog.LevelInfo)
+
+	// Límite del pool de conexiones de MySQL y PostgreSQL; con él /readyz detecta la saturación
+	dbMaxOpenConns := countFromEnv("DB_MAX_OPEN_CONNS", 25)
+
 	dbHost := os.Getenv("DB_HOST")
 	if dbHost == "" {
 		dbHost = "localhost"
@@ -54,13 +157,67 @@ func LoadConfig() *Config {
 		dbName = "usersdb"
 	}
 
+	sqlitePath := os.Getenv("SQLITE_PATH")
+	if sqlitePath == "" {
+		sqlitePath = "usersdb.sqlite"
+	}
+
+	pgHost := os.Getenv("PG_HOST")
+	if pgHost == "" {
+		pgHost = "localhost"
+	}
+
+	pgPort := os.Getenv("PG_PORT")
+	if pgPort == "" {
+		pgPort = "5432"
+	}
+
+	pgUser := os.Getenv("PG_USER")
+	if pgUser == "" {
+		pgUser = "postgres"
+	}
+
+	pgPassword := os.Getenv("PG_PASSWORD")
+
+	pgName := os.Getenv("PG_NAME")
+	if pgName == "" {
+		pgName = "usersdb"
+	}
+
+	pgSSLMode := os.Getenv("PG_SSLMODE")
+	if pgSSLMode == "" {
+		pgSSLMode = "disable"
+	}
+
 	return &Config{
-		Port:       port,
-		DBHost:     dbHost,
-		DBPort:     dbPort,
-		DBUser:     dbUser,
-		DBPassword: dbPassword,
-		DBName:     dbName,
+		Port:               port,
+		StorageDriver:      storageDriver,
+		MigrateOnStart:     migrateOnStart,
+		PurgeRetention:     purgeRetention,
+		ShutdownDelay:      shutdownDelay,
+		ShutdownTimeout:    shutdownTimeout,
+		HealthCheckTimeout: healthCheckTimeout,
+		HealthCacheTTL:     healthCacheTTL,
+		MaxBodyBytes:       maxBodyBytes,
+		MaxImportBytes:     maxImportBytes,
+		EmailBlocklistFile: emailBlocklistFile,
+		TracingExporter:    tracingExporter,
+		TracingSampleRatio: tracingSampleRatio,
+		LogFormat:          logFormat,
+		LogLevel:           logLevel,
+		DBMaxOpenConns:     dbMaxOpenConns,
+		DBHost:             dbHost,
+		DBPort:             dbPort,
+		DBUser:             dbUser,
+		DBPassword:         dbPassword,
+		DBName:             dbName,
+		SQLitePath:         sqlitePath,
+		PGHost:             pgHost,
+		PGPort:             pgPort,
+		PGUser:             pgUser,
+		PGPassword:         pgPassword,
+		PGName:             pgName,
+		PGSSLMode:          pgSSLMode,
 	}
 }
 
@@ -68,3 +225,85 @@ func LoadConfig() *Config {
 func (c *Config) GetDSN() string {
 	return c.DBUser + ":" + c.DBPassword + "@tcp(" + c.DBHost + ":" + c.DBPort + ")/" + c.DBName + "?charset=utf8mb4&parseTime=True&loc=Local"
 }
+
+// GetPostgresDSN retorna el Data Source Name para PostgreSQL en formato URL
+func (c *Config) GetPostgresDSN() string {
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.PGUser, c.PGPassword),
+		Host:     fmt.Sprintf("%s:%s", c.PGHost, c.PGPort),
+		Path:     "/" + c.PGName,
+		RawQuery: url.Values{"sslmode": []string{c.PGSSLMode}}.Encode(),
+	}
+	return dsn.String()
+}
+
+// bytesFromEnv lee un tamaño en bytes de la variable name; si falta o no es un entero positivo usa fallback
+func bytesFromEnv(name string, fallback int64) int64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed <= 0 {
+		slog.Warn("Valor inválido en la configuración", "variable", name, "value", value, "default", fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// countFromEnv lee un entero no negativo de la variable name; si falta o es inválido usa fallback
+func countFromEnv(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < 0 {
+		slog.Warn("Valor inválido en la configuración", "variable", name, "value", value, "default", fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// durationFromEnv lee una duración Go (ej. "30s") de la variable name; si falta o es negativa usa fallback
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		slog.Warn("Valor inválido en la configuración", "variable", name, "value", value, "default", fallback.String())
+		return fallback
+	}
+	return parsed
+}
+
+// ratioFromEnv lee una fracción entre 0 y 1 de la variable name; si falta o está fuera de rango usa fallback
+func ratioFromEnv(name string, fallback float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed < 0 || parsed > 1 {
+		slog.Warn("Valor inválido en la configuración", "variable", name, "value", value, "default", fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// levelFromEnv lee un nivel de log (debug, info, warn o error) de la variable name; si falta o es inválido usa fallback
+func levelFromEnv(name string, fallback slog.Level) slog.Level {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		slog.Warn("Valor inválido en la configuración", "variable", name, "value", value, "default", fallback.String())
+		return fallback
+	}
+	return level
+}
@@ -25,34 +25,124 @@ const docTemplate = `{
     "paths": {
         "/users": {
             "get": {
-                "description": "Obtiene una lista de todos los usuarios registrados",
+                "description": "Obtiene una página de usuarios filtrada y ordenada (por defecto por fecha de creación descendente). Admite paginación por offset (limit/offset) o por cursor opaco (limit/cursor); el header Link incluye las páginas relacionadas. Con fields cada usuario incluye solo los campos indicados, y solo esas columnas se leen de la base de datos. Los parámetros desconocidos se rechazan con 400.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "text/csv",
+                    "application/msgpack"
                 ],
                 "tags": [
                     "usuarios"
                 ],
-                "summary": "Obtener todos los usuarios",
+                "summary": "Obtener usuarios paginados",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Tamaño de página (1-100, por defecto 20)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Cantidad de usuarios a omitir (no combinable con cursor)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Cursor opaco devuelto en next_cursor (requiere el mismo sort)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-created_at",
+                        "description": "Campo de orden: name, email, age, created_at o updated_at; prefijo '-' para descendente",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Prefijo del nombre (sin distinguir mayúsculas)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Email exacto (sin distinguir mayúsculas)",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Edad mínima (inclusive)",
+                        "name": "min_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Edad máxima (inclusive)",
+                        "name": "max_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Creados en esta fecha o después (RFC 3339)",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Creados antes de esta fecha (RFC 3339)",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Modificados en esta fecha o después (RFC 3339), para sincronización incremental",
+                        "name": "updated_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Incluir usuarios eliminados lógicamente (uso administrativo)",
+                        "name": "include_deleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Campos a incluir separados por comas (id, name, email, age, version, created_at, updated_at, deleted_at); por defecto todos",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/models.UserListResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Enlaces first, prev, next y last (RFC 8288)"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     }
                 }
@@ -63,7 +153,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "text/csv",
+                    "application/msgpack"
                 ],
                 "tags": [
                     "usuarios"
@@ -85,37 +178,269 @@ const docTemplate = `{
                         "description": "Created",
                         "schema": {
                             "$ref": "#/definitions/models.User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Versión del usuario creado"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/export": {
+            "get": {
+                "description": "Descarga todos los usuarios que cumplen los filtros, en CSV (por defecto) o NDJSON. Las filas se leen del repositorio y se envían a medida que se escriben, sin cargar el listado en memoria. En CSV, los valores que empiezan con =, +, -, @ se prefijan con ' para que las planillas no los evalúen como fórmulas. Si la exportación falla después de empezar a enviarse, la conexión se corta para que el archivo parcial no parezca completo.",
+                "produces": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "usuarios"
+                ],
+                "summary": "Exportar usuarios",
+                "parameters": [
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "default": "csv",
+                        "description": "Formato del archivo",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "-created_at",
+                        "description": "Campo de orden: name, email, age, created_at o updated_at; prefijo '-' para descendente",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Prefijo del nombre (sin distinguir mayúsculas)",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Email exacto (sin distinguir mayúsculas)",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Edad mínima (inclusive)",
+                        "name": "min_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Edad máxima (inclusive)",
+                        "name": "max_age",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Creados en esta fecha o después (RFC 3339)",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Creados antes de esta fecha (RFC 3339)",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Modificados en esta fecha o después (RFC 3339)",
+                        "name": "updated_since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Incluir usuarios eliminados lógicamente",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        },
+                        "headers": {
+                            "Content-Disposition": {
+                                "type": "string",
+                                "description": "attachment; filename=\\\"users.csv\\"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/import": {
+            "post": {
+                "description": "Crea los usuarios de un archivo CSV (con cabecera) o NDJSON según el Content-Type, validando cada fila con las mismas reglas que POST /users. Las filas inválidas se informan con su número de línea y no impiden importar las demás. Las columnas id, version, created_at, updated_at y deleted_at de una exportación se ignoran, y en CSV se quita el ' que la exportación antepone a los valores que empiezan con =, +, -, @. Con dry_run=true solo se validan las filas, sin crear usuarios.",
+                "consumes": [
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "usuarios"
+                ],
+                "summary": "Importar usuarios",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Validar sin crear usuarios",
+                        "name": "dry_run",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Archivo CSV o NDJSON",
+                        "name": "file",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/purge": {
+            "post": {
+                "description": "Elimina definitivamente los usuarios eliminados hace más que la retención configurada (PURGE_RETENTION)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "usuarios"
+                ],
+                "summary": "Purgar usuarios eliminados",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.PurgeResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
                     }
                 }
             }
         },
         "/users/{id}": {
             "get": {
-                "description": "Obtiene la información de un usuario específico",
+                "description": "Obtiene la información de un usuario específico. El header ETag identifica su versión en el formato y la selección de campos de la respuesta, y puede enviarse en If-Match al modificarlo. Con fields la respuesta incluye solo los campos indicados, y solo esas columnas se leen de la base de datos.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "text/csv",
+                    "application/msgpack"
                 ],
                 "tags": [
                     "usuarios"
@@ -128,6 +453,12 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Campos a incluir separados por comas (id, name, email, age, version, created_at, updated_at, deleted_at); por defecto todos",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -135,40 +466,49 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/models.User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Versión del usuario"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     }
                 }
             },
             "put": {
-                "description": "Actualiza la información de un usuario existente (actualización parcial)",
+                "description": "Reemplaza todos los datos editables de un usuario existente; los campos omitidos no conservan su valor anterior. Para modificaciones parciales se usa PATCH. Con If-Match solo se aplica si el ETag coincide con la versión actual.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "text/csv",
+                    "application/msgpack"
                 ],
                 "tags": [
                     "usuarios"
                 ],
-                "summary": "Actualizar un usuario",
+                "summary": "Reemplazar un usuario",
                 "parameters": [
                     {
                         "type": "string",
@@ -178,12 +518,18 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Datos a actualizar",
+                        "type": "string",
+                        "description": "ETag obtenido al leer el usuario",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Datos completos del usuario",
                         "name": "user",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateUserRequest"
+                            "$ref": "#/definitions/models.ReplaceUserRequest"
                         }
                     }
                 ],
@@ -192,44 +538,73 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/models.User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Nueva versión del usuario"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Elimina un usuario del sistema",
+                "description": "Elimina lógicamente un usuario; puede restaurarse hasta que se purgue. Con If-Match solo se elimina si el ETag coincide con la versión actual.",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/xml",
+                    "application/msgpack"
                 ],
                 "tags": [
                     "usuarios"
@@ -242,84 +617,811 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag obtenido al leer el usuario",
+                        "name": "If-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/models.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Aplica un JSON Merge Patch (RFC 7396, application/merge-patch+json) o un JSON Patch (RFC 6902, application/json-patch+json) sobre la representación del usuario. Solo pueden modificarse name, email y age; el resto de los campos admite operaciones test. El resultado se valida igual que en PUT. Con If-Match solo se aplica si el ETag coincide con la versión actual.",
+                "consumes": [
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "text/csv",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "usuarios"
+                ],
+                "summary": "Modificar parcialmente un usuario",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag obtenido al leer el usuario",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Merge patch (objeto) o JSON Patch (lista de operaciones)",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Nueva versión del usuario"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/restore": {
+            "post": {
+                "description": "Revierte la eliminación lógica de un usuario que aún no fue purgado",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "text/csv",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "usuarios"
+                ],
+                "summary": "Restaurar un usuario",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID del usuario",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.User"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Versión del usuario restaurado"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users:batch": {
+            "post": {
+                "description": "Crea hasta 1000 usuarios validando cada uno como en POST /users. En modo atomic (por defecto) se crean todos o ninguno; en modo partial se crean los válidos. Responde 200 si todos se aplicaron y 207 si alguno falló, con el resultado de cada elemento en el orden de la petición; los elementos no aplicados por el fallo de otro tienen status 424.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "usuarios"
+                ],
+                "summary": "Crear usuarios por lotes",
+                "parameters": [
+                    {
+                        "description": "Usuarios a crear",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Multi-Status",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "models.CreateUserRequest": {
-            "description": "Datos requeridos para crear un nuevo usuario",
-            "type": "object",
-            "required": [
-                "age",
-                "email",
-                "name"
-            ],
-            "properties": {
-                "age": {
-                    "description": "Edad del usuario",
-                    "type": "integer",
-                    "example": 30
-                },
-                "email": {
-                    "description": "Email del usuario",
-                    "type": "string",
-                    "example": "juan@example.com"
-                },
-                "name": {
-                    "description": "Nombre del usuario",
-                    "type": "string",
-                    "example": "Juan Pérez"
-                }
             }
         },
-        "models.UpdateUserRequest": {
-            "description": "Datos opcionales para actualizar un usuario (actualización parcial)",
+        "/users:batchDelete": {
+            "post": {
+                "description": "Elimina lógicamente hasta 1000 usuarios; version es opcional y funciona como If-Match. En modo atomic (por defecto) se eliminan todos o ninguno; en modo partial cada uno por separado. Responde 200 si todos se aplicaron y 207 si alguno falló.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "usuarios"
+                ],
+                "summary": "Eliminar usuarios por lotes",
+                "parameters": [
+                    {
+                        "description": "Usuarios a eliminar",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchDeleteRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Multi-Status",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/users:batchUpdate": {
+            "post": {
+                "description": "Reemplaza hasta 1000 usuarios con las mismas reglas que PUT /users/{id}; version es opcional y funciona como If-Match. En modo atomic (por defecto) las escrituras se aplican en una única transacción; en modo partial cada una por separado. Responde 200 si todos se aplicaron y 207 si alguno falló.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/xml",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "usuarios"
+                ],
+                "summary": "Reemplazar usuarios por lotes",
+                "parameters": [
+                    {
+                        "description": "Usuarios a reemplazar",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchReplaceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchResponse"
+                        }
+                    },
+                    "207": {
+                        "description": "Multi-Status",
+                        "schema": {
+                            "$ref": "#/definitions/models.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apperrors.FieldError": {
+            "description": "Error de validación de un campo",
+            "type": "object",
+            "properties": {
+                "field": {
+                    "description": "Ruta del campo (ej. \"email\")",
+                    "type": "string",
+                    "example": "email"
+                },
+                "message": {
+                    "description": "Descripción legible",
+                    "type": "string",
+                    "example": "es obligatorio"
+                },
+                "rule": {
+                    "description": "Regla incumplida",
+                    "type": "string",
+                    "example": "required"
+                }
+            }
+        },
+        "apperrors.Problem": {
+            "description": "Error de la API (application/problem+json)",
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Código estable para clientes",
+                    "type": "string",
+                    "example": "user_not_found"
+                },
+                "detail": {
+                    "description": "Explicación de esta ocurrencia",
+                    "type": "string",
+                    "example": "usuario no encontrado"
+                },
+                "errors": {
+                    "description": "Errores por campo",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apperrors.FieldError"
+                    }
+                },
+                "instance": {
+                    "description": "Recurso de la petición",
+                    "type": "string",
+                    "example": "/api/v1/users/42"
+                },
+                "status": {
+                    "description": "Status HTTP",
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "description": "Resumen del status HTTP",
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "trace_id": {
+                    "description": "Identificador para correlacionar con los logs",
+                    "type": "string",
+                    "example": "3f2b9c0e4a1d4e8f"
+                },
+                "type": {
+                    "description": "Identificador del tipo de problema",
+                    "type": "string",
+                    "example": "/problems/user_not_found"
+                }
+            }
+        },
+        "models.BatchCreateRequest": {
+            "description": "Usuarios a crear y modo de aplicación",
             "type": "object",
+            "properties": {
+                "mode": {
+                    "description": "atomic (por defecto) o partial",
+                    "enum": [
+                        "atomic",
+                        "partial"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.BatchMode"
+                        }
+                    ],
+                    "example": "atomic"
+                },
+                "users": {
+                    "description": "Usuarios a crear (máximo 1000)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CreateUserRequest"
+                    }
+                }
+            }
+        },
+        "models.BatchDeleteRequest": {
+            "description": "Usuarios a eliminar y modo de aplicación",
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "description": "atomic (por defecto) o partial",
+                    "enum": [
+                        "atomic",
+                        "partial"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.BatchMode"
+                        }
+                    ],
+                    "example": "atomic"
+                },
+                "users": {
+                    "description": "Usuarios a eliminar (máximo 1000)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UserRef"
+                    }
+                }
+            }
+        },
+        "models.BatchItemResponse": {
+            "description": "Resultado de un elemento: el usuario afectado o el error",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Error del elemento",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    ]
+                },
+                "id": {
+                    "description": "ID del usuario afectado",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "index": {
+                    "description": "Posición del elemento en la petición",
+                    "type": "integer",
+                    "example": 0
+                },
+                "status": {
+                    "description": "Status HTTP equivalente a la operación individual",
+                    "type": "integer",
+                    "example": 201
+                },
+                "user": {
+                    "description": "Usuario resultante (creación y reemplazo)",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.BatchMode": {
+            "type": "string",
+            "enum": [
+                "atomic",
+                "partial"
+            ],
+            "x-enum-comments": {
+                "BatchModeAtomic": "Se aplican todos los elementos o ninguno",
+                "BatchModePartial": "Cada elemento se aplica por separado"
+            },
+            "x-enum-varnames": [
+                "BatchModeAtomic",
+                "BatchModePartial"
+            ]
+        },
+        "models.BatchReplaceRequest": {
+            "description": "Usuarios a reemplazar y modo de aplicación",
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "description": "atomic (por defecto) o partial",
+                    "enum": [
+                        "atomic",
+                        "partial"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.BatchMode"
+                        }
+                    ],
+                    "example": "atomic"
+                },
+                "users": {
+                    "description": "Usuarios a reemplazar (máximo 1000)",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ReplaceUserItem"
+                    }
+                }
+            }
+        },
+        "models.BatchResponse": {
+            "description": "Resultado por elemento de una operación por lotes",
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "description": "Elementos no aplicados",
+                    "type": "integer",
+                    "example": 0
+                },
+                "mode": {
+                    "description": "Modo aplicado",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.BatchMode"
+                        }
+                    ],
+                    "example": "atomic"
+                },
+                "results": {
+                    "description": "Resultados en el orden de la petición",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.BatchItemResponse"
+                    }
+                },
+                "succeeded": {
+                    "description": "Elementos aplicados",
+                    "type": "integer",
+                    "example": 2
+                }
+            }
+        },
+        "models.CreateUserRequest": {
+            "description": "Datos requeridos para crear un nuevo usuario",
+            "type": "object",
+            "required": [
+                "age",
+                "email",
+                "name"
+            ],
             "properties": {
                 "age": {
-                    "description": "Nueva edad (opcional)",
+                    "description": "Edad del usuario",
                     "type": "integer",
+                    "maximum": 150,
+                    "minimum": 1,
+                    "example": 30
+                },
+                "email": {
+                    "description": "Email del usuario",
+                    "type": "string",
+                    "maxLength": 254,
+                    "example": "juan@example.com"
+                },
+                "name": {
+                    "description": "Nombre del usuario",
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Juan Pérez"
+                }
+            }
+        },
+        "models.ImportReport": {
+            "description": "Resultado de una importación con los errores por fila",
+            "type": "object",
+            "properties": {
+                "dry_run": {
+                    "description": "Si solo se validaron las filas",
+                    "type": "boolean",
+                    "example": false
+                },
+                "errors": {
+                    "description": "Errores por fila, ordenados por línea",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImportRowError"
+                    }
+                },
+                "failed": {
+                    "description": "Filas con errores",
+                    "type": "integer",
+                    "example": 1
+                },
+                "imported": {
+                    "description": "Filas creadas (o válidas, con dry_run)",
+                    "type": "integer",
+                    "example": 2
+                },
+                "rows": {
+                    "description": "Filas de datos leídas",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.ImportRowError": {
+            "description": "Error de una fila de una importación",
+            "type": "object",
+            "properties": {
+                "error": {
+                    "description": "Error de la fila",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/apperrors.Problem"
+                        }
+                    ]
+                },
+                "line": {
+                    "description": "Línea del archivo (en CSV la cabecera es la línea 1)",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.MessageResponse": {
+            "description": "Mensaje sobre el resultado de la operación",
+            "type": "object",
+            "properties": {
+                "message": {
+                    "description": "Mensaje informativo",
+                    "type": "string",
+                    "example": "usuario eliminado correctamente"
+                }
+            }
+        },
+        "models.PurgeResponse": {
+            "description": "Cantidad de usuarios purgados",
+            "type": "object",
+            "properties": {
+                "purged": {
+                    "description": "Usuarios borrados definitivamente",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.ReplaceUserItem": {
+            "description": "Usuario a reemplazar en una operación por lotes",
+            "type": "object",
+            "required": [
+                "age",
+                "email",
+                "name"
+            ],
+            "properties": {
+                "age": {
+                    "description": "Edad del usuario",
+                    "type": "integer",
+                    "maximum": 150,
+                    "minimum": 1,
+                    "example": 31
+                },
+                "email": {
+                    "description": "Email del usuario",
+                    "type": "string",
+                    "maxLength": 254,
+                    "example": "juan@example.com"
+                },
+                "id": {
+                    "description": "ID del usuario",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "name": {
+                    "description": "Nombre del usuario",
+                    "type": "string",
+                    "maxLength": 100,
+                    "example": "Juan Pérez"
+                },
+                "version": {
+                    "description": "Versión esperada (omitida = cualquiera)",
+                    "type": "integer",
+                    "example": 3
+                }
+            }
+        },
+        "models.ReplaceUserRequest": {
+            "description": "Datos completos del usuario; los campos omitidos no conservan su valor anterior",
+            "type": "object",
+            "required": [
+                "age",
+                "email",
+                "name"
+            ],
+            "properties": {
+                "age": {
+                    "description": "Edad del usuario",
+                    "type": "integer",
+                    "maximum": 150,
+                    "minimum": 1,
                     "example": 31
                 },
                 "email": {
-                    "description": "Nuevo email (opcional)",
+                    "description": "Email del usuario",
                     "type": "string",
+                    "maxLength": 254,
                     "example": "juan@example.com"
                 },
                 "name": {
-                    "description": "Nuevo nombre (opcional)",
+                    "description": "Nombre del usuario",
                     "type": "string",
+                    "maxLength": 100,
                     "example": "Juan Pérez"
                 }
             }
@@ -333,6 +1435,16 @@ const docTemplate = `{
                     "type": "integer",
                     "example": 30
                 },
+                "created_at": {
+                    "description": "Fecha de alta del usuario",
+                    "type": "string",
+                    "example": "2024-01-15T09:30:00Z"
+                },
+                "deleted_at": {
+                    "description": "Fecha de eliminación lógica (solo usuarios eliminados)",
+                    "type": "string",
+                    "example": "2024-01-31T10:00:00Z"
+                },
                 "email": {
                     "description": "Email del usuario",
                     "type": "string",
@@ -347,6 +1459,65 @@ const docTemplate = `{
                     "description": "Nombre del usuario",
                     "type": "string",
                     "example": "Juan Pérez"
+                },
+                "updated_at": {
+                    "description": "Fecha de la última modificación",
+                    "type": "string",
+                    "example": "2024-01-20T18:45:00Z"
+                },
+                "version": {
+                    "description": "Versión del usuario; se incrementa en cada modificación",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "models.UserListResponse": {
+            "description": "Página de usuarios con metadatos de paginación",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Usuarios de la página",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.User"
+                    }
+                },
+                "limit": {
+                    "description": "Tamaño de página aplicado",
+                    "type": "integer",
+                    "example": 20
+                },
+                "next_cursor": {
+                    "description": "Cursor de la página siguiente",
+                    "type": "string",
+                    "example": "eyJjIjoi"
+                },
+                "offset": {
+                    "description": "Offset aplicado (solo paginación por offset)",
+                    "type": "integer",
+                    "example": 0
+                },
+                "total": {
+                    "description": "Total de usuarios",
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "models.UserRef": {
+            "description": "Referencia a un usuario para operaciones por lotes",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "ID del usuario",
+                    "type": "string",
+                    "example": "550e8400-e29b-41d4-a716-446655440000"
+                },
+                "version": {
+                    "description": "Versión esperada (omitida = cualquiera)",
+                    "type": "integer",
+                    "example": 3
                 }
             }
         }
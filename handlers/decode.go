@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"helloworld/apperrors"
+	"helloworld/validation"
+)
+
+// DefaultMaxBodyBytes es el tamaño máximo por defecto del cuerpo de una petición
+const DefaultMaxBodyBytes int64 = 1 << 20
+
+// jsonContentType es el media type exigido a los cuerpos JSON
+const jsonContentType = "application/json"
+
+// requestDecoder lee los cuerpos de las peticiones aplicando las mismas reglas en todos los handlers:
+// tamaño máximo (413), Content-Type admitido (415) y, para JSON, un único valor sin campos desconocidos
+type requestDecoder struct {
+	maxBodyBytes int64
+}
+
+// decodeJSON decodifica el cuerpo JSON de r en dst. Los errores son *apperrors.Error listos para
+// responder; los de formato indican el byte o el campo que los causó.
+func (d requestDecoder) decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	if err := requireContentType(r, jsonContentType); err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, d.maxBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		return d.decodeError(err)
+	}
+	// Cualquier contenido tras el primer valor (salvo espacios) se rechaza
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return d.decodeError(err)
+		}
+		return invalidBody(fmt.Sprintf("el cuerpo debe contener un único valor JSON; hay contenido adicional en el byte %d", decoder.InputOffset()))
+	}
+	return nil
+}
+
+// readBody lee el cuerpo completo de r, que debe tener alguno de los media types indicados
+func (d requestDecoder) readBody(w http.ResponseWriter, r *http.Request, mediaTypes ...string) ([]byte, error) {
+	if err := requireContentType(r, mediaTypes...); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, d.maxBodyBytes))
+	if err != nil {
+		return nil, d.decodeError(err)
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, invalidBody("el cuerpo de la petición está vacío")
+	}
+	return body, nil
+}
+
+// decodeError traduce un error de lectura o de encoding/json a un error de la API
+func (d requestDecoder) decodeError(err error) error {
+	var (
+		maxBytesErr  *http.MaxBytesError
+		syntaxErr    *json.SyntaxError
+		typeErr      *json.UnmarshalTypeError
+		maxBodyBytes = strconv.FormatInt(d.maxBodyBytes, 10)
+	)
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return apperrors.New(http.StatusRequestEntityTooLarge, apperrors.CodeBodyTooLarge,
+			"el cuerpo de la petición supera el máximo de "+maxBodyBytes+" bytes")
+	case errors.Is(err, io.EOF):
+		return invalidBody("el cuerpo de la petición está vacío")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return invalidBody("el cuerpo de la petición es un JSON incompleto")
+	case errors.As(err, &syntaxErr):
+		return invalidBody(fmt.Sprintf("JSON mal formado en el byte %d: %v", syntaxErr.Offset, syntaxErr))
+	case errors.As(err, &typeErr):
+		apiErr := invalidBody(fmt.Sprintf("valor de tipo inválido en el byte %d", typeErr.Offset))
+		apiErr.Fields = []apperrors.FieldError{{
+			Field:   typeErr.Field,
+			Rule:    validation.RuleType,
+			Message: "debe ser de tipo " + jsonTypeName(typeErr.Type),
+		}}
+		return apiErr
+	}
+
+	// encoding/json no expone un tipo para los campos desconocidos
+	if field, found := strings.CutPrefix(err.Error(), "json: unknown field "); found {
+		if unquoted, unquoteErr := strconv.Unquote(field); unquoteErr == nil {
+			field = unquoted
+		}
+		apiErr := invalidBody(fmt.Sprintf("campo desconocido %q", field))
+		apiErr.Fields = []apperrors.FieldError{{Field: field, Rule: validation.RuleUnknown, Message: "no es un campo admitido"}}
+		return apiErr
+	}
+
+	return &apperrors.Error{
+		Status:  http.StatusBadRequest,
+		Code:    apperrors.CodeInvalidBody,
+		Message: errDecodeBody.Error(),
+		Err:     fmt.Errorf("%w: %v", errDecodeBody, err),
+	}
+}
+
+// requireContentType verifica que el Content-Type de r sea alguno de mediaTypes (ignorando parámetros como charset)
+func requireContentType(r *http.Request, mediaTypes ...string) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err == nil {
+		for _, allowed := range mediaTypes {
+			if mediaType == allowed {
+				return nil
+			}
+		}
+	}
+	return unsupportedMediaType(mediaTypes...)
+}
+
+// unsupportedMediaType retorna el error 415 que informa los media types admitidos
+func unsupportedMediaType(mediaTypes ...string) *apperrors.Error {
+	return apperrors.New(http.StatusUnsupportedMediaType, apperrors.CodeUnsupportedMediaType,
+		"el Content-Type debe ser "+strings.Join(mediaTypes, " o "))
+}
+
+// invalidBody crea un error 400 por un cuerpo de petición inválido
+func invalidBody(message string) *apperrors.Error {
+	return &apperrors.Error{Status: http.StatusBadRequest, Code: apperrors.CodeInvalidBody, Message: message, Err: errDecodeBody}
+}
+
+// jsonTypeName traduce el tipo de Go esperado al nombre del tipo JSON correspondiente
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "texto"
+	case reflect.Bool:
+		return "booleano"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "número"
+	case reflect.Slice, reflect.Array:
+		return "lista"
+	default:
+		return "objeto"
+	}
+}
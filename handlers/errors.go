@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"helloworld/apperrors"
+	"helloworld/logging"
+	"helloworld/models"
+	"helloworld/repositories"
+	"helloworld/services"
+	"helloworld/validation"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// statusClientClosedRequest es el status (no estándar) con el que se registran las peticiones
+// que el cliente canceló antes de recibir la respuesta
+const statusClientClosedRequest = 499
+
+// errorMappings traduce los errores de las capas inferiores a errores de la API.
+// Es el único lugar donde se decide el status HTTP de cada error de dominio.
+var errorMappings = []struct {
+	target error
+	status int
+	code   string
+}{
+	{repositories.ErrUserNotFound, http.StatusNotFound, apperrors.CodeUserNotFound},
+	{repositories.ErrEmailAlreadyExists, http.StatusConflict, apperrors.CodeEmailAlreadyExists},
+	{repositories.ErrVersionConflict, http.StatusConflict, apperrors.CodeVersionConflict},
+	{services.ErrInvalidLimit, http.StatusBadRequest, apperrors.CodeInvalidLimit},
+	{services.ErrInvalidOffset, http.StatusBadRequest, apperrors.CodeInvalidOffset},
+	{services.ErrInvalidAgeRange, http.StatusBadRequest, apperrors.CodeInvalidAgeRange},
+	{services.ErrInvalidDateRange, http.StatusBadRequest, apperrors.CodeInvalidDateRange},
+	{services.ErrInvalidBatchSize, http.StatusBadRequest, apperrors.CodeInvalidBatch},
+	{services.ErrInvalidBatchMode, http.StatusBadRequest, apperrors.CodeInvalidBatch},
+	{services.ErrBatchAborted, http.StatusFailedDependency, apperrors.CodeBatchAborted},
+	{models.ErrInvalidSort, http.StatusBadRequest, apperrors.CodeInvalidSort},
+	{models.ErrInvalidCursor, http.StatusBadRequest, apperrors.CodeInvalidCursor},
+	{models.ErrInvalidFields, http.StatusBadRequest, apperrors.CodeInvalidFields},
+	{errDecodeBody, http.StatusBadRequest, apperrors.CodeInvalidBody},
+	{errInvalidLimitParam, http.StatusBadRequest, apperrors.CodeInvalidLimit},
+	{errInvalidOffsetParam, http.StatusBadRequest, apperrors.CodeInvalidOffset},
+	{errMultipleETags, http.StatusBadRequest, apperrors.CodeInvalidIfMatch},
+	{errETagMismatch, http.StatusPreconditionFailed, apperrors.CodePreconditionFailed},
+	{errInvalidPatch, http.StatusBadRequest, apperrors.CodeInvalidPatch},
+	{errPatchConflict, http.StatusConflict, apperrors.CodePatchConflict},
+	{context.DeadlineExceeded, http.StatusGatewayTimeout, apperrors.CodeTimeout},
+	{context.Canceled, statusClientClosedRequest, apperrors.CodeRequestCanceled},
+}
+
+// toAPIError clasifica err en un error de la API. Los errores no reconocidos se
+// responden como 500 con un mensaje genérico para no exponer detalles internos.
+func toAPIError(r *http.Request, err error) *apperrors.Error {
+	var apiErr *apperrors.Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	// Se evalúa antes que errorMappings: validation.Errors también envuelve ErrInvalidName y similares
+	var violations validation.Errors
+	if errors.As(err, &violations) {
+		return validationError(violations)
+	}
+
+	// Un conflicto de versión ante un If-Match explícito es una precondición fallida
+	if errors.Is(err, repositories.ErrVersionConflict) && r.Header.Get("If-Match") != "" {
+		return &apperrors.Error{
+			Status:  http.StatusPreconditionFailed,
+			Code:    apperrors.CodePreconditionFailed,
+			Message: errETagMismatch.Error(),
+			Err:     err,
+		}
+	}
+
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.target) {
+			return &apperrors.Error{Status: mapping.status, Code: mapping.code, Message: mapping.target.Error(), Err: err}
+		}
+	}
+
+	return &apperrors.Error{
+		Status:  http.StatusInternalServerError,
+		Code:    apperrors.CodeInternal,
+		Message: "error interno del servidor",
+		Err:     err,
+	}
+}
+
+// validationError convierte las violaciones de validación en un error 422 con el detalle por campo
+func validationError(violations validation.Errors) *apperrors.Error {
+	fields := make([]apperrors.FieldError, 0, len(violations))
+	for _, violation := range violations {
+		fields = append(fields, apperrors.FieldError{Field: violation.Field, Rule: violation.Rule, Message: violation.Message})
+	}
+	return &apperrors.Error{
+		Status:  http.StatusUnprocessableEntity,
+		Code:    apperrors.CodeValidationFailed,
+		Message: "la petición contiene campos inválidos",
+		Fields:  fields,
+		Err:     violations,
+	}
+}
+
+// requestTraceID retorna el identificador con el que se correlaciona la respuesta de error
+// con los logs: el de la petición (asignado por middleware.RequestIDMiddleware), el X-Request-ID
+// recibido, el id de la traza de la petición o uno nuevo
+func requestTraceID(r *http.Request) string {
+	if id := logging.RequestID(r.Context()); id != "" {
+		return id
+	}
+	if id := r.Header.Get("X-Request-ID"); id != "" {
+		return id
+	}
+	if span := trace.SpanContextFromContext(r.Context()); span.HasTraceID() {
+		return span.TraceID().String()
+	}
+	return uuid.NewString()
+}
+
+// respondWithError envía err como application/problem+json (RFC 7807), o como application/problem+xml
+// si el cliente prefiere XML
+func respondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := toAPIError(r, err)
+	traceID := requestTraceID(r)
+
+	// El log lleva el mismo request_id que el trace_id de la respuesta
+	ctx := r.Context()
+	if logging.RequestID(ctx) == "" {
+		ctx = logging.WithRequestID(ctx, traceID)
+	}
+	level, message := slog.LevelDebug, "Petición rechazada"
+	if apiErr.Status >= http.StatusInternalServerError {
+		level, message = slog.LevelError, "Error al atender la petición"
+	}
+	slog.Log(ctx, level, message,
+		"method", r.Method,
+		"path", r.URL.Path,
+		"status", apiErr.Status,
+		"code", apiErr.Code,
+		"error", err.Error(),
+	)
+
+	problem := apiErr.Problem(r.URL.Path, traceID)
+	varyAccept(w)
+	if prefersProblemXML(r) {
+		w.Header().Set("Content-Type", apperrors.ProblemXMLContentType)
+		w.WriteHeader(apiErr.Status)
+		// nolint:errcheck // Error de escritura en respuesta HTTP, no hay recuperación posible
+		_, _ = io.WriteString(w, xml.Header)
+		root := xml.StartElement{Name: xml.Name{Space: problemXMLNamespace, Local: "problem"}}
+		// nolint:errcheck // Error de escritura en respuesta HTTP, no hay recuperación posible
+		_ = xml.NewEncoder(w).EncodeElement(problem, root)
+		return
+	}
+
+	w.Header().Set("Content-Type", apperrors.ProblemContentType)
+	w.WriteHeader(apiErr.Status)
+	// nolint:errcheck // Error de escritura en respuesta HTTP, no hay recuperación posible
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+// NotFound responde las rutas inexistentes con el mismo formato que el resto de los errores
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	respondWithError(w, r, apperrors.New(http.StatusNotFound, apperrors.CodeRouteNotFound, "ruta no encontrada"))
+}
+
+// MethodNotAllowed responde los métodos no soportados por una ruta existente
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	respondWithError(w, r, apperrors.New(http.StatusMethodNotAllowed, apperrors.CodeMethodNotAllowed, "método no permitido para esta ruta"))
+}
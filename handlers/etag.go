@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"helloworld/models"
+)
+
+var (
+	// errETagMismatch indica que el If-Match recibido no puede coincidir con ninguna versión
+	errETagMismatch = errors.New("el ETag de If-Match no coincide con la versión actual del usuario")
+	// errMultipleETags indica un If-Match con más de un ETag, que no se admite
+	errMultipleETags = errors.New("If-Match admite un único ETag o *")
+)
+
+// userETag retorna el ETag fuerte de una representación de la versión de un usuario. Cada formato
+// y selección de campos es una representación distinta (RFC 9110, sección 8.8.1), por lo que a la
+// versión se le agrega un resumen del media type y de los campos: "<versión>-<resumen>".
+func userETag(version int64, mediaType string, fields models.UserFields) string {
+	hash := fnv.New32a()
+	_, _ = io.WriteString(hash, mediaType+";"+fields.OrAll().String()) // nolint:errcheck // hash.Hash nunca retorna error
+	return fmt.Sprintf(`"%d-%08x"`, version, hash.Sum32())
+}
+
+// respondUser envía user, limitado a fields si no está vacía, con el ETag de la representación elegida
+func (h *UserHandler) respondUser(w http.ResponseWriter, r *http.Request, statusCode int, user *models.User, fields models.UserFields) {
+	var payload interface{} = user
+	if len(fields) > 0 {
+		payload = fields.Project(user)
+	}
+	h.respondAs(w, r, statusCode, payload, func(mediaType string) {
+		w.Header().Set("ETag", userETag(user.Version, mediaType, fields))
+	})
+}
+
+// ifMatchVersion retorna la versión exigida por el header If-Match.
+// Sin header o con "*" retorna models.AnyVersion. If-Match usa comparación fuerte (RFC 9110),
+// por lo que un ETag débil o ajeno a este servicio retorna errETagMismatch. Solo se compara la
+// versión: el ETag de cualquier representación de la versión actual habilita la escritura.
+func ifMatchVersion(r *http.Request) (int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return models.AnyVersion, nil
+	}
+	if strings.Contains(header, ",") {
+		return 0, errMultipleETags
+	}
+
+	if !strings.HasPrefix(header, `"`) || !strings.HasSuffix(header, `"`) || len(header) < 2 {
+		return 0, errETagMismatch
+	}
+	tag, _, _ := strings.Cut(header[1:len(header)-1], "-")
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, errETagMismatch
+	}
+	return version, nil
+}
+
+// parseIfMatch lee la versión exigida por If-Match y responde el error si el header no es válido.
+// Retorna false si ya se respondió la petición.
+func parseIfMatch(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		respondWithError(w, r, err)
+		return 0, false
+	}
+	return version, true
+}
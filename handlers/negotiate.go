@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"helloworld/apperrors"
+	"helloworld/models"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Media types de las respuestas negociables
+const (
+	xmlContentType     = "application/xml"
+	msgpackContentType = "application/msgpack"
+)
+
+// problemXMLNamespace es el espacio de nombres de application/problem+xml (RFC 7807, apéndice A)
+const problemXMLNamespace = "urn:ietf:rfc:7807"
+
+// ErrUnsupportedPayload indica que un Encoder no puede representar una respuesta
+var ErrUnsupportedPayload = errors.New("el formato no puede representar esta respuesta")
+
+// Encoder serializa las respuestas de la API en un media type. Para cada respuesta se usa el
+// primero, según el header Accept, que puede representarla.
+type Encoder interface {
+	// MediaType es el media type producido, usado en la negociación y en el header Content-Type
+	MediaType() string
+	// Encode escribe v en w, o retorna ErrUnsupportedPayload si el formato no puede representarlo
+	Encode(w io.Writer, v interface{}) error
+}
+
+// defaultEncoders retorna los formatos incluidos en orden de preferencia del servidor:
+// JSON se usa cuando Accept falta o admite cualquier formato
+func defaultEncoders() []Encoder {
+	return []Encoder{jsonEncoder{}, xmlEncoder{}, csvEncoder{}, msgpackEncoder{}}
+}
+
+// WithEncoder agrega un formato de respuesta, o reemplaza al que produce el mismo media type
+func WithEncoder(encoder Encoder) Option {
+	return func(h *UserHandler) {
+		for i, current := range h.encoders {
+			if current.MediaType() == encoder.MediaType() {
+				h.encoders[i] = encoder
+				return
+			}
+		}
+		h.encoders = append(h.encoders, encoder)
+	}
+}
+
+// respond envía payload con status en el formato que prefiere el header Accept, o 406 si
+// ningún formato aceptable puede representarlo
+func (h *UserHandler) respond(w http.ResponseWriter, r *http.Request, statusCode int, payload interface{}) {
+	h.respondAs(w, r, statusCode, payload, nil)
+}
+
+// respondAs es respond con beforeWrite, que recibe el media type elegido antes de enviar los
+// headers, para los headers que dependen de la representación (como el ETag)
+func (h *UserHandler) respondAs(w http.ResponseWriter, r *http.Request, statusCode int, payload interface{}, beforeWrite func(mediaType string)) {
+	varyAccept(w)
+	accept := parseAccept(r.Header.Get("Accept"))
+
+	for _, encoder := range acceptableEncoders(accept, h.encoders) {
+		var body bytes.Buffer
+		err := encoder.Encode(&body, payload)
+		if errors.Is(err, ErrUnsupportedPayload) {
+			continue
+		}
+		if err != nil {
+			respondWithError(w, r, fmt.Errorf("error al codificar respuesta como %s: %w", encoder.MediaType(), err))
+			return
+		}
+
+		if beforeWrite != nil {
+			beforeWrite(encoder.MediaType())
+		}
+		w.Header().Set("Content-Type", contentTypeHeader(encoder.MediaType()))
+		w.WriteHeader(statusCode)
+		if _, err := w.Write(body.Bytes()); err != nil {
+			// El status ya se envió, no podemos hacer mucho más
+			slog.WarnContext(r.Context(), "Error al escribir respuesta", "error", err.Error())
+		}
+		return
+	}
+
+	respondWithError(w, r, h.notAcceptable())
+}
+
+// RequireAcceptable responde 406 antes de ejecutar el handler si el header Accept no admite
+// ninguno de los formatos de la API, de modo que una escritura no se aplique sin poder informarla
+func (h *UserHandler) RequireAcceptable(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept := parseAccept(r.Header.Get("Accept"))
+		for _, mediaType := range h.mediaTypes() {
+			if accept.quality(mediaType) > 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		respondWithError(w, r, h.notAcceptable())
+	})
+}
+
+// varyAccept indica a los caches que la respuesta depende del header Accept
+func varyAccept(w http.ResponseWriter) {
+	for _, value := range w.Header().Values("Vary") {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), "Accept") {
+				return
+			}
+		}
+	}
+	w.Header().Add("Vary", "Accept")
+}
+
+// mediaTypes retorna los media types que produce la API: los de los encoders y los de la exportación
+func (h *UserHandler) mediaTypes() []string {
+	mediaTypes := make([]string, 0, len(h.encoders)+1)
+	for _, encoder := range h.encoders {
+		mediaTypes = append(mediaTypes, encoder.MediaType())
+	}
+	return append(mediaTypes, ndjsonContentType)
+}
+
+// notAcceptable crea el error 406 que informa los formatos disponibles
+func (h *UserHandler) notAcceptable() *apperrors.Error {
+	return apperrors.New(http.StatusNotAcceptable, apperrors.CodeNotAcceptable,
+		"ningún formato aceptable puede representar la respuesta; formatos disponibles: "+strings.Join(h.mediaTypes(), ", "))
+}
+
+// contentTypeHeader agrega el charset a los media types de texto
+func contentTypeHeader(mediaType string) string {
+	if strings.HasPrefix(mediaType, "text/") {
+		return mediaType + "; charset=utf-8"
+	}
+	return mediaType
+}
+
+// acceptRange es un media range del header Accept con su calidad
+type acceptRange struct {
+	mediaType string // "tipo/subtipo", "tipo/*" o "*/*"
+	quality   float64
+}
+
+// acceptHeader son los media ranges de un header Accept
+type acceptHeader []acceptRange
+
+// parseAccept interpreta el header Accept (RFC 9110, sección 12.5.1). Un header vacío admite
+// cualquier formato y los rangos mal formados se ignoran.
+func parseAccept(header string) acceptHeader {
+	if strings.TrimSpace(header) == "" {
+		return acceptHeader{{mediaType: "*/*", quality: 1}}
+	}
+
+	ranges := make(acceptHeader, 0, 4)
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || !strings.Contains(mediaType, "/") {
+			continue
+		}
+		quality := 1.0
+		if raw, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(raw, 64); err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, quality: quality})
+	}
+	return ranges
+}
+
+// quality retorna la calidad que el rango más específico que incluye a mediaType le asigna,
+// o 0 si ninguno lo incluye
+func (a acceptHeader) quality(mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	best, specificity := 0.0, -1
+	for _, r := range a {
+		current := -1
+		switch r.mediaType {
+		case mediaType:
+			current = 2
+		case mainType + "/*":
+			current = 1
+		case "*/*":
+			current = 0
+		}
+		if current > specificity {
+			best, specificity = r.quality, current
+		}
+	}
+	return best
+}
+
+// acceptableEncoders retorna los encoders con calidad mayor a 0, de mayor a menor calidad y,
+// a igual calidad, en el orden de preferencia del servidor
+func acceptableEncoders(accept acceptHeader, encoders []Encoder) []Encoder {
+	acceptable := make([]Encoder, 0, len(encoders))
+	for _, encoder := range encoders {
+		if accept.quality(encoder.MediaType()) > 0 {
+			acceptable = append(acceptable, encoder)
+		}
+	}
+	sort.SliceStable(acceptable, func(i, j int) bool {
+		return accept.quality(acceptable[i].MediaType()) > accept.quality(acceptable[j].MediaType())
+	})
+	return acceptable
+}
+
+// prefersProblemXML indica si la respuesta de error debe enviarse como application/problem+xml:
+// solo cuando el cliente prefiere XML a JSON, ya que los errores se informan aunque Accept no admita JSON
+func prefersProblemXML(r *http.Request) bool {
+	accept := parseAccept(r.Header.Get("Accept"))
+	xmlQuality := max(accept.quality(xmlContentType), accept.quality(apperrors.ProblemXMLContentType))
+	jsonQuality := max(accept.quality(jsonContentType), accept.quality(apperrors.ProblemContentType))
+	return xmlQuality > jsonQuality
+}
+
+// jsonEncoder es el formato por defecto
+type jsonEncoder struct{}
+
+func (jsonEncoder) MediaType() string { return jsonContentType }
+
+func (jsonEncoder) Encode(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// xmlEncoder usa los tags xml de los modelos, que replican los nombres de JSON
+type xmlEncoder struct{}
+
+func (xmlEncoder) MediaType() string { return xmlContentType }
+
+func (xmlEncoder) Encode(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	if err := xml.NewEncoder(w).Encode(v); err != nil {
+		var unsupported *xml.UnsupportedTypeError
+		if errors.As(err, &unsupported) {
+			return fmt.Errorf("%w: %v", ErrUnsupportedPayload, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// csvEncoder representa usuarios y listados de usuarios, completos o con los campos seleccionados,
+// con las mismas columnas que la exportación
+type csvEncoder struct{}
+
+func (csvEncoder) MediaType() string { return csvContentType }
+
+func (csvEncoder) Encode(w io.Writer, v interface{}) error {
+	writer := csvUserWriter{writer: csv.NewWriter(w)}
+	var rows []models.PartialUser
+	switch payload := v.(type) {
+	case *models.User:
+		rows = []models.PartialUser{writer.fields.Project(payload)}
+	case models.UserListResponse:
+		rows = projectAll(payload.Data)
+	case *models.UserListResponse:
+		rows = projectAll(payload.Data)
+	case models.PartialUser:
+		writer.fields = payload.Fields()
+		rows = []models.PartialUser{payload}
+	case models.PartialUserListResponse:
+		writer.fields, rows = payload.Fields, payload.Data
+	default:
+		return ErrUnsupportedPayload
+	}
+
+	if err := writer.writeHeader(); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err := writer.writePartial(row); err != nil {
+			return err
+		}
+	}
+	return writer.flush()
+}
+
+// projectAll proyecta los usuarios con todos sus campos
+func projectAll(users []*models.User) []models.PartialUser {
+	rows := make([]models.PartialUser, 0, len(users))
+	for _, user := range users {
+		rows = append(rows, models.AllUserFields.Project(user))
+	}
+	return rows
+}
+
+// msgpackEncoder usa los nombres de los tags json para que las claves coincidan con las de JSON
+type msgpackEncoder struct{}
+
+func (msgpackEncoder) MediaType() string { return msgpackContentType }
+
+func (msgpackEncoder) Encode(w io.Writer, v interface{}) error {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json")
+	return encoder.Encode(v)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"helloworld/models"
+)
+
+var (
+	errInvalidLimitParam  = errors.New("el parámetro limit debe ser un número entero")
+	errInvalidOffsetParam = errors.New("el parámetro offset debe ser un número entero")
+)
+
+// parsePagination lee limit, offset y cursor de la query string
+func parsePagination(values url.Values) (models.UserQuery, error) {
+	var query models.UserQuery
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			return query, errInvalidLimitParam
+		}
+		query.Limit = limit
+	}
+
+	if raw := values.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil {
+			return query, errInvalidOffsetParam
+		}
+		query.Offset = offset
+	}
+
+	if raw := values.Get("cursor"); raw != "" {
+		cursor, err := models.DecodeCursor(raw)
+		if err != nil {
+			return query, err
+		}
+		query.Cursor = cursor
+	}
+
+	return query, nil
+}
+
+// setPaginationLinks agrega el header Link (RFC 8288) con las páginas relacionadas.
+// En modo cursor solo se conoce la página siguiente; en modo offset también first, prev y last.
+func setPaginationLinks(w http.ResponseWriter, r *http.Request, query models.UserQuery, page *models.UserPage) {
+	links := make([]string, 0, 4)
+	link := func(rel string, set func(values url.Values)) {
+		values := r.URL.Query()
+		values.Del("cursor")
+		values.Del("offset")
+		values.Set("limit", strconv.Itoa(page.Limit))
+		set(values)
+		target := url.URL{Path: r.URL.Path, RawQuery: values.Encode()}
+		links = append(links, fmt.Sprintf("<%s>; rel=%q", target.String(), rel))
+	}
+
+	link("first", func(url.Values) {})
+
+	if query.Cursor != nil {
+		if page.NextCursor != nil {
+			link("next", func(values url.Values) { values.Set("cursor", page.NextCursor.Encode()) })
+		}
+	} else {
+		if page.Offset > 0 {
+			prev := page.Offset - page.Limit
+			if prev < 0 {
+				prev = 0
+			}
+			link("prev", func(values url.Values) { values.Set("offset", strconv.Itoa(prev)) })
+		}
+		if page.Offset+page.Limit < page.Total {
+			link("next", func(values url.Values) { values.Set("offset", strconv.Itoa(page.Offset+page.Limit)) })
+		}
+		if page.Total > 0 {
+			last := (page.Total - 1) / page.Limit * page.Limit
+			link("last", func(values url.Values) { values.Set("offset", strconv.Itoa(last)) })
+		}
+	}
+
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// userListPayload retorna el envoltorio del listado, limitado a los campos de fields si se seleccionaron
+func userListPayload(page *models.UserPage, fields models.UserFields) interface{} {
+	response := newUserListResponse(page)
+	if len(fields) == 0 {
+		return response
+	}
+
+	partial := models.PartialUserListResponse{
+		Fields:     fields,
+		Data:       make([]models.PartialUser, 0, len(page.Users)),
+		Total:      response.Total,
+		Limit:      response.Limit,
+		Offset:     response.Offset,
+		NextCursor: response.NextCursor,
+	}
+	for _, user := range page.Users {
+		partial.Data = append(partial.Data, fields.Project(user))
+	}
+	return partial
+}
+
+// newUserListResponse convierte una página en el envoltorio JSON del listado
+func newUserListResponse(page *models.UserPage) models.UserListResponse {
+	response := models.UserListResponse{
+		Data:   page.Users,
+		Total:  page.Total,
+		Limit:  page.Limit,
+		Offset: page.Offset,
+	}
+	if page.NextCursor != nil {
+		response.NextCursor = page.NextCursor.Encode()
+	}
+	return response
+}
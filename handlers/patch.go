@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"sort"
+
+	"helloworld/models"
+	"helloworld/services"
+	"helloworld/validation"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// Media types admitidos por PATCH /users/{id}
+const (
+	mergePatchContentType = "application/merge-patch+json" // RFC 7396
+	jsonPatchContentType  = "application/json-patch+json"  // RFC 6902
+)
+
+var (
+	// errInvalidPatch indica un documento de patch mal formado
+	errInvalidPatch = errors.New("el documento de patch no es válido")
+	// errPatchConflict indica un patch que no puede aplicarse al estado actual del usuario,
+	// por ejemplo una operación test que no se cumple o una ruta inexistente
+	errPatchConflict = errors.New("el patch no puede aplicarse al estado actual del usuario")
+)
+
+// readOnlyUserFields son los campos de models.User que un patch no puede modificar.
+// Pueden usarse en operaciones test, por ejemplo para exigir una versión.
+var readOnlyUserFields = []string{"id", "version", "created_at", "updated_at", "deleted_at"}
+
+// jsonPatchOperations son las operaciones definidas por RFC 6902
+var jsonPatchOperations = map[string]bool{
+	"add": true, "remove": true, "replace": true, "move": true, "copy": true, "test": true,
+}
+
+// patchApplier aplica un patch ya decodificado a la representación JSON de un usuario
+type patchApplier func(doc []byte) ([]byte, error)
+
+// decodeUserPatch interpreta body según contentType y retorna el patch para services.UserPatch.
+// contentType debe ser mergePatchContentType o jsonPatchContentType, con parámetros opcionales.
+// El patch se aplica sobre la representación JSON de models.User; el resultado solo puede
+// diferir en los campos editables (name, email y age).
+func decodeUserPatch(contentType string, body []byte) (services.UserPatch, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, unsupportedMediaType(mergePatchContentType, jsonPatchContentType)
+	}
+
+	var apply patchApplier
+	switch mediaType {
+	case mergePatchContentType:
+		// Un merge patch que no es un objeto reemplazaría al usuario completo
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(body, &members); err != nil || members == nil {
+			return nil, errInvalidPatch
+		}
+		apply = func(doc []byte) ([]byte, error) {
+			return jsonpatch.MergePatch(doc, body)
+		}
+	case jsonPatchContentType:
+		patch, err := jsonpatch.DecodePatch(body)
+		if err != nil || patch == nil {
+			return nil, errInvalidPatch
+		}
+		for _, operation := range patch {
+			if _, err := operation.Path(); err != nil || !jsonPatchOperations[operation.Kind()] {
+				return nil, errInvalidPatch
+			}
+		}
+		apply = patch.Apply
+	default:
+		return nil, unsupportedMediaType(mergePatchContentType, jsonPatchContentType)
+	}
+
+	return func(current models.User) (models.ReplaceUserRequest, error) {
+		doc, err := json.Marshal(current)
+		if err != nil {
+			return models.ReplaceUserRequest{}, fmt.Errorf("error al codificar usuario: %w", err)
+		}
+		patched, err := apply(doc)
+		if err != nil {
+			return models.ReplaceUserRequest{}, errPatchConflict
+		}
+		return patchedUserRequest(doc, patched)
+	}, nil
+}
+
+// patchedUserRequest extrae los campos editables del usuario parcheado y verifica que
+// no se hayan agregado campos ni modificado los de solo lectura
+func patchedUserRequest(original, patched []byte) (models.ReplaceUserRequest, error) {
+	var before, after map[string]interface{}
+	if err := json.Unmarshal(original, &before); err != nil {
+		return models.ReplaceUserRequest{}, fmt.Errorf("error al decodificar usuario: %w", err)
+	}
+	if err := json.Unmarshal(patched, &after); err != nil || after == nil {
+		return models.ReplaceUserRequest{}, errPatchConflict
+	}
+
+	var v validation.Validator
+	unknown := make([]string, 0)
+	for field := range after {
+		if !isUserField(field) {
+			unknown = append(unknown, field)
+		}
+	}
+	sort.Strings(unknown)
+	for _, field := range unknown {
+		v.Field(field, nil).Check(false, validation.RuleUnknown, "no es un campo del usuario")
+	}
+	for _, field := range readOnlyUserFields {
+		v.Field(field, nil).Check(reflect.DeepEqual(before[field], after[field]), validation.RuleReadOnly, "no puede modificarse")
+	}
+
+	// Un campo eliminado o null queda vacío y lo rechaza la validación del servicio
+	name, nameOK := after["name"].(string)
+	email, emailOK := after["email"].(string)
+	age, ageOK := after["age"].(float64)
+	v.Field("name", services.ErrInvalidName).Check(nameOK || after["name"] == nil, validation.RuleType, "debe ser un texto")
+	v.Field("email", services.ErrInvalidEmail).Check(emailOK || after["email"] == nil, validation.RuleType, "debe ser un texto")
+	v.Field("age", services.ErrInvalidAge).Check((ageOK && age == float64(int(age))) || after["age"] == nil, validation.RuleType, "debe ser un número entero")
+	if err := v.Err(); err != nil {
+		return models.ReplaceUserRequest{}, err
+	}
+
+	return models.ReplaceUserRequest{Name: name, Email: email, Age: int(age)}, nil
+}
+
+// isUserField indica si field es un campo de la representación JSON de models.User
+func isUserField(field string) bool {
+	switch field {
+	case "name", "email", "age":
+		return true
+	}
+	for _, readOnly := range readOnlyUserFields {
+		if field == readOnly {
+			return true
+		}
+	}
+	return false
+}
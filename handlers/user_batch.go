@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"net/http"
+
+	"helloworld/models"
+)
+
+// CreateUsersBatch maneja la creación de usuarios por lotes
+// @Summary      Crear usuarios por lotes
+// @Description  Crea hasta 1000 usuarios validando cada uno como en POST /users. En modo atomic (por defecto) se crean todos o ninguno; en modo partial se crean los válidos. Responde 200 si todos se aplicaron y 207 si alguno falló, con el resultado de cada elemento en el orden de la petición; los elementos no aplicados por el fallo de otro tienen status 424.
+// @Tags         usuarios
+// @Accept       json
+// @Produce      json
+// @Produce      xml
+// @Produce      application/msgpack
+// @Param        batch  body      models.BatchCreateRequest  true  "Usuarios a crear"
+// @Success      200    {object}  models.BatchResponse
+// @Success      207    {object}  models.BatchResponse
+// @Failure      400    {object}  apperrors.Problem
+// @Failure      409    {object}  apperrors.Problem
+// @Failure      413    {object}  apperrors.Problem
+// @Failure      415    {object}  apperrors.Problem
+// @Failure      500    {object}  apperrors.Problem
+// @Router       /users:batch [post]
+func (h *UserHandler) CreateUsersBatch(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchCreateRequest
+	if err := h.decoder.decodeJSON(w, r, &req); err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	results, err := h.service.CreateUsers(r.Context(), req.Mode, req.Users)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	h.respondWithBatch(w, r, req.Mode, http.StatusCreated, results)
+}
+
+// ReplaceUsersBatch maneja el reemplazo de usuarios por lotes
+// @Summary      Reemplazar usuarios por lotes
+// @Description  Reemplaza hasta 1000 usuarios con las mismas reglas que PUT /users/{id}; version es opcional y funciona como If-Match. En modo atomic (por defecto) las escrituras se aplican en una única transacción; en modo partial cada una por separado. Responde 200 si todos se aplicaron y 207 si alguno falló.
+// @Tags         usuarios
+// @Accept       json
+// @Produce      json
+// @Produce      xml
+// @Produce      application/msgpack
+// @Param        batch  body      models.BatchReplaceRequest  true  "Usuarios a reemplazar"
+// @Success      200    {object}  models.BatchResponse
+// @Success      207    {object}  models.BatchResponse
+// @Failure      400    {object}  apperrors.Problem
+// @Failure      413    {object}  apperrors.Problem
+// @Failure      415    {object}  apperrors.Problem
+// @Failure      500    {object}  apperrors.Problem
+// @Router       /users:batchUpdate [post]
+func (h *UserHandler) ReplaceUsersBatch(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchReplaceRequest
+	if err := h.decoder.decodeJSON(w, r, &req); err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	results, err := h.service.ReplaceUsers(r.Context(), req.Mode, req.Users)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	h.respondWithBatch(w, r, req.Mode, http.StatusOK, results)
+}
+
+// DeleteUsersBatch maneja la eliminación de usuarios por lotes
+// @Summary      Eliminar usuarios por lotes
+// @Description  Elimina lógicamente hasta 1000 usuarios; version es opcional y funciona como If-Match. En modo atomic (por defecto) se eliminan todos o ninguno; en modo partial cada uno por separado. Responde 200 si todos se aplicaron y 207 si alguno falló.
+// @Tags         usuarios
+// @Accept       json
+// @Produce      json
+// @Produce      xml
+// @Produce      application/msgpack
+// @Param        batch  body      models.BatchDeleteRequest  true  "Usuarios a eliminar"
+// @Success      200    {object}  models.BatchResponse
+// @Success      207    {object}  models.BatchResponse
+// @Failure      400    {object}  apperrors.Problem
+// @Failure      413    {object}  apperrors.Problem
+// @Failure      415    {object}  apperrors.Problem
+// @Failure      500    {object}  apperrors.Problem
+// @Router       /users:batchDelete [post]
+func (h *UserHandler) DeleteUsersBatch(w http.ResponseWriter, r *http.Request) {
+	var req models.BatchDeleteRequest
+	if err := h.decoder.decodeJSON(w, r, &req); err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	results, err := h.service.DeleteUsers(r.Context(), req.Mode, req.Users)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	h.respondWithBatch(w, r, req.Mode, http.StatusOK, results)
+}
+
+// respondWithBatch envía el resultado de cada elemento de un lote. successStatus es el status de
+// los elementos aplicados y los fallidos usan el mismo mapeo de errores que las operaciones individuales.
+func (h *UserHandler) respondWithBatch(w http.ResponseWriter, r *http.Request, mode models.BatchMode, successStatus int, results []models.BatchResult) {
+	response := models.BatchResponse{
+		Mode:    mode.OrDefault(),
+		Results: make([]models.BatchItemResponse, 0, len(results)),
+	}
+	for i, result := range results {
+		item := models.BatchItemResponse{Index: i, Status: successStatus, ID: result.ID, User: result.User}
+		if result.Err != nil {
+			apiErr := toAPIError(r, result.Err)
+			problem := apiErr.Problem("", "")
+			item.Status = apiErr.Status
+			item.Error = &problem
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+		response.Results = append(response.Results, item)
+	}
+
+	status := http.StatusOK
+	if response.Failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	h.respond(w, r, status, response)
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"helloworld/models"
+)
+
+// Formatos de exportación e importación y sus media types
+const (
+	formatCSV         = "csv"
+	formatNDJSON      = "ndjson"
+	csvContentType    = "text/csv"
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// exportFlushRows es la cantidad de filas escritas entre cada envío parcial al cliente
+const exportFlushRows = 500
+
+// streamIdleTimeout es el tiempo máximo sin progreso de una exportación o importación. Reemplaza a los
+// timeouts de lectura y escritura del servidor, pensados para peticiones cortas.
+const streamIdleTimeout = 30 * time.Second
+
+// exportParams son los parámetros aceptados por la exportación de usuarios
+var exportParams = allowedParams(userFilterParams, "format")
+
+// csvColumns son las columnas de la exportación CSV, en orden
+var csvColumns = []string{"id", "name", "email", "age", "version", "created_at", "updated_at", "deleted_at"}
+
+// csvFormulaPrefixes son los caracteres con los que una celda se interpreta como fórmula en una planilla
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// userRowWriter escribe los usuarios exportados en un formato concreto
+type userRowWriter interface {
+	writeHeader() error
+	write(user *models.User) error
+	// flush envía al ResponseWriter lo que quedó en el buffer
+	flush() error
+}
+
+// ExportUsers maneja la exportación de usuarios
+// @Summary      Exportar usuarios
+// @Description  Descarga todos los usuarios que cumplen los filtros, en CSV (por defecto) o NDJSON. Las filas se leen del repositorio y se envían a medida que se escriben, sin cargar el listado en memoria. En CSV, los valores que empiezan con =, +, -, @ se prefijan con ' para que las planillas no los evalúen como fórmulas. Si la exportación falla después de empezar a enviarse, la conexión se corta para que el archivo parcial no parezca completo.
+// @Tags         usuarios
+// @Produce      text/csv
+// @Produce      application/x-ndjson
+// @Param        format          query     string  false  "Formato del archivo"  Enums(csv, ndjson)  default(csv)
+// @Param        sort            query     string  false  "Campo de orden: name, email, age, created_at o updated_at; prefijo '-' para descendente"  default(-created_at)
+// @Param        name            query     string  false  "Prefijo del nombre (sin distinguir mayúsculas)"
+// @Param        email           query     string  false  "Email exacto (sin distinguir mayúsculas)"
+// @Param        min_age         query     int     false  "Edad mínima (inclusive)"
+// @Param        max_age         query     int     false  "Edad máxima (inclusive)"
+// @Param        created_after   query     string  false  "Creados en esta fecha o después (RFC 3339)"
+// @Param        created_before  query     string  false  "Creados antes de esta fecha (RFC 3339)"
+// @Param        updated_since   query     string  false  "Modificados en esta fecha o después (RFC 3339)"
+// @Param        include_deleted query     bool    false  "Incluir usuarios eliminados lógicamente"
+// @Success      200             {file}    file
+// @Header       200             {string}  Content-Disposition  "attachment; filename=\"users.csv\""
+// @Failure      400             {object}  apperrors.Problem
+// @Failure      500             {object}  apperrors.Problem
+// @Router       /users/export [get]
+func (h *UserHandler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	if err := rejectUnknownParams(values, exportParams); err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+	filter, ordered, err := parseUserFilter(values)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	format := values.Get("format")
+	var (
+		rowWriter   userRowWriter
+		contentType string
+	)
+	switch format {
+	case "", formatCSV:
+		format, contentType = formatCSV, csvContentType+"; charset=utf-8"
+		rowWriter = csvUserWriter{writer: csv.NewWriter(w)}
+	case formatNDJSON:
+		buffered := bufio.NewWriter(w)
+		contentType = ndjsonContentType
+		rowWriter = ndjsonUserWriter{buffer: buffered, encoder: json.NewEncoder(buffered)}
+	default:
+		respondWithError(w, r, invalidQuery("el parámetro format debe ser %s o %s", formatCSV, formatNDJSON))
+		return
+	}
+
+	// La respuesta se inicia con el primer usuario, de modo que los errores previos (filtros
+	// inválidos, fallas de la consulta) todavía pueden responderse como problem+json
+	controller := http.NewResponseController(w)
+	started := false
+	start := func() error {
+		started = true
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"users.%s\"", format))
+		w.WriteHeader(http.StatusOK)
+		return rowWriter.writeHeader()
+	}
+
+	written := 0
+	err = h.service.ExportUsers(r.Context(), filter, ordered, func(user *models.User) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := rowWriter.write(user); err != nil {
+			return err
+		}
+		written++
+		if written%exportFlushRows == 0 {
+			return flushStream(controller, rowWriter)
+		}
+		return nil
+	})
+	if err == nil && !started {
+		err = start()
+	}
+	if err == nil {
+		err = rowWriter.flush()
+	}
+	if err != nil {
+		if !started {
+			respondWithError(w, r, err)
+			return
+		}
+		// El status ya se envió: cortar la conexión evita que el cliente tome el archivo parcial por completo
+		slog.ErrorContext(r.Context(), "Exportación de usuarios interrumpida", "rows", written, "error", err.Error())
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// flushStream envía al cliente lo escrito hasta ahora y extiende el deadline de escritura
+func flushStream(controller *http.ResponseController, rowWriter userRowWriter) error {
+	if err := rowWriter.flush(); err != nil {
+		return err
+	}
+	_ = controller.SetWriteDeadline(time.Now().Add(streamIdleTimeout)) // nolint:errcheck // No todos los ResponseWriter admiten deadlines
+	_ = controller.Flush()                                             // nolint:errcheck // Sin Flush los datos se envían al completar el buffer
+	return nil
+}
+
+// csvUserWriter escribe los usuarios como filas CSV con las columnas de fields, o con las de
+// csvColumns si fields está vacía
+type csvUserWriter struct {
+	writer *csv.Writer
+	fields models.UserFields
+}
+
+func (c csvUserWriter) writeHeader() error {
+	return c.writer.Write(strings.Split(c.fields.OrAll().String(), ","))
+}
+
+func (c csvUserWriter) write(user *models.User) error {
+	return c.writePartial(c.fields.Project(user))
+}
+
+// writePartial escribe una fila con los campos de un usuario ya proyectado
+func (c csvUserWriter) writePartial(user models.PartialUser) error {
+	record := make([]string, 0, len(c.fields.OrAll()))
+	for _, field := range c.fields.OrAll() {
+		record = append(record, csvCell(user[field]))
+	}
+	return c.writer.Write(record)
+}
+
+func (c csvUserWriter) flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+// csvCell formatea el valor de un campo; un campo ausente (deleted_at de un usuario activo) queda vacío
+func csvCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return csvSafe(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// csvSafe evita que una planilla interprete el valor como fórmula (inyección CSV)
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// ndjsonUserWriter escribe cada usuario como un objeto JSON por línea
+type ndjsonUserWriter struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (n ndjsonUserWriter) writeHeader() error {
+	return nil
+}
+
+func (n ndjsonUserWriter) write(user *models.User) error {
+	return n.encoder.Encode(user)
+}
+
+func (n ndjsonUserWriter) flush() error {
+	return n.buffer.Flush()
+}
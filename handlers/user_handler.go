@@ -1,26 +1,57 @@
 package handlers
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 
 	"helloworld/models"
-	"helloworld/repositories"
 	"helloworld/services"
 
 	"github.com/gorilla/mux"
 )
 
+// errDecodeBody indica un cuerpo de petición que no es JSON válido
+var errDecodeBody = errors.New("error al decodificar el cuerpo de la petición")
+
 // UserHandler maneja las peticiones HTTP relacionadas con usuarios
 type UserHandler struct {
 	service services.UserService
+	decoder requestDecoder
+	// importDecoder aplica a los archivos de importación su propio límite de tamaño
+	importDecoder requestDecoder
+	// encoders son los formatos de respuesta, en orden de preferencia del servidor
+	encoders []Encoder
+}
+
+// Option configura el handler de usuarios
+type Option func(*UserHandler)
+
+// WithMaxBodyBytes limita el tamaño del cuerpo de las peticiones; las mayores se responden con 413
+func WithMaxBodyBytes(maxBodyBytes int64) Option {
+	return func(h *UserHandler) {
+		h.decoder.maxBodyBytes = maxBodyBytes
+	}
+}
+
+// WithMaxImportBytes limita el tamaño de los archivos de importación; los mayores se responden con 413
+func WithMaxImportBytes(maxImportBytes int64) Option {
+	return func(h *UserHandler) {
+		h.importDecoder.maxBodyBytes = maxImportBytes
+	}
 }
 
 // NewUserHandler crea una nueva instancia del handler de usuarios
-func NewUserHandler(service services.UserService) *UserHandler {
-	return &UserHandler{
-		service: service,
+func NewUserHandler(service services.UserService, opts ...Option) *UserHandler {
+	h := &UserHandler{
+		service:       service,
+		decoder:       requestDecoder{maxBodyBytes: DefaultMaxBodyBytes},
+		importDecoder: requestDecoder{maxBodyBytes: DefaultMaxImportBytes},
+		encoders:      defaultEncoders(),
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
 // CreateUser maneja la creación de un nuevo usuario
@@ -29,155 +60,295 @@ func NewUserHandler(service services.UserService) *UserHandler {
 // @Tags         usuarios
 // @Accept       json
 // @Produce      json
+// @Produce      xml
+// @Produce      text/csv
+// @Produce      application/msgpack
 // @Param        user  body      models.CreateUserRequest  true  "Datos del usuario"
 // @Success      201   {object}  models.User
-// @Failure      400   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Header       201   {string}  ETag  "Versión del usuario creado"
+// @Failure      400   {object}  apperrors.Problem
+// @Failure      409   {object}  apperrors.Problem
+// @Failure      413   {object}  apperrors.Problem
+// @Failure      415   {object}  apperrors.Problem
+// @Failure      422   {object}  apperrors.Problem
+// @Failure      500   {object}  apperrors.Problem
 // @Router       /users [post]
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "error al decodificar el cuerpo de la petición")
+	if err := h.decoder.decodeJSON(w, r, &req); err != nil {
+		respondWithError(w, r, err)
 		return
 	}
 
-	user, err := h.service.CreateUser(req)
+	user, err := h.service.CreateUser(r.Context(), req)
 	if err != nil {
-		statusCode := http.StatusInternalServerError
-		if err == services.ErrInvalidEmail || err == services.ErrInvalidAge || err == services.ErrInvalidName {
-			statusCode = http.StatusBadRequest
-		}
-		respondWithError(w, statusCode, err.Error())
+		respondWithError(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, user)
+	h.respondUser(w, r, http.StatusCreated, user, nil)
 }
 
 // GetUser maneja la obtención de un usuario por ID
 // @Summary      Obtener un usuario por ID
-// @Description  Obtiene la información de un usuario específico
+// @Description  Obtiene la información de un usuario específico. El header ETag identifica su versión en el formato y la selección de campos de la respuesta, y puede enviarse en If-Match al modificarlo. Con fields la respuesta incluye solo los campos indicados, y solo esas columnas se leen de la base de datos.
 // @Tags         usuarios
 // @Accept       json
 // @Produce      json
-// @Param        id   path      string  true  "ID del usuario"
-// @Success      200  {object}  models.User
-// @Failure      404  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Produce      xml
+// @Produce      text/csv
+// @Produce      application/msgpack
+// @Param        id      path      string  true   "ID del usuario"
+// @Param        fields  query     string  false  "Campos a incluir separados por comas (id, name, email, age, version, created_at, updated_at, deleted_at); por defecto todos"
+// @Success      200     {object}  models.User
+// @Header       200     {string}  ETag  "Versión del usuario"
+// @Failure      400     {object}  apperrors.Problem
+// @Failure      404     {object}  apperrors.Problem
+// @Failure      500     {object}  apperrors.Problem
 // @Router       /users/{id} [get]
 func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	user, err := h.service.GetUserByID(id)
+	fields, err := parseFieldsParam(r.URL.Query())
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	// La versión se lee siempre porque el ETag la necesita
+	user, err := h.service.GetUserByID(r.Context(), id, fields.With(models.UserFieldVersion))
 	if err != nil {
-		statusCode := http.StatusInternalServerError
-		if err == repositories.ErrUserNotFound || err.Error() == repositories.ErrUserNotFound.Error() {
-			statusCode = http.StatusNotFound
-		}
-		respondWithError(w, statusCode, err.Error())
+		respondWithError(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, user)
+	h.respondUser(w, r, http.StatusOK, user, fields)
 }
 
-// GetAllUsers maneja la obtención de todos los usuarios
-// @Summary      Obtener todos los usuarios
-// @Description  Obtiene una lista de todos los usuarios registrados
+// GetAllUsers maneja la obtención paginada de usuarios
+// @Summary      Obtener usuarios paginados
+// @Description  Obtiene una página de usuarios filtrada y ordenada (por defecto por fecha de creación descendente). Admite paginación por offset (limit/offset) o por cursor opaco (limit/cursor); el header Link incluye las páginas relacionadas. Con fields cada usuario incluye solo los campos indicados, y solo esas columnas se leen de la base de datos. Los parámetros desconocidos se rechazan con 400.
 // @Tags         usuarios
 // @Accept       json
 // @Produce      json
-// @Success      200  {array}   models.User
-// @Failure      500  {object}  map[string]string
+// @Produce      xml
+// @Produce      text/csv
+// @Produce      application/msgpack
+// @Param        limit           query     int     false  "Tamaño de página (1-100, por defecto 20)"
+// @Param        offset          query     int     false  "Cantidad de usuarios a omitir (no combinable con cursor)"
+// @Param        cursor          query     string  false  "Cursor opaco devuelto en next_cursor (requiere el mismo sort)"
+// @Param        sort            query     string  false  "Campo de orden: name, email, age, created_at o updated_at; prefijo '-' para descendente"  default(-created_at)
+// @Param        name            query     string  false  "Prefijo del nombre (sin distinguir mayúsculas)"
+// @Param        email           query     string  false  "Email exacto (sin distinguir mayúsculas)"
+// @Param        min_age         query     int     false  "Edad mínima (inclusive)"
+// @Param        max_age         query     int     false  "Edad máxima (inclusive)"
+// @Param        created_after   query     string  false  "Creados en esta fecha o después (RFC 3339)"
+// @Param        created_before  query     string  false  "Creados antes de esta fecha (RFC 3339)"
+// @Param        updated_since   query     string  false  "Modificados en esta fecha o después (RFC 3339), para sincronización incremental"
+// @Param        include_deleted query     bool    false  "Incluir usuarios eliminados lógicamente (uso administrativo)"
+// @Param        fields          query     string  false  "Campos a incluir separados por comas (id, name, email, age, version, created_at, updated_at, deleted_at); por defecto todos"
+// @Success      200             {object}  models.UserListResponse
+// @Header       200             {string}  Link  "Enlaces first, prev, next y last (RFC 8288)"
+// @Failure      400             {object}  apperrors.Problem
+// @Failure      500             {object}  apperrors.Problem
 // @Router       /users [get]
 func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := h.service.GetAllUsers()
+	query, err := parseUserQuery(r.URL.Query())
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithError(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, users)
+	page, err := h.service.GetAllUsers(r.Context(), query)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	setPaginationLinks(w, r, query, page)
+	h.respond(w, r, http.StatusOK, userListPayload(page, query.Fields))
 }
 
-// UpdateUser maneja la actualización de un usuario
-// @Summary      Actualizar un usuario
-// @Description  Actualiza la información de un usuario existente (actualización parcial)
+// ReplaceUser maneja el reemplazo completo de un usuario
+// @Summary      Reemplazar un usuario
+// @Description  Reemplaza todos los datos editables de un usuario existente; los campos omitidos no conservan su valor anterior. Para modificaciones parciales se usa PATCH. Con If-Match solo se aplica si el ETag coincide con la versión actual.
 // @Tags         usuarios
 // @Accept       json
 // @Produce      json
-// @Param        id    path      string                  true  "ID del usuario"
-// @Param        user  body      models.UpdateUserRequest  true  "Datos a actualizar"
-// @Success      200   {object}  models.User
-// @Failure      400   {object}  map[string]string
-// @Failure      404   {object}  map[string]string
-// @Failure      500   {object}  map[string]string
+// @Produce      xml
+// @Produce      text/csv
+// @Produce      application/msgpack
+// @Param        id        path      string                     true   "ID del usuario"
+// @Param        If-Match  header    string                     false  "ETag obtenido al leer el usuario"
+// @Param        user      body      models.ReplaceUserRequest  true   "Datos completos del usuario"
+// @Success      200       {object}  models.User
+// @Header       200       {string}  ETag  "Nueva versión del usuario"
+// @Failure      400       {object}  apperrors.Problem
+// @Failure      404       {object}  apperrors.Problem
+// @Failure      409       {object}  apperrors.Problem
+// @Failure      412       {object}  apperrors.Problem
+// @Failure      413       {object}  apperrors.Problem
+// @Failure      415       {object}  apperrors.Problem
+// @Failure      422       {object}  apperrors.Problem
+// @Failure      500       {object}  apperrors.Problem
 // @Router       /users/{id} [put]
-func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+func (h *UserHandler) ReplaceUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	version, ok := parseIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.ReplaceUserRequest
+	if err := h.decoder.decodeJSON(w, r, &req); err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	user, err := h.service.ReplaceUser(r.Context(), id, version, req)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	h.respondUser(w, r, http.StatusOK, user, nil)
+}
+
+// PatchUser maneja la modificación parcial de un usuario
+// @Summary      Modificar parcialmente un usuario
+// @Description  Aplica un JSON Merge Patch (RFC 7396, application/merge-patch+json) o un JSON Patch (RFC 6902, application/json-patch+json) sobre la representación del usuario. Solo pueden modificarse name, email y age; el resto de los campos admite operaciones test. El resultado se valida igual que en PUT. Con If-Match solo se aplica si el ETag coincide con la versión actual.
+// @Tags         usuarios
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
+// @Produce      json
+// @Produce      xml
+// @Produce      text/csv
+// @Produce      application/msgpack
+// @Param        id        path      string  true   "ID del usuario"
+// @Param        If-Match  header    string  false  "ETag obtenido al leer el usuario"
+// @Param        patch     body      object  true   "Merge patch (objeto) o JSON Patch (lista de operaciones)"
+// @Success      200       {object}  models.User
+// @Header       200       {string}  ETag  "Nueva versión del usuario"
+// @Failure      400       {object}  apperrors.Problem
+// @Failure      404       {object}  apperrors.Problem
+// @Failure      409       {object}  apperrors.Problem
+// @Failure      412       {object}  apperrors.Problem
+// @Failure      413       {object}  apperrors.Problem
+// @Failure      415       {object}  apperrors.Problem
+// @Failure      422       {object}  apperrors.Problem
+// @Failure      500       {object}  apperrors.Problem
+// @Router       /users/{id} [patch]
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	var req models.UpdateUserRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondWithError(w, http.StatusBadRequest, "error al decodificar el cuerpo de la petición")
+	version, ok := parseIfMatch(w, r)
+	if !ok {
 		return
 	}
 
-	user, err := h.service.UpdateUser(id, req)
+	body, err := h.decoder.readBody(w, r, mergePatchContentType, jsonPatchContentType)
 	if err != nil {
-		statusCode := http.StatusInternalServerError
-		if err == repositories.ErrUserNotFound || err.Error() == repositories.ErrUserNotFound.Error() {
-			statusCode = http.StatusNotFound
-		} else if err == services.ErrInvalidEmail || err == services.ErrInvalidAge || err == services.ErrInvalidName {
-			statusCode = http.StatusBadRequest
-		}
-		respondWithError(w, statusCode, err.Error())
+		respondWithError(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, user)
+	patch, err := decodeUserPatch(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	user, err := h.service.PatchUser(r.Context(), id, version, patch)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	h.respondUser(w, r, http.StatusOK, user, nil)
 }
 
 // DeleteUser maneja la eliminación de un usuario
 // @Summary      Eliminar un usuario
-// @Description  Elimina un usuario del sistema
+// @Description  Elimina lógicamente un usuario; puede restaurarse hasta que se purgue. Con If-Match solo se elimina si el ETag coincide con la versión actual.
 // @Tags         usuarios
 // @Accept       json
 // @Produce      json
-// @Param        id   path      string  true  "ID del usuario"
-// @Success      200  {object}  map[string]string
-// @Failure      404  {object}  map[string]string
-// @Failure      500  {object}  map[string]string
+// @Produce      xml
+// @Produce      application/msgpack
+// @Param        id        path      string  true   "ID del usuario"
+// @Param        If-Match  header    string  false  "ETag obtenido al leer el usuario"
+// @Success      200       {object}  models.MessageResponse
+// @Failure      400       {object}  apperrors.Problem
+// @Failure      404       {object}  apperrors.Problem
+// @Failure      412       {object}  apperrors.Problem
+// @Failure      500       {object}  apperrors.Problem
 // @Router       /users/{id} [delete]
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	if err := h.service.DeleteUser(id); err != nil {
-		statusCode := http.StatusInternalServerError
-		if err == repositories.ErrUserNotFound || err.Error() == repositories.ErrUserNotFound.Error() {
-			statusCode = http.StatusNotFound
-		}
-		respondWithError(w, statusCode, err.Error())
+	version, ok := parseIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteUser(r.Context(), id, version); err != nil {
+		respondWithError(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, map[string]string{"message": "usuario eliminado correctamente"})
+	h.respond(w, r, http.StatusOK, models.MessageResponse{Message: "usuario eliminado correctamente"})
 }
 
-// respondWithJSON envía una respuesta JSON
-func respondWithJSON(w http.ResponseWriter, statusCode int, payload interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	if err := json.NewEncoder(w).Encode(payload); err != nil {
-		// Si falla la codificación JSON, ya escribimos el header, no podemos hacer mucho más
-		// nolint:errcheck // Error de escritura en respuesta HTTP, no hay recuperación posible
-		_, _ = w.Write([]byte(`{"error":"error al codificar respuesta"}`))
+// RestoreUser maneja la restauración de un usuario eliminado
+// @Summary      Restaurar un usuario
+// @Description  Revierte la eliminación lógica de un usuario que aún no fue purgado
+// @Tags         usuarios
+// @Accept       json
+// @Produce      json
+// @Produce      xml
+// @Produce      text/csv
+// @Produce      application/msgpack
+// @Param        id   path      string  true  "ID del usuario"
+// @Success      200  {object}  models.User
+// @Header       200  {string}  ETag  "Versión del usuario restaurado"
+// @Failure      404  {object}  apperrors.Problem
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /users/{id}/restore [post]
+func (h *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	user, err := h.service.RestoreUser(r.Context(), id)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
 	}
+
+	h.respondUser(w, r, http.StatusOK, user, nil)
 }
 
-// respondWithError envía una respuesta de error JSON
-func respondWithError(w http.ResponseWriter, statusCode int, message string) {
-	respondWithJSON(w, statusCode, map[string]string{"error": message})
+// PurgeDeletedUsers maneja la purga de usuarios eliminados
+// @Summary      Purgar usuarios eliminados
+// @Description  Elimina definitivamente los usuarios eliminados hace más que la retención configurada (PURGE_RETENTION)
+// @Tags         usuarios
+// @Accept       json
+// @Produce      json
+// @Produce      xml
+// @Produce      application/msgpack
+// @Success      200  {object}  models.PurgeResponse
+// @Failure      500  {object}  apperrors.Problem
+// @Router       /users/purge [post]
+func (h *UserHandler) PurgeDeletedUsers(w http.ResponseWriter, r *http.Request) {
+	purged, err := h.service.PurgeDeletedUsers(r.Context())
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	h.respond(w, r, http.StatusOK, models.PurgeResponse{Purged: purged})
 }
@@ -0,0 +1,1161 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"helloworld/apperrors"
+	"helloworld/logging"
+	"helloworld/models"
+	"helloworld/repositories"
+	"helloworld/services"
+
+	"github.com/gorilla/mux"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestHandler crea un handler respaldado por el repositorio en memoria con n usuarios
+func newTestHandler(t *testing.T, n int) *UserHandler {
+	t.Helper()
+	service := services.NewUserService(repositories.NewMemoryUserRepository())
+	for i := 0; i < n; i++ {
+		req := models.CreateUserRequest{Name: fmt.Sprintf("User %d", i), Email: fmt.Sprintf("user%d@example.com", i), Age: 20 + i}
+		if _, err := service.CreateUser(context.Background(), req); err != nil {
+			t.Fatalf("Error al crear usuario: %v", err)
+		}
+	}
+	return NewUserHandler(service)
+}
+
+// newJSONRequest crea una petición con cuerpo JSON y su Content-Type
+func newJSONRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func TestUserHandler_GetAllUsersPagination(t *testing.T) {
+	handler := newTestHandler(t, 5)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users?limit=2&offset=2", nil)
+	rec := httptest.NewRecorder()
+	handler.GetAllUsers(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, esperaba %d", rec.Code, http.StatusOK)
+	}
+
+	var body models.UserListResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("error al decodificar respuesta: %v", err)
+	}
+	if len(body.Data) != 2 || body.Total != 5 || body.Limit != 2 || body.Offset != 2 {
+		t.Errorf("respuesta = %+v, esperaba 2 usuarios, total 5, limit 2, offset 2", body)
+	}
+	if body.NextCursor == "" {
+		t.Errorf("next_cursor vacío, esperaba un cursor")
+	}
+
+	link := rec.Header().Get("Link")
+	for _, want := range []string{
+		`</api/v1/users?limit=2>; rel="first"`,
+		`</api/v1/users?limit=2&offset=0>; rel="prev"`,
+		`</api/v1/users?limit=2&offset=4>; rel="next"`,
+		`</api/v1/users?limit=2&offset=4>; rel="last"`,
+	} {
+		if !strings.Contains(link, want) {
+			t.Errorf("Link = %q, no contiene %q", link, want)
+		}
+	}
+
+	// Seguir el cursor debe devolver la página siguiente
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/users?limit=2&cursor="+body.NextCursor, nil)
+	rec = httptest.NewRecorder()
+	handler.GetAllUsers(rec, req)
+
+	var next models.UserListResponse
+	if err := json.NewDecoder(rec.Body).Decode(&next); err != nil {
+		t.Fatalf("error al decodificar respuesta: %v", err)
+	}
+	if len(next.Data) != 1 || next.Data[0].ID == body.Data[1].ID {
+		t.Errorf("página por cursor = %+v, esperaba el último usuario", next.Data)
+	}
+	if strings.Contains(rec.Header().Get("Link"), `rel="next"`) {
+		t.Errorf("Link = %q, no esperaba rel=next en la última página", rec.Header().Get("Link"))
+	}
+}
+
+func TestUserHandler_GetAllUsersInvalidParams(t *testing.T) {
+	handler := newTestHandler(t, 0)
+
+	otherSortCursor := models.Cursor{Sort: "name", Value: "a", ID: "x"}.Encode()
+	invalidParams := []string{
+		"limit=abc",
+		"limit=0&offset=-1",
+		"limit=1000",
+		"cursor=!!!",
+		"offset=1&cursor=" + otherSortCursor,
+		"sort=password",
+		"cursor=" + otherSortCursor,
+		"emial=a@b.com",
+		"min_age=x",
+		"min_age=40&max_age=30",
+		"created_after=ayer",
+		"created_after=2024-02-01T00:00:00Z&created_before=2024-01-01T00:00:00Z",
+		"include_deleted=quizas",
+		"updated_since=ayer",
+	}
+	for _, query := range invalidParams {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users?"+query, nil)
+		rec := httptest.NewRecorder()
+		handler.GetAllUsers(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET ?%s status = %d, esperaba %d", query, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestUserHandler_GetAllUsersFilterAndSort(t *testing.T) {
+	handler := newTestHandler(t, 5)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users?min_age=21&max_age=23&sort=-age", nil)
+	rec := httptest.NewRecorder()
+	handler.GetAllUsers(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, esperaba %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	var body models.UserListResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("error al decodificar respuesta: %v", err)
+	}
+	ages := make([]int, 0, len(body.Data))
+	for _, user := range body.Data {
+		ages = append(ages, user.Age)
+	}
+	if fmt.Sprint(ages) != "[23 22 21]" {
+		t.Errorf("edades = %v, esperaba [23 22 21]", ages)
+	}
+	if !strings.Contains(rec.Header().Get("Link"), "sort=-age") {
+		t.Errorf("Link = %q, debe conservar sort", rec.Header().Get("Link"))
+	}
+}
+
+func TestUserHandler_CreateUserDuplicateEmail(t *testing.T) {
+	handler := newTestHandler(t, 1)
+
+	req := newJSONRequest(http.MethodPost, "/api/v1/users", `{"name": "Otro", "email": "USER0@example.com", "age": 30}`)
+	rec := httptest.NewRecorder()
+	handler.CreateUser(rec, req)
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("status = %d, esperaba %d", rec.Code, http.StatusConflict)
+	}
+}
+
+func TestUserHandler_DeleteAndRestore(t *testing.T) {
+	handler := newTestHandler(t, 2)
+	ctx := context.Background()
+
+	page, err := handler.service.GetAllUsers(ctx, models.UserQuery{})
+	if err != nil {
+		t.Fatalf("Error al listar usuarios: %v", err)
+	}
+	id := page.Users[0].ID
+	vars := map[string]string{"id": id}
+
+	rec := httptest.NewRecorder()
+	handler.DeleteUser(rec, mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+id, nil), vars))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("DELETE status = %d, esperaba %d", rec.Code, http.StatusOK)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.GetAllUsers(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users?include_deleted=true", nil))
+	var body models.UserListResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("error al decodificar respuesta: %v", err)
+	}
+	if body.Total != 2 {
+		t.Errorf("total con include_deleted = %d, esperaba 2", body.Total)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.RestoreUser(rec, mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/v1/users/"+id+"/restore", nil), vars))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("restore status = %d, esperaba %d", rec.Code, http.StatusOK)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.RestoreUser(rec, mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/v1/users/"+id+"/restore", nil), vars))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("restore repetido status = %d, esperaba %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestUserHandler_IfMatch(t *testing.T) {
+	handler := newTestHandler(t, 1)
+
+	page, err := handler.service.GetAllUsers(context.Background(), models.UserQuery{})
+	if err != nil {
+		t.Fatalf("Error al listar usuarios: %v", err)
+	}
+	id := page.Users[0].ID
+	vars := map[string]string{"id": id}
+
+	newRequest := func(method, ifMatch, body string) *http.Request {
+		req := newJSONRequest(method, "/api/v1/users/"+id, body)
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		return mux.SetURLVars(req, vars)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.GetUser(rec, newRequest(http.MethodGet, "", ""))
+	etag := rec.Header().Get("ETag")
+	if want := userETag(1, jsonContentType, nil); etag != want {
+		t.Fatalf("GET ETag = %q, esperaba %q", etag, want)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ReplaceUser(rec, newRequest(http.MethodPut, etag, `{"name": "User 0", "email": "user0@example.com", "age": 40}`))
+	if want := userETag(2, jsonContentType, nil); rec.Code != http.StatusOK || rec.Header().Get("ETag") != want {
+		t.Fatalf("PUT status = %d, ETag = %q, esperaba 200 y %q", rec.Code, rec.Header().Get("ETag"), want)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		ifMatch string
+		want    int
+	}{
+		{name: "PUT con ETag viejo", method: http.MethodPut, ifMatch: etag, want: http.StatusPreconditionFailed},
+		{name: "PUT con ETag débil", method: http.MethodPut, ifMatch: `W/"2"`, want: http.StatusPreconditionFailed},
+		{name: "PUT con varios ETags", method: http.MethodPut, ifMatch: `"1", "2"`, want: http.StatusBadRequest},
+		{name: "PUT con ETag ajeno", method: http.MethodPut, ifMatch: `"-2"`, want: http.StatusPreconditionFailed},
+		{name: "DELETE con ETag viejo", method: http.MethodDelete, ifMatch: etag, want: http.StatusPreconditionFailed},
+		// Solo se compara la versión, así que sirve el ETag de cualquier representación
+		{name: "DELETE con ETag actual", method: http.MethodDelete, ifMatch: userETag(2, xmlContentType, models.UserFields{models.UserFieldName}), want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := newRequest(tt.method, tt.ifMatch, `{"name": "User 0", "email": "user0@example.com", "age": 50}`)
+			if tt.method == http.MethodPut {
+				handler.ReplaceUser(rec, req)
+			} else {
+				handler.DeleteUser(rec, req)
+			}
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, esperaba %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestUserHandler_ETagPerRepresentation(t *testing.T) {
+	handler := newTestHandler(t, 1)
+	page, _ := handler.service.GetAllUsers(context.Background(), models.UserQuery{})
+	id := page.Users[0].ID
+
+	// Cada formato y selección de campos de la misma versión tiene su propio ETag fuerte
+	seen := make(map[string]string)
+	for _, target := range []struct{ query, accept string }{
+		{query: "", accept: jsonContentType},
+		{query: "", accept: xmlContentType},
+		{query: "fields=name", accept: jsonContentType},
+		{query: "fields=name", accept: xmlContentType},
+		{query: "fields=id,name,email,age,version,created_at,updated_at,deleted_at", accept: msgpackContentType},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/"+id+"?"+target.query, nil)
+		req.Header.Set("Accept", target.accept)
+		rec := httptest.NewRecorder()
+		handler.GetUser(rec, mux.SetURLVars(req, map[string]string{"id": id}))
+
+		etag := rec.Header().Get("ETag")
+		if !strings.HasPrefix(etag, `"1-`) || rec.Header().Get("Vary") != "Accept" {
+			t.Errorf("%s %s: ETag = %q, Vary = %q, esperaba un ETag fuerte de la versión 1 y Vary Accept", target.accept, target.query, etag, rec.Header().Get("Vary"))
+		}
+		if previous, ok := seen[etag]; ok {
+			t.Errorf("%s %s: ETag %q repetido de %s", target.accept, target.query, etag, previous)
+		}
+		seen[etag] = target.accept + " " + target.query
+	}
+
+	// Todos los campos seleccionados equivalen a no seleccionar ninguno
+	if got, want := userETag(1, jsonContentType, models.AllUserFields), userETag(1, jsonContentType, nil); got != want {
+		t.Errorf("userETag(AllUserFields) = %q, esperaba %q", got, want)
+	}
+}
+
+func TestUserHandler_PatchUser(t *testing.T) {
+	handler := newTestHandler(t, 2)
+
+	page, err := handler.service.GetAllUsers(context.Background(), models.UserQuery{Sort: models.UserSort{Field: models.SortByName}})
+	if err != nil {
+		t.Fatalf("Error al listar usuarios: %v", err)
+	}
+	id := page.Users[0].ID
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantCode    string
+		want        string
+	}{
+		{name: "merge patch", contentType: mergePatchContentType, body: `{"age": 33}`, wantStatus: http.StatusOK, want: `"age":33`},
+		{name: "merge patch con charset", contentType: mergePatchContentType + "; charset=utf-8", body: `{"name": "  Ana  "}`, wantStatus: http.StatusOK, want: `"name":"Ana"`},
+		{name: "merge patch que borra un campo obligatorio", contentType: mergePatchContentType, body: `{"name": null}`, wantStatus: http.StatusUnprocessableEntity, wantCode: apperrors.CodeValidationFailed, want: `"rule":"required"`},
+		{name: "merge patch que no es un objeto", contentType: mergePatchContentType, body: `[]`, wantStatus: http.StatusBadRequest, wantCode: apperrors.CodeInvalidPatch},
+		{name: "json patch", contentType: jsonPatchContentType, body: `[{"op": "test", "path": "/version", "value": 3}, {"op": "replace", "path": "/email", "value": "ANA@example.com"}]`, wantStatus: http.StatusOK, want: `"email":"ana@example.com"`},
+		{name: "json patch con test fallido", contentType: jsonPatchContentType, body: `[{"op": "test", "path": "/age", "value": 99}, {"op": "replace", "path": "/age", "value": 40}]`, wantStatus: http.StatusConflict, wantCode: apperrors.CodePatchConflict},
+		{name: "json patch con operación desconocida", contentType: jsonPatchContentType, body: `[{"op": "merge", "path": "/age", "value": 40}]`, wantStatus: http.StatusBadRequest, wantCode: apperrors.CodeInvalidPatch},
+		{name: "json patch sobre campo de solo lectura", contentType: jsonPatchContentType, body: `[{"op": "replace", "path": "/id", "value": "otro"}]`, wantStatus: http.StatusUnprocessableEntity, wantCode: apperrors.CodeValidationFailed, want: `"rule":"read_only"`},
+		{name: "json patch que agrega un campo", contentType: jsonPatchContentType, body: `[{"op": "add", "path": "/emial", "value": "x@example.com"}]`, wantStatus: http.StatusUnprocessableEntity, wantCode: apperrors.CodeValidationFailed, want: `"rule":"unknown_field"`},
+		{name: "json patch con tipo inválido", contentType: jsonPatchContentType, body: `[{"op": "replace", "path": "/age", "value": "treinta"}]`, wantStatus: http.StatusUnprocessableEntity, wantCode: apperrors.CodeValidationFailed, want: `"rule":"type"`},
+		{name: "email duplicado", contentType: mergePatchContentType, body: `{"email": "user1@example.com"}`, wantStatus: http.StatusConflict, wantCode: apperrors.CodeEmailAlreadyExists},
+		{name: "content type no admitido", contentType: "application/json", body: `{"age": 40}`, wantStatus: http.StatusUnsupportedMediaType, wantCode: apperrors.CodeUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/"+id, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			handler.PatchUser(rec, mux.SetURLVars(req, map[string]string{"id": id}))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantCode != "" && !strings.Contains(rec.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("respuesta = %s, esperaba code %q", rec.Body.String(), tt.wantCode)
+			}
+			if !strings.Contains(rec.Body.String(), tt.want) {
+				t.Errorf("respuesta = %s, esperaba que contenga %s", rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestUserHandler_ProblemDetails(t *testing.T) {
+	handler := newTestHandler(t, 0)
+
+	tests := []struct {
+		name    string
+		serve   func(w http.ResponseWriter, r *http.Request)
+		req     *http.Request
+		status  int
+		code    string
+		traceID string
+	}{
+		{
+			name:    "usuario inexistente",
+			serve:   handler.GetUser,
+			req:     mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/v1/users/nope", nil), map[string]string{"id": "nope"}),
+			status:  http.StatusNotFound,
+			code:    "user_not_found",
+			traceID: "req-123",
+		},
+		{
+			name:   "parámetro inválido",
+			serve:  handler.GetAllUsers,
+			req:    httptest.NewRequest(http.MethodGet, "/api/v1/users?min_age=x", nil),
+			status: http.StatusBadRequest,
+			code:   "invalid_query",
+		},
+		{
+			name:   "orden inválido",
+			serve:  handler.GetAllUsers,
+			req:    httptest.NewRequest(http.MethodGet, "/api/v1/users?sort=password", nil),
+			status: http.StatusBadRequest,
+			code:   "invalid_sort",
+		},
+		{
+			name:   "cuerpo inválido",
+			serve:  handler.CreateUser,
+			req:    newJSONRequest(http.MethodPost, "/api/v1/users", "{"),
+			status: http.StatusBadRequest,
+			code:   "invalid_body",
+		},
+		{
+			name:   "ruta inexistente",
+			serve:  NotFound,
+			req:    httptest.NewRequest(http.MethodGet, "/api/v1/nada", nil),
+			status: http.StatusNotFound,
+			code:   "route_not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.traceID != "" {
+				tt.req.Header.Set("X-Request-ID", tt.traceID)
+			}
+			rec := httptest.NewRecorder()
+			tt.serve(rec, tt.req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, esperaba %d", rec.Code, tt.status)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("Content-Type = %q, esperaba application/problem+json", ct)
+			}
+
+			var problem apperrors.Problem
+			if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+				t.Fatalf("error al decodificar respuesta: %v", err)
+			}
+			if problem.Code != tt.code || problem.Status != tt.status || problem.Type != "/problems/"+tt.code {
+				t.Errorf("problem = %+v, esperaba code %q y status %d", problem, tt.code, tt.status)
+			}
+			if problem.Instance != tt.req.URL.Path || problem.TraceID == "" {
+				t.Errorf("problem = %+v, esperaba instance %q y trace_id", problem, tt.req.URL.Path)
+			}
+			if tt.traceID != "" && problem.TraceID != tt.traceID {
+				t.Errorf("trace_id = %q, esperaba %q", problem.TraceID, tt.traceID)
+			}
+		})
+	}
+}
+
+func TestRequestTraceID(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	traced := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil).WithContext(traced)
+	if got := requestTraceID(req); got != traceID.String() {
+		t.Errorf("requestTraceID() con traza = %q, esperaba %q", got, traceID)
+	}
+
+	req.Header.Set("X-Request-ID", "req-123")
+	if got := requestTraceID(req); got != "req-123" {
+		t.Errorf("requestTraceID() con X-Request-ID = %q, esperaba req-123", got)
+	}
+}
+
+func TestRespondWithError_Log(t *testing.T) {
+	var output bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&output, logging.Options{Format: logging.FormatJSON}))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+	req = req.WithContext(logging.WithRequestID(req.Context(), "req-500"))
+	rec := httptest.NewRecorder()
+	respondWithError(rec, req, fmt.Errorf("error al obtener usuarios: %w", errors.New("dial tcp: connection refused")))
+
+	var problem apperrors.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatalf("error al decodificar respuesta: %v", err)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+		t.Fatalf("el log no es una línea JSON: %v\n%s", err, output.String())
+	}
+	if problem.TraceID != "req-500" || entry["request_id"] != "req-500" {
+		t.Errorf("trace_id = %q, request_id = %v; esperaba req-500 en ambos", problem.TraceID, entry["request_id"])
+	}
+	if entry["level"] != "ERROR" || !strings.Contains(fmt.Sprint(entry["error"]), "dial tcp") {
+		t.Errorf("log = %v, esperaba nivel ERROR con el error interno", entry)
+	}
+}
+
+func TestToAPIError_Internal(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+	apiErr := toAPIError(req, fmt.Errorf("error al obtener usuarios: %w", errors.New("dial tcp: connection refused")))
+
+	if apiErr.Status != http.StatusInternalServerError || apiErr.Code != "internal_error" {
+		t.Errorf("toAPIError() = %d %s, esperaba 500 internal_error", apiErr.Status, apiErr.Code)
+	}
+	if strings.Contains(apiErr.Message, "dial tcp") {
+		t.Errorf("toAPIError() expone el error interno: %q", apiErr.Message)
+	}
+
+	wrapped := fmt.Errorf("error al obtener usuario: %w", repositories.ErrUserNotFound)
+	if apiErr := toAPIError(req, wrapped); apiErr.Status != http.StatusNotFound {
+		t.Errorf("toAPIError() de error envuelto status = %d, esperaba 404", apiErr.Status)
+	}
+}
+
+func TestUserHandler_CreateUserValidation(t *testing.T) {
+	handler := newTestHandler(t, 0)
+
+	rec := httptest.NewRecorder()
+	handler.CreateUser(rec, newJSONRequest(http.MethodPost, "/api/v1/users", `{"name": "  ", "email": "no-es-email", "age": 0}`))
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, esperaba %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	var problem apperrors.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatalf("error al decodificar respuesta: %v", err)
+	}
+	if problem.Code != "validation_failed" || len(problem.Errors) != 3 {
+		t.Fatalf("problem = %+v, esperaba validation_failed con 3 errores", problem)
+	}
+	for i, field := range []string{"name", "email", "age"} {
+		if problem.Errors[i].Field != field || problem.Errors[i].Rule == "" {
+			t.Errorf("errors[%d] = %+v, esperaba el campo %s con su regla", i, problem.Errors[i], field)
+		}
+	}
+}
+
+func TestUserHandler_DecodeJSON(t *testing.T) {
+	service := services.NewUserService(repositories.NewMemoryUserRepository())
+	handler := NewUserHandler(service, WithMaxBodyBytes(64))
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		wantCode    string
+		wantField   string
+		wantDetail  string
+	}{
+		{name: "válido con charset", contentType: "application/json; charset=utf-8", body: `{"name": "Ana", "email": "ana@example.com", "age": 30}`, wantStatus: http.StatusCreated},
+		{name: "sin Content-Type", body: `{"name": "Ana"}`, wantStatus: http.StatusUnsupportedMediaType, wantCode: apperrors.CodeUnsupportedMediaType},
+		{name: "Content-Type no JSON", contentType: "text/plain", body: `{"name": "Ana"}`, wantStatus: http.StatusUnsupportedMediaType, wantCode: apperrors.CodeUnsupportedMediaType},
+		{name: "cuerpo demasiado grande", contentType: "application/json", body: `{"name": "` + strings.Repeat("a", 100) + `"}`, wantStatus: http.StatusRequestEntityTooLarge, wantCode: apperrors.CodeBodyTooLarge},
+		{name: "campo desconocido", contentType: "application/json", body: `{"name": "Ana", "emial": "ana@example.com"}`, wantStatus: http.StatusBadRequest, wantCode: apperrors.CodeInvalidBody, wantField: "emial"},
+		{name: "tipo inválido", contentType: "application/json", body: `{"age": "treinta"}`, wantStatus: http.StatusBadRequest, wantCode: apperrors.CodeInvalidBody, wantField: "age", wantDetail: "byte 17"},
+		{name: "JSON mal formado", contentType: "application/json", body: `{"name": }`, wantStatus: http.StatusBadRequest, wantCode: apperrors.CodeInvalidBody, wantDetail: "byte 10"},
+		{name: "varios valores", contentType: "application/json", body: `{"name": "Ana"} {}`, wantStatus: http.StatusBadRequest, wantCode: apperrors.CodeInvalidBody, wantDetail: "único valor"},
+		{name: "cuerpo vacío", contentType: "application/json", body: ``, wantStatus: http.StatusBadRequest, wantCode: apperrors.CodeInvalidBody},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler.CreateUser(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var problem apperrors.Problem
+			if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+				t.Fatalf("error al decodificar respuesta: %v", err)
+			}
+			if problem.Code != tt.wantCode {
+				t.Errorf("code = %q, esperaba %q", problem.Code, tt.wantCode)
+			}
+			if tt.wantField != "" && (len(problem.Errors) != 1 || problem.Errors[0].Field != tt.wantField) {
+				t.Errorf("errors = %+v, esperaba el campo %q", problem.Errors, tt.wantField)
+			}
+			if !strings.Contains(problem.Detail, tt.wantDetail) {
+				t.Errorf("detail = %q, esperaba que contenga %q", problem.Detail, tt.wantDetail)
+			}
+		})
+	}
+}
+
+func TestUserHandler_Batch(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantStatus   int
+		wantStatuses []int
+		wantCode     string
+	}{
+		{
+			name:         "atómico exitoso",
+			body:         `{"users": [{"name": "Ana", "email": "ana@example.com", "age": 30}, {"name": "Bruno", "email": "bruno@example.com", "age": 40}]}`,
+			wantStatus:   http.StatusOK,
+			wantStatuses: []int{http.StatusCreated, http.StatusCreated},
+		},
+		{
+			name:         "atómico con elemento inválido",
+			body:         `{"users": [{"name": "Ana", "email": "ana@example.com", "age": 30}, {"name": "", "email": "bruno@example.com", "age": 40}]}`,
+			wantStatus:   http.StatusMultiStatus,
+			wantStatuses: []int{http.StatusFailedDependency, http.StatusUnprocessableEntity},
+		},
+		{
+			name:         "parcial con email existente",
+			body:         `{"mode": "partial", "users": [{"name": "Ana", "email": "ana@example.com", "age": 30}, {"name": "Otro", "email": "user0@example.com", "age": 40}]}`,
+			wantStatus:   http.StatusMultiStatus,
+			wantStatuses: []int{http.StatusCreated, http.StatusConflict},
+		},
+		{
+			name:       "modo inválido",
+			body:       `{"mode": "todo", "users": [{"name": "Ana", "email": "ana@example.com", "age": 30}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_batch",
+		},
+		{
+			name:       "lote vacío",
+			body:       `{"users": []}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_batch",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestHandler(t, 1)
+			rec := httptest.NewRecorder()
+			handler.CreateUsersBatch(rec, newJSONRequest(http.MethodPost, "/api/v1/users:batch", tt.body))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantCode != "" {
+				var problem apperrors.Problem
+				if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+					t.Fatalf("error al decodificar respuesta: %v", err)
+				}
+				if problem.Code != tt.wantCode {
+					t.Errorf("code = %q, esperaba %q", problem.Code, tt.wantCode)
+				}
+				return
+			}
+
+			var response models.BatchResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatalf("error al decodificar respuesta: %v", err)
+			}
+			if len(response.Results) != len(tt.wantStatuses) {
+				t.Fatalf("results = %+v, esperaba %d elementos", response.Results, len(tt.wantStatuses))
+			}
+			for i, want := range tt.wantStatuses {
+				item := response.Results[i]
+				if item.Index != i || item.Status != want {
+					t.Errorf("results[%d] = {index: %d, status: %d}, esperaba {index: %d, status: %d}", i, item.Index, item.Status, i, want)
+				}
+				if (item.Error != nil) == (want < 300) {
+					t.Errorf("results[%d].error = %+v con status %d", i, item.Error, want)
+				}
+			}
+		})
+	}
+}
+
+func TestUserHandler_ExportUsers(t *testing.T) {
+	handler := newTestHandler(t, 3)
+	service := handler.service
+	if _, err := service.CreateUser(context.Background(), models.CreateUserRequest{Name: "=SUMA(A1)", Email: "formula@example.com", Age: 40}); err != nil {
+		t.Fatalf("Error al crear usuario: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ExportUsers(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users/export?sort=email", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("status = %d, Content-Type = %q, esperaba 200 text/csv", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 5 || lines[0] != "id,name,email,age,version,created_at,updated_at,deleted_at" {
+		t.Fatalf("CSV = %q, esperaba cabecera y 4 filas", rec.Body.String())
+	}
+	if !strings.Contains(lines[1], ",'=SUMA(A1),formula@example.com,40,1,") {
+		t.Errorf("fila = %q, esperaba la fórmula neutralizada", lines[1])
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ExportUsers(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users/export?format=ndjson&min_age=21", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Fatalf("status = %d, Content-Type = %q, esperaba 200 application/x-ndjson", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	decoder := json.NewDecoder(rec.Body)
+	count := 0
+	for decoder.More() {
+		var user models.User
+		if err := decoder.Decode(&user); err != nil {
+			t.Fatalf("error al decodificar línea: %v", err)
+		}
+		if user.Age < 21 {
+			t.Errorf("usuario %+v no cumple min_age", user)
+		}
+		count++
+	}
+	if count != 3 {
+		t.Errorf("se exportaron %d usuarios, esperaba 3", count)
+	}
+
+	for _, target := range []string{"/api/v1/users/export?format=xlsx", "/api/v1/users/export?limit=10", "/api/v1/users/export?sort=edad"} {
+		rec = httptest.NewRecorder()
+		handler.ExportUsers(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, esperaba %d", target, rec.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+func TestUserHandler_ImportUsers(t *testing.T) {
+	csvFile := "\ufeffName,email,age,id\n" +
+		"Ana,ana@example.com,30,ignorado\n" +
+		"Bruno,bruno@example.com,treinta,\n" +
+		"Carla,user0@example.com,40,\n" +
+		"Dante,dante@example.com\n"
+	ndjsonFile := `{"name": "Ana", "email": "ana@example.com", "age": 30, "id": "ignorado"}` + "\n\n" +
+		`{"name": "Bruno", "email": "bruno@example.com", "age": "treinta"}` + "\n" +
+		`{"name": "Carla", "email": "user0@example.com", "age": 40}` + "\n" +
+		`{"name": "Dante", "email": "dante@example.com", "age": 30, "rol": "admin"}` + "\n"
+
+	tests := []struct {
+		name         string
+		target       string
+		contentType  string
+		body         string
+		wantStatus   int
+		wantCode     string
+		wantImported int
+		wantLines    []int
+		wantCodes    []string
+		wantTotal    int
+	}{
+		{
+			name:         "csv",
+			target:       "/api/v1/users/import",
+			contentType:  "text/csv",
+			body:         csvFile,
+			wantStatus:   http.StatusOK,
+			wantImported: 1,
+			wantLines:    []int{3, 4, 5},
+			wantCodes:    []string{apperrors.CodeValidationFailed, apperrors.CodeEmailAlreadyExists, apperrors.CodeInvalidBody},
+			wantTotal:    2,
+		},
+		{
+			name:         "csv en dry run",
+			target:       "/api/v1/users/import?dry_run=true",
+			contentType:  "text/csv; charset=utf-8",
+			body:         csvFile,
+			wantStatus:   http.StatusOK,
+			wantImported: 1,
+			wantLines:    []int{3, 4, 5},
+			wantCodes:    []string{apperrors.CodeValidationFailed, apperrors.CodeEmailAlreadyExists, apperrors.CodeInvalidBody},
+			wantTotal:    1,
+		},
+		{
+			name:         "ndjson",
+			target:       "/api/v1/users/import",
+			contentType:  "application/x-ndjson",
+			body:         ndjsonFile,
+			wantStatus:   http.StatusOK,
+			wantImported: 1,
+			wantLines:    []int{3, 4, 5},
+			wantCodes:    []string{apperrors.CodeInvalidBody, apperrors.CodeEmailAlreadyExists, apperrors.CodeInvalidBody},
+			wantTotal:    2,
+		},
+		{
+			name:        "cabecera sin columna obligatoria",
+			target:      "/api/v1/users/import",
+			contentType: "text/csv",
+			body:        "name,email\nAna,ana@example.com\n",
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    apperrors.CodeInvalidImport,
+		},
+		{
+			name:        "Content-Type no admitido",
+			target:      "/api/v1/users/import",
+			contentType: "application/json",
+			body:        `[]`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantCode:    apperrors.CodeUnsupportedMediaType,
+		},
+		{
+			name:        "archivo demasiado grande",
+			target:      "/api/v1/users/import",
+			contentType: "text/csv",
+			body:        "name,email,age\n" + strings.Repeat("Ana,ana@example.com,30\n", 30),
+			wantStatus:  http.StatusRequestEntityTooLarge,
+			wantCode:    apperrors.CodeBodyTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestHandler(t, 1)
+			WithMaxImportBytes(512)(handler)
+
+			req := httptest.NewRequest(http.MethodPost, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			handler.ImportUsers(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantCode != "" {
+				var problem apperrors.Problem
+				if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+					t.Fatalf("error al decodificar respuesta: %v", err)
+				}
+				if problem.Code != tt.wantCode {
+					t.Errorf("code = %q, esperaba %q", problem.Code, tt.wantCode)
+				}
+				return
+			}
+
+			var report models.ImportReport
+			if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+				t.Fatalf("error al decodificar respuesta: %v", err)
+			}
+			if report.Imported != tt.wantImported || report.Failed != len(tt.wantLines) || report.Rows != tt.wantImported+len(tt.wantLines) {
+				t.Fatalf("informe = %+v, esperaba %d importadas y %d errores", report, tt.wantImported, len(tt.wantLines))
+			}
+			for i, line := range tt.wantLines {
+				if got := report.Errors[i]; got.Line != line || got.Error.Code != tt.wantCodes[i] {
+					t.Errorf("errors[%d] = línea %d %q, esperaba línea %d %q", i, got.Line, got.Error.Code, line, tt.wantCodes[i])
+				}
+			}
+
+			page, err := handler.service.GetAllUsers(context.Background(), models.UserQuery{})
+			if err != nil || page.Total != tt.wantTotal {
+				t.Errorf("usuarios tras importar = %d (%v), esperaba %d", page.Total, err, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestUserHandler_ExportImportCSVRoundTrip(t *testing.T) {
+	source := newTestHandler(t, 0)
+	names := []string{"=SUMA(A1)", "-Guión", "+Más", "@Arroba", "'Citado"}
+	for i, name := range names {
+		req := models.CreateUserRequest{Name: name, Email: fmt.Sprintf("user%d@example.com", i), Age: 30}
+		if _, err := source.service.CreateUser(context.Background(), req); err != nil {
+			t.Fatalf("Error al crear usuario: %v", err)
+		}
+	}
+
+	exported := httptest.NewRecorder()
+	source.ExportUsers(exported, httptest.NewRequest(http.MethodGet, "/api/v1/users/export?sort=email", nil))
+	if exported.Code != http.StatusOK {
+		t.Fatalf("export status = %d, esperaba %d", exported.Code, http.StatusOK)
+	}
+
+	target := newTestHandler(t, 0)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/import", exported.Body)
+	req.Header.Set("Content-Type", "text/csv")
+	rec := httptest.NewRecorder()
+	target.ImportUsers(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("import status = %d, esperaba %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+
+	// El ' que agrega la exportación se quita al importar; el resto de los valores queda igual
+	page, err := target.service.GetAllUsers(context.Background(), models.UserQuery{Sort: models.UserSort{Field: models.SortByEmail}})
+	if err != nil || len(page.Users) != len(names) {
+		t.Fatalf("usuarios importados = %d (%v), esperaba %d", len(page.Users), err, len(names))
+	}
+	for i, user := range page.Users {
+		if user.Name != names[i] {
+			t.Errorf("name = %q, esperaba %q", user.Name, names[i])
+		}
+	}
+}
+
+// plainEncoder es un formato de respuesta adicional para probar WithEncoder
+type plainEncoder struct{}
+
+func (plainEncoder) MediaType() string { return "text/plain" }
+
+func (plainEncoder) Encode(w io.Writer, v interface{}) error {
+	user, ok := v.(*models.User)
+	if !ok {
+		return ErrUnsupportedPayload
+	}
+	_, err := fmt.Fprintf(w, "%s <%s>", user.Name, user.Email)
+	return err
+}
+
+func TestUserHandler_ContentNegotiation(t *testing.T) {
+	handler := newTestHandler(t, 1)
+	WithEncoder(plainEncoder{})(handler)
+	page, _ := handler.service.GetAllUsers(context.Background(), models.UserQuery{})
+	id := page.Users[0].ID
+
+	getUser := func(userID string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			handler.GetUser(w, mux.SetURLVars(r, map[string]string{"id": userID}))
+		}
+	}
+
+	tests := []struct {
+		name            string
+		handler         http.HandlerFunc
+		accept          string
+		wantStatus      int
+		wantContentType string
+		check           func(t *testing.T, body []byte)
+	}{
+		{
+			name:            "sin Accept usa JSON",
+			handler:         getUser(id),
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+			check: func(t *testing.T, body []byte) {
+				var user models.User
+				if err := json.Unmarshal(body, &user); err != nil || user.ID != id {
+					t.Errorf("JSON = %s (%v), esperaba el usuario %s", body, err, id)
+				}
+			},
+		},
+		{
+			name:            "XML",
+			handler:         getUser(id),
+			accept:          "application/xml",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/xml",
+			check: func(t *testing.T, body []byte) {
+				var user models.User
+				if err := xml.Unmarshal(body, &user); err != nil || user.ID != id || user.Email != "user0@example.com" {
+					t.Errorf("XML = %s (%v), esperaba el usuario %s", body, err, id)
+				}
+			},
+		},
+		{
+			name:            "listado en CSV",
+			handler:         handler.GetAllUsers,
+			accept:          "text/csv",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			check: func(t *testing.T, body []byte) {
+				lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+				if len(lines) != 2 || !strings.HasPrefix(lines[1], id+",User 0,user0@example.com,20,") {
+					t.Errorf("CSV = %q, esperaba cabecera y una fila", body)
+				}
+			},
+		},
+		{
+			name:            "MessagePack preferido por calidad",
+			handler:         getUser(id),
+			accept:          "application/xml;q=0.5, application/msgpack",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/msgpack",
+			check: func(t *testing.T, body []byte) {
+				var user map[string]interface{}
+				if err := msgpack.Unmarshal(body, &user); err != nil || user["id"] != id || user["email"] != "user0@example.com" {
+					t.Errorf("MessagePack = %v (%v), esperaba el usuario %s", user, err, id)
+				}
+			},
+		},
+		{
+			name:            "comodín de tipo usa la preferencia del servidor",
+			handler:         getUser(id),
+			accept:          "application/*",
+			wantStatus:      http.StatusOK,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "encoder agregado con WithEncoder",
+			handler:         getUser(id),
+			accept:          "text/plain",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/plain; charset=utf-8",
+			check: func(t *testing.T, body []byte) {
+				if string(body) != "User 0 <user0@example.com>" {
+					t.Errorf("texto = %q", body)
+				}
+			},
+		},
+		{
+			name:            "formato que no representa la respuesta",
+			handler:         handler.PurgeDeletedUsers,
+			accept:          "text/csv",
+			wantStatus:      http.StatusNotAcceptable,
+			wantContentType: apperrors.ProblemContentType,
+			check: func(t *testing.T, body []byte) {
+				var problem apperrors.Problem
+				if err := json.Unmarshal(body, &problem); err != nil || problem.Code != apperrors.CodeNotAcceptable {
+					t.Errorf("problem = %s (%v), esperaba %q", body, err, apperrors.CodeNotAcceptable)
+				}
+			},
+		},
+		{
+			name:            "calidad 0 excluye el formato",
+			handler:         getUser(id),
+			accept:          "application/json;q=0, text/csv;q=0.1",
+			wantStatus:      http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+		},
+		{
+			name:            "error en XML si el cliente prefiere XML",
+			handler:         getUser("nope"),
+			accept:          "application/xml",
+			wantStatus:      http.StatusNotFound,
+			wantContentType: apperrors.ProblemXMLContentType,
+			check: func(t *testing.T, body []byte) {
+				var problem struct {
+					XMLName xml.Name
+					Code    string `xml:"code"`
+				}
+				if err := xml.Unmarshal(body, &problem); err != nil || problem.XMLName.Space != "urn:ietf:rfc:7807" || problem.Code != apperrors.CodeUserNotFound {
+					t.Errorf("problem = %s (%v), esperaba problem+xml con %q", body, err, apperrors.CodeUserNotFound)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+			tt.handler(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, esperaba %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, esperaba %q", got, tt.wantContentType)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary = %q, esperaba %q", got, "Accept")
+			}
+			if tt.check != nil {
+				tt.check(t, rec.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestUserHandler_RequireAcceptable(t *testing.T) {
+	handler := newTestHandler(t, 0)
+	called := false
+	next := handler.RequireAcceptable(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	for accept, wantCalled := range map[string]bool{
+		"":                     true,
+		"*/*":                  true,
+		"application/x-ndjson": true,
+		"text/html":            false,
+		"application/json;q=0": false,
+	} {
+		called = false
+		req := newJSONRequest(http.MethodPost, "/api/v1/users", `{}`)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		next.ServeHTTP(rec, req)
+
+		if called != wantCalled {
+			t.Errorf("Accept %q: handler ejecutado = %v, esperaba %v", accept, called, wantCalled)
+		}
+		if !wantCalled && rec.Code != http.StatusNotAcceptable {
+			t.Errorf("Accept %q: status = %d, esperaba %d", accept, rec.Code, http.StatusNotAcceptable)
+		}
+	}
+}
+
+func TestUserHandler_Fields(t *testing.T) {
+	handler := newTestHandler(t, 3)
+	page, _ := handler.service.GetAllUsers(context.Background(), models.UserQuery{})
+	user := page.Users[0]
+
+	getUser := func(rawQuery, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users/"+user.ID+"?"+rawQuery, nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		handler.GetUser(rec, mux.SetURLVars(req, map[string]string{"id": user.ID}))
+		return rec
+	}
+	getAll := func(rawQuery, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/users?"+rawQuery, nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		handler.GetAllUsers(rec, req)
+		return rec
+	}
+
+	t.Run("usuario con campos seleccionados", func(t *testing.T) {
+		rec := getUser("fields=name,id,name", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+		}
+		want := fmt.Sprintf(`{"id":%q,"name":%q}`, user.ID, user.Name)
+		if got := strings.TrimSpace(rec.Body.String()); got != want {
+			t.Errorf("body = %s, esperaba %s", got, want)
+		}
+		fields := models.UserFields{models.UserFieldID, models.UserFieldName}
+		if got, want := rec.Header().Get("ETag"), userETag(user.Version, jsonContentType, fields); got != want {
+			t.Errorf("ETag = %q, esperaba %q", got, want)
+		}
+	})
+
+	t.Run("usuario en XML y CSV", func(t *testing.T) {
+		rec := getUser("fields=email", "application/xml")
+		want := fmt.Sprintf("<user><email>%s</email></user>", user.Email)
+		if !strings.HasSuffix(strings.TrimSpace(rec.Body.String()), want) {
+			t.Errorf("XML = %s, esperaba %s", rec.Body.String(), want)
+		}
+
+		rec = getUser("fields=email,age", "text/csv")
+		want = fmt.Sprintf("email,age\n%s,%d\n", user.Email, user.Age)
+		if rec.Body.String() != want {
+			t.Errorf("CSV = %q, esperaba %q", rec.Body.String(), want)
+		}
+	})
+
+	t.Run("listado con campos seleccionados", func(t *testing.T) {
+		rec := getAll("fields=email&limit=2", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+		}
+		var response struct {
+			Data       []map[string]interface{} `json:"data"`
+			Total      int                      `json:"total"`
+			NextCursor string                   `json:"next_cursor"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("error al decodificar respuesta: %v", err)
+		}
+		if len(response.Data) != 2 || response.Total != 3 || response.NextCursor == "" {
+			t.Fatalf("respuesta = %+v, esperaba 2 usuarios de 3 y cursor", response)
+		}
+		for _, item := range response.Data {
+			if len(item) != 1 || item["email"] == nil {
+				t.Errorf("usuario = %v, esperaba solo email", item)
+			}
+		}
+		if link := rec.Header().Get("Link"); !strings.Contains(link, "fields=email") {
+			t.Errorf("Link = %q, esperaba conservar fields", link)
+		}
+
+		rec = getAll("fields=name", "text/csv")
+		if lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n"); len(lines) != 4 || lines[0] != "name" {
+			t.Errorf("CSV = %q, esperaba cabecera name y 3 filas", rec.Body.String())
+		}
+	})
+
+	for name, rawQuery := range map[string]string{
+		"campo inexistente": "fields=id,password",
+		"lista vacía":       "fields=",
+		"campo vacío":       "fields=id,,name",
+	} {
+		t.Run(name, func(t *testing.T) {
+			for _, rec := range []*httptest.ResponseRecorder{getUser(rawQuery, ""), getAll(rawQuery, "")} {
+				var problem apperrors.Problem
+				if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil || rec.Code != http.StatusBadRequest || problem.Code != apperrors.CodeInvalidFields {
+					t.Errorf("status = %d, problem = %s, esperaba 400 %q", rec.Code, rec.Body.String(), apperrors.CodeInvalidFields)
+				}
+			}
+		})
+	}
+}
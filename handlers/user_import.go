@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"helloworld/apperrors"
+	"helloworld/models"
+	"helloworld/services"
+	"helloworld/validation"
+)
+
+// DefaultMaxImportBytes es el tamaño máximo por defecto de un archivo de importación
+const DefaultMaxImportBytes int64 = 32 << 20
+
+// maxImportLineBytes es el tamaño máximo de una línea NDJSON
+const maxImportLineBytes = 64 << 10
+
+// importParams son los parámetros aceptados por la importación de usuarios
+var importParams = allowedParams(nil, "dry_run")
+
+// importColumns indica qué columnas admite una importación: las de CreateUserRequest son
+// obligatorias y las de solo lectura de una exportación se aceptan y se ignoran
+var importColumns = map[string]bool{
+	"name":       true,
+	"email":      true,
+	"age":        true,
+	"id":         false,
+	"version":    false,
+	"created_at": false,
+	"updated_at": false,
+	"deleted_at": false,
+}
+
+// ImportUsers maneja la importación de usuarios
+// @Summary      Importar usuarios
+// @Description  Crea los usuarios de un archivo CSV (con cabecera) o NDJSON según el Content-Type, validando cada fila con las mismas reglas que POST /users. Las filas inválidas se informan con su número de línea y no impiden importar las demás. Las columnas id, version, created_at, updated_at y deleted_at de una exportación se ignoran, y en CSV se quita el ' que la exportación antepone a los valores que empiezan con =, +, -, @. Con dry_run=true solo se validan las filas, sin crear usuarios.
+// @Tags         usuarios
+// @Accept       text/csv
+// @Accept       application/x-ndjson
+// @Produce      json
+// @Produce      xml
+// @Produce      application/msgpack
+// @Param        dry_run  query     bool    false  "Validar sin crear usuarios"
+// @Param        file     body      string  true   "Archivo CSV o NDJSON"
+// @Success      200      {object}  models.ImportReport
+// @Failure      400      {object}  apperrors.Problem
+// @Failure      413      {object}  apperrors.Problem
+// @Failure      415      {object}  apperrors.Problem
+// @Failure      500      {object}  apperrors.Problem
+// @Router       /users/import [post]
+func (h *UserHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	if err := rejectUnknownParams(values, importParams); err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+	dryRun := false
+	if raw := values.Get("dry_run"); raw != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			respondWithError(w, r, invalidQuery("el parámetro dry_run debe ser true o false"))
+			return
+		}
+	}
+	if err := requireContentType(r, csvContentType, ndjsonContentType); err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")) // nolint:errcheck // Validado en requireContentType
+
+	controller := http.NewResponseController(w)
+	body := idleTimeoutReader{
+		reader:     http.MaxBytesReader(w, r.Body, h.importDecoder.maxBodyBytes),
+		controller: controller,
+	}
+
+	var rows services.UserRowReader
+	if mediaType == csvContentType {
+		csvRows, err := newCSVUserReader(body, h.importDecoder)
+		if err != nil {
+			respondWithError(w, r, err)
+			return
+		}
+		rows = csvRows
+	} else {
+		rows = newNDJSONUserReader(body, h.importDecoder)
+	}
+
+	result, err := h.service.ImportUsers(r.Context(), rows, dryRun)
+	if err != nil {
+		respondWithError(w, r, err)
+		return
+	}
+
+	report := models.ImportReport{
+		DryRun:   result.DryRun,
+		Rows:     result.Rows,
+		Imported: result.Imported,
+		Failed:   len(result.Failures),
+		Errors:   make([]models.ImportRowError, 0, len(result.Failures)),
+	}
+	for _, failure := range result.Failures {
+		report.Errors = append(report.Errors, models.ImportRowError{
+			Line:  failure.Line,
+			Error: toAPIError(r, failure.Err).Problem("", ""),
+		})
+	}
+
+	// Procesar el archivo puede superar el WriteTimeout del servidor
+	_ = controller.SetWriteDeadline(time.Now().Add(streamIdleTimeout)) // nolint:errcheck // No todos los ResponseWriter admiten deadlines
+	h.respond(w, r, http.StatusOK, report)
+}
+
+// idleTimeoutReader extiende el deadline de lectura de la conexión antes de cada lectura, de modo
+// que un archivo grande solo se corta si el cliente deja de enviar datos durante streamIdleTimeout
+type idleTimeoutReader struct {
+	reader     io.Reader
+	controller *http.ResponseController
+}
+
+func (r idleTimeoutReader) Read(p []byte) (int, error) {
+	_ = r.controller.SetReadDeadline(time.Now().Add(streamIdleTimeout)) // nolint:errcheck // No todos los ResponseWriter admiten deadlines
+	return r.reader.Read(p)
+}
+
+// invalidImport crea el error 400 de un archivo de importación que no puede procesarse
+func invalidImport(format string, args ...interface{}) *apperrors.Error {
+	return apperrors.New(http.StatusBadRequest, apperrors.CodeInvalidImport, fmt.Sprintf(format, args...))
+}
+
+// csvUserReader lee usuarios de un CSV cuya primera fila es la cabecera
+type csvUserReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+	decoder requestDecoder
+}
+
+// newCSVUserReader lee y valida la cabecera: sin columnas desconocidas ni repetidas y con todas las obligatorias
+func newCSVUserReader(r io.Reader, decoder requestDecoder) (*csvUserReader, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, invalidImport("el archivo está vacío; la primera fila debe ser la cabecera")
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, invalidImport("cabecera CSV mal formada: %v", parseErr.Err)
+		}
+		return nil, decoder.decodeError(err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			// Las planillas suelen anteponer un BOM al exportar en UTF-8
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, known := importColumns[name]; !known {
+			return nil, invalidImport("columna desconocida %q en la cabecera", name)
+		}
+		if _, repeated := columns[name]; repeated {
+			return nil, invalidImport("columna %q repetida en la cabecera", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range csvColumns {
+		if _, present := columns[name]; importColumns[name] && !present {
+			return nil, invalidImport("falta la columna %q en la cabecera", name)
+		}
+	}
+
+	return &csvUserReader{reader: reader, columns: columns, decoder: decoder}, nil
+}
+
+// Next implementa services.UserRowReader
+func (c *csvUserReader) Next() (models.ImportRow, error) {
+	record, err := c.reader.Read()
+	if errors.Is(err, io.EOF) {
+		return models.ImportRow{}, io.EOF
+	}
+	if err != nil {
+		var parseErr *csv.ParseError
+		if !errors.As(err, &parseErr) {
+			return models.ImportRow{}, c.decoder.decodeError(err)
+		}
+		message := "fila CSV mal formada: " + parseErr.Err.Error()
+		if errors.Is(parseErr.Err, csv.ErrFieldCount) {
+			message = fmt.Sprintf("la fila tiene %d columnas y la cabecera %d", len(record), len(c.columns))
+		}
+		return models.ImportRow{Line: parseErr.StartLine, Err: invalidBody(message)}, nil
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	row := models.ImportRow{
+		Line: line,
+		User: models.CreateUserRequest{
+			Name:  csvUnescape(record[c.columns["name"]]),
+			Email: csvUnescape(record[c.columns["email"]]),
+		},
+	}
+	// Una edad vacía se trata como 0 para que la informe la validación del servicio
+	if age := strings.TrimSpace(record[c.columns["age"]]); age != "" {
+		if row.User.Age, err = strconv.Atoi(age); err != nil {
+			row.Err = validation.Errors{{Field: "age", Rule: validation.RuleType, Message: "debe ser un número entero", Err: services.ErrInvalidAge}}
+		}
+	}
+	return row, nil
+}
+
+// csvUnescape quita el ' que csvSafe antepone al exportar, para que reimportar una exportación conserve los valores
+func csvUnescape(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+// ndjsonUserReader lee un usuario por línea de un archivo NDJSON; las líneas vacías se omiten
+type ndjsonUserReader struct {
+	scanner *bufio.Scanner
+	line    int
+	decoder requestDecoder
+}
+
+func newNDJSONUserReader(r io.Reader, decoder requestDecoder) *ndjsonUserReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), maxImportLineBytes)
+	return &ndjsonUserReader{scanner: scanner, decoder: decoder}
+}
+
+// Next implementa services.UserRowReader
+func (n *ndjsonUserReader) Next() (models.ImportRow, error) {
+	for n.scanner.Scan() {
+		n.line++
+		data := bytes.TrimSpace(n.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		row := models.ImportRow{Line: n.line}
+		row.User, row.Err = n.decodeLine(data)
+		return row, nil
+	}
+
+	if err := n.scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return models.ImportRow{}, invalidImport("la línea %d supera el máximo de %d bytes", n.line+1, maxImportLineBytes)
+		}
+		return models.ImportRow{}, n.decoder.decodeError(err)
+	}
+	return models.ImportRow{}, io.EOF
+}
+
+// importRecord es una línea NDJSON: los campos de CreateUserRequest más los de solo lectura
+// de una exportación, que se aceptan para poder reimportarla y se ignoran
+type importRecord struct {
+	models.CreateUserRequest
+	ID        json.RawMessage `json:"id"`
+	Version   json.RawMessage `json:"version"`
+	CreatedAt json.RawMessage `json:"created_at"`
+	UpdatedAt json.RawMessage `json:"updated_at"`
+	DeletedAt json.RawMessage `json:"deleted_at"`
+}
+
+// decodeLine decodifica una línea con las mismas reglas estrictas que los cuerpos JSON
+func (n *ndjsonUserReader) decodeLine(data []byte) (models.CreateUserRequest, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var record importRecord
+	if err := decoder.Decode(&record); err != nil {
+		return record.CreateUserRequest, n.decoder.decodeError(err)
+	}
+	if err := decoder.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+		return record.CreateUserRequest, invalidBody("la línea debe contener un único objeto JSON")
+	}
+	return record.CreateUserRequest, nil
+}
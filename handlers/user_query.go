@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"helloworld/apperrors"
+	"helloworld/models"
+)
+
+// userFilterParams son los parámetros de filtro y orden comunes al listado y a la exportación
+var userFilterParams = []string{
+	"sort", "name", "email", "min_age", "max_age",
+	"created_after", "created_before", "updated_since", "include_deleted",
+}
+
+// userQueryParams son los parámetros aceptados por el listado de usuarios
+var userQueryParams = allowedParams(userFilterParams, "limit", "offset", "cursor", "fields")
+
+// parseUserQuery convierte la query string del listado en un models.UserQuery.
+// Rechaza parámetros desconocidos para que un error de tipeo no se ignore en silencio.
+func parseUserQuery(values url.Values) (models.UserQuery, error) {
+	if err := rejectUnknownParams(values, userQueryParams); err != nil {
+		return models.UserQuery{}, err
+	}
+
+	query, err := parsePagination(values)
+	if err != nil {
+		return query, err
+	}
+
+	if query.Fields, err = parseFieldsParam(values); err != nil {
+		return query, err
+	}
+
+	query.Filter, query.Sort, err = parseUserFilter(values)
+	return query, err
+}
+
+// parseFieldsParam lee la selección de campos opcional del parámetro fields
+func parseFieldsParam(values url.Values) (models.UserFields, error) {
+	if _, ok := values["fields"]; !ok {
+		return nil, nil
+	}
+	return models.ParseUserFields(values.Get("fields"))
+}
+
+// parseUserFilter lee de la query string los parámetros de userFilterParams
+func parseUserFilter(values url.Values) (models.UserFilter, models.UserSort, error) {
+	var (
+		filter  models.UserFilter
+		ordered models.UserSort
+		err     error
+	)
+
+	if raw := values.Get("sort"); raw != "" {
+		if ordered, err = models.ParseUserSort(raw); err != nil {
+			return filter, ordered, err
+		}
+	}
+
+	filter.NamePrefix = values.Get("name")
+	filter.Email = values.Get("email")
+
+	if filter.MinAge, err = parseIntParam(values, "min_age"); err != nil {
+		return filter, ordered, err
+	}
+	if filter.MaxAge, err = parseIntParam(values, "max_age"); err != nil {
+		return filter, ordered, err
+	}
+	if filter.CreatedAfter, err = parseTimeParam(values, "created_after"); err != nil {
+		return filter, ordered, err
+	}
+	if filter.CreatedBefore, err = parseTimeParam(values, "created_before"); err != nil {
+		return filter, ordered, err
+	}
+	if filter.UpdatedSince, err = parseTimeParam(values, "updated_since"); err != nil {
+		return filter, ordered, err
+	}
+	if raw := values.Get("include_deleted"); raw != "" {
+		if filter.IncludeDeleted, err = strconv.ParseBool(raw); err != nil {
+			return filter, ordered, invalidQuery("el parámetro include_deleted debe ser true o false")
+		}
+	}
+
+	return filter, ordered, nil
+}
+
+// allowedParams construye el conjunto de parámetros admitidos a partir de common y extra
+func allowedParams(common []string, extra ...string) map[string]bool {
+	allowed := make(map[string]bool, len(common)+len(extra))
+	for _, name := range append(append([]string(nil), common...), extra...) {
+		allowed[name] = true
+	}
+	return allowed
+}
+
+// rejectUnknownParams retorna un error que enumera los parámetros de values que no están en allowed
+func rejectUnknownParams(values url.Values, allowed map[string]bool) error {
+	unknown := make([]string, 0)
+	for key := range values {
+		if !allowed[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return invalidQuery("parámetros desconocidos: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// invalidQuery crea el error de un parámetro de la query string mal formado
+func invalidQuery(format string, args ...interface{}) *apperrors.Error {
+	return apperrors.New(http.StatusBadRequest, apperrors.CodeInvalidQuery, fmt.Sprintf(format, args...))
+}
+
+// parseIntParam lee un parámetro entero opcional
+func parseIntParam(values url.Values, name string) (*int, error) {
+	raw := values.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, invalidQuery("el parámetro %s debe ser un número entero", name)
+	}
+	return &value, nil
+}
+
+// parseTimeParam lee un parámetro de fecha opcional en formato RFC 3339
+func parseTimeParam(values url.Values, name string) (*time.Time, error) {
+	raw := values.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, invalidQuery("el parámetro %s debe ser una fecha RFC 3339 (ej. 2024-01-31T00:00:00Z)", name)
+	}
+	return &value, nil
+}
@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+
+	"helloworld/migrations"
+)
+
+// PingChecker verifica que la base de datos responda
+func PingChecker(db *sql.DB) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		if err := db.PingContext(ctx); err != nil {
+			return fmt.Errorf("la base de datos no responde: %w", err)
+		}
+		return nil
+	})
+}
+
+// MigrationChecker verifica que el esquema tenga aplicadas todas las migraciones que conoce
+// este binario. Las versiones aplicadas que no conoce (un esquema más nuevo, durante un
+// despliegue gradual) no se consideran un error. Solo consulta la base de datos, por lo que
+// funciona con un usuario sin permisos de DDL o con MIGRATE_ON_START=false.
+func MigrationChecker(migrator *migrations.Migrator) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("error al leer el estado de las migraciones: %w", err)
+		}
+		pending := make([]string, 0)
+		for _, status := range statuses {
+			if !status.Applied {
+				pending = append(pending, fmt.Sprintf("%04d_%s", status.Version, status.Name))
+			}
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("migraciones pendientes: %s", strings.Join(pending, ", "))
+		}
+		return nil
+	})
+}
+
+// poolChecker recuerda cuántas esperas por conexión había en la verificación anterior
+type poolChecker struct {
+	db *sql.DB
+
+	mu        sync.Mutex
+	waitCount int64
+}
+
+// PoolChecker verifica que el pool de conexiones no esté saturado: falla si todas las
+// conexiones están en uso y alguna petición tuvo que esperar una conexión desde la
+// verificación anterior. Un pool sin límite de conexiones nunca se considera saturado, y
+// tampoco uno de una única conexión (SQLite), donde esperar es el funcionamiento normal:
+// cualquier export en curso la mantiene ocupada.
+func PoolChecker(db *sql.DB) Checker {
+	return &poolChecker{db: db, waitCount: db.Stats().WaitCount}
+}
+
+// Check implementa Checker
+func (p *poolChecker) Check(ctx context.Context) error {
+	stats := p.db.Stats()
+
+	p.mu.Lock()
+	waited := stats.WaitCount - p.waitCount
+	p.waitCount = stats.WaitCount
+	p.mu.Unlock()
+
+	if stats.MaxOpenConnections > 1 && stats.InUse >= stats.MaxOpenConnections && waited > 0 {
+		return fmt.Errorf("pool de conexiones saturado: %d de %d en uso, %d esperas desde la verificación anterior",
+			stats.InUse, stats.MaxOpenConnections, waited)
+	}
+	return nil
+}
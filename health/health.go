@@ -0,0 +1,204 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Estados de un reporte y de cada verificación
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// Valores por defecto de Health
+const (
+	DefaultCheckTimeout = 2 * time.Second
+	DefaultCacheTTL     = 5 * time.Second
+)
+
+// Checker verifica una dependencia; un error indica que la instancia no debe recibir tráfico
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapta una función a Checker
+type CheckerFunc func(ctx context.Context) error
+
+// Check implementa Checker
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// CheckResult es el resultado de una verificación
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report es el resultado de todas las verificaciones; Status es StatusFail si alguna falló
+type Report struct {
+	Status    string        `json:"status"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Checks    []CheckResult `json:"checks"`
+}
+
+// namedChecker es un Checker registrado
+type namedChecker struct {
+	name    string
+	checker Checker
+}
+
+// Health ejecuta las verificaciones registradas para decidir si la instancia está lista.
+// Los resultados se reutilizan durante el TTL de la caché para que las sondas frecuentes de
+// varios orquestadores y balanceadores no multipliquen las consultas a las dependencias.
+type Health struct {
+	readiness *Readiness
+	timeout   time.Duration
+	cacheTTL  time.Duration
+	now       func() time.Time
+
+	// mu serializa las ejecuciones: las sondas que llegan durante una esperan su resultado
+	mu     sync.Mutex
+	checks []namedChecker
+	cached *Report
+}
+
+// Option configura un Health
+type Option func(*Health)
+
+// WithCheckTimeout limita la duración de cada verificación
+func WithCheckTimeout(timeout time.Duration) Option {
+	return func(h *Health) {
+		if timeout > 0 {
+			h.timeout = timeout
+		}
+	}
+}
+
+// WithCacheTTL define durante cuánto tiempo se reutiliza un reporte; 0 ejecuta las verificaciones en cada sonda
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(h *Health) {
+		if ttl >= 0 {
+			h.cacheTTL = ttl
+		}
+	}
+}
+
+// New crea un Health sin verificaciones; la instancia deja de estar lista cuando readiness se drena
+func New(readiness *Readiness, opts ...Option) *Health {
+	h := &Health{
+		readiness: readiness,
+		timeout:   DefaultCheckTimeout,
+		cacheTTL:  DefaultCacheTTL,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Register agrega una verificación; los resultados se informan en el orden de registro
+func (h *Health) Register(name string, checker Checker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, namedChecker{name: name, checker: checker})
+	h.cached = nil
+}
+
+// Ready ejecuta las verificaciones, o retorna el reporte en caché si no venció. Durante el
+// apagado falla sin consultar las dependencias.
+func (h *Health) Ready(ctx context.Context) Report {
+	if !h.readiness.Ready() {
+		return Report{
+			Status:    StatusFail,
+			CheckedAt: h.now(),
+			Checks:    []CheckResult{{Name: "shutdown", Status: StatusFail, Error: "la instancia se está apagando"}},
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cached != nil && h.now().Sub(h.cached.CheckedAt) < h.cacheTTL {
+		return *h.cached
+	}
+
+	// El resultado se comparte con otras sondas, por lo que no depende de la cancelación de esta petición
+	ctx = context.WithoutCancel(ctx)
+	report := Report{Status: StatusOK, CheckedAt: h.now(), Checks: make([]CheckResult, len(h.checks))}
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func(i int, check namedChecker) {
+			defer wg.Done()
+			report.Checks[i] = h.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	h.cached = &report
+	return report
+}
+
+// run ejecuta una verificación con el timeout configurado
+func (h *Health) run(ctx context.Context, check namedChecker) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.checker.Check(ctx)
+	if err == nil && ctx.Err() != nil {
+		// Un checker que ignora el contexto no debe informar éxito tras el timeout
+		err = ctx.Err()
+	}
+	result := CheckResult{
+		Name:      check.name,
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// LiveHandler responde 200 mientras el proceso puede atender peticiones. No consulta las
+// dependencias: una base de datos caída no se soluciona reiniciando la instancia.
+func (h *Health) LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeReport(w, http.StatusOK, Report{Status: StatusOK, CheckedAt: h.now(), Checks: []CheckResult{}})
+	})
+}
+
+// ReadyHandler responde el reporte de Ready, con 503 si alguna verificación falló
+func (h *Health) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := h.Ready(r.Context())
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		writeReport(w, status, report)
+	})
+}
+
+// writeReport envía report como JSON sin permitir que se guarde en caches intermedios
+func writeReport(w http.ResponseWriter, status int, report Report) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	// nolint:errcheck // Error de escritura en respuesta HTTP, no hay recuperación posible
+	_ = json.NewEncoder(w).Encode(report)
+}
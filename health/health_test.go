@@ -0,0 +1,133 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingChecker cuenta sus ejecuciones y retorna err
+type countingChecker struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (c *countingChecker) Check(ctx context.Context) error {
+	c.calls.Add(1)
+	return c.err
+}
+
+func getReport(t *testing.T, handler http.Handler) (int, Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("error al decodificar reporte %q: %v", rec.Body.String(), err)
+	}
+	if got := rec.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Cache-Control = %q, esperaba no-store", got)
+	}
+	return rec.Code, report
+}
+
+func TestHealth_Ready(t *testing.T) {
+	checks := New(NewReadiness(), WithCacheTTL(0))
+	database := &countingChecker{}
+	pool := &countingChecker{err: errors.New("pool saturado")}
+	checks.Register("database", database)
+	checks.Register("connection_pool", pool)
+
+	status, report := getReport(t, checks.ReadyHandler())
+	if status != http.StatusServiceUnavailable || report.Status != StatusFail {
+		t.Fatalf("status = %d, reporte = %+v, esperaba 503 fail", status, report)
+	}
+	if len(report.Checks) != 2 {
+		t.Fatalf("checks = %+v, esperaba 2 en orden de registro", report.Checks)
+	}
+	if got := report.Checks[0]; got.Name != "database" || got.Status != StatusOK || got.Error != "" {
+		t.Errorf("database = %+v, esperaba ok", got)
+	}
+	if got := report.Checks[1]; got.Name != "connection_pool" || got.Status != StatusFail || got.Error != "pool saturado" {
+		t.Errorf("connection_pool = %+v, esperaba fail con el error", got)
+	}
+
+	pool.err = nil
+	if status, report := getReport(t, checks.ReadyHandler()); status != http.StatusOK || report.Status != StatusOK {
+		t.Errorf("status = %d, reporte = %+v, esperaba 200 ok", status, report)
+	}
+}
+
+func TestHealth_CheckTimeout(t *testing.T) {
+	checks := New(NewReadiness(), WithCheckTimeout(50*time.Millisecond))
+	checks.Register("slow", CheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	// Un checker que ignora el contexto tampoco informa éxito tras el timeout
+	checks.Register("ignores_context", CheckerFunc(func(ctx context.Context) error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	}))
+
+	report := checks.Ready(context.Background())
+	for _, result := range report.Checks {
+		if result.Status != StatusFail || result.LatencyMs < 50 {
+			t.Errorf("%s = %+v, esperaba fail tras el timeout", result.Name, result)
+		}
+	}
+}
+
+func TestHealth_Cache(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	checks := New(NewReadiness(), WithCacheTTL(5*time.Second))
+	checks.now = func() time.Time { return now }
+	database := &countingChecker{}
+	checks.Register("database", database)
+
+	// Una sonda cancelada no deja un resultado fallido en la caché
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if report := checks.Ready(ctx); report.Status != StatusOK {
+		t.Errorf("Ready() con contexto cancelado = %+v, esperaba ok", report)
+	}
+
+	now = now.Add(4 * time.Second)
+	checks.Ready(context.Background())
+	if got := database.calls.Load(); got != 1 {
+		t.Errorf("verificaciones dentro del TTL = %d, esperaba 1", got)
+	}
+
+	now = now.Add(time.Second)
+	checks.Ready(context.Background())
+	if got := database.calls.Load(); got != 2 {
+		t.Errorf("verificaciones tras vencer el TTL = %d, esperaba 2", got)
+	}
+}
+
+func TestHealth_Draining(t *testing.T) {
+	readiness := NewReadiness()
+	checks := New(readiness)
+	database := &countingChecker{}
+	checks.Register("database", database)
+
+	readiness.Drain()
+	status, report := getReport(t, checks.ReadyHandler())
+	if status != http.StatusServiceUnavailable || report.Status != StatusFail || report.Checks[0].Name != "shutdown" {
+		t.Errorf("status = %d, reporte = %+v, esperaba 503 por apagado", status, report)
+	}
+	if got := database.calls.Load(); got != 0 {
+		t.Errorf("verificaciones durante el apagado = %d, esperaba 0", got)
+	}
+
+	// La instancia sigue viva mientras drena las peticiones en curso
+	if status, report := getReport(t, checks.LiveHandler()); status != http.StatusOK || report.Status != StatusOK {
+		t.Errorf("liveness status = %d, reporte = %+v, esperaba 200 ok", status, report)
+	}
+}
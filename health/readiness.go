@@ -0,0 +1,27 @@
+// Package health informa si la instancia está viva y lista para recibir tráfico,
+// verificando sus dependencias
+package health
+
+import "sync/atomic"
+
+// Readiness indica si la instancia debe recibir tráfico. Al iniciar el apagado se marca como
+// no lista para que los balanceadores dejen de enviarle peticiones mientras se drenan las que
+// están en curso. El valor cero está listo.
+type Readiness struct {
+	draining atomic.Bool
+}
+
+// NewReadiness crea una instancia lista para recibir tráfico
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// Drain marca la instancia como no lista de forma permanente
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+// Ready indica si la instancia sigue aceptando tráfico nuevo
+func (r *Readiness) Ready() bool {
+	return !r.draining.Load()
+}
@@ -0,0 +1,81 @@
+// Package logging configura el logger estructurado (log/slog) de la aplicación y asocia a cada
+// línea el identificador de la petición y la traza en curso
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Formatos de salida soportados
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Options configura el logger
+type Options struct {
+	// Format es FormatJSON (por defecto) o FormatText
+	Format string
+	// Level es el nivel mínimo de las líneas que se escriben
+	Level slog.Level
+}
+
+// New crea un logger que escribe en w y agrega a cada línea request_id, trace_id y span_id
+// cuando el contexto del registro los tiene (usar las variantes ...Context de slog)
+func New(w io.Writer, opts Options) *slog.Logger {
+	handlerOptions := &slog.HandlerOptions{Level: opts.Level}
+	var handler slog.Handler
+	if opts.Format == FormatText {
+		handler = slog.NewTextHandler(w, handlerOptions)
+	} else {
+		handler = slog.NewJSONHandler(w, handlerOptions)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// requestIDKey es la clave del identificador de la petición en el contexto
+type requestIDKey struct{}
+
+// WithRequestID retorna una copia de ctx con el identificador de la petición
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID retorna el identificador de la petición guardado en ctx, o "" si no tiene
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler agrega a cada registro los identificadores presentes en su contexto
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle implementa slog.Handler
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", span.TraceID().String()),
+			slog.String("span_id", span.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs implementa slog.Handler conservando el agregado de identificadores
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implementa slog.Handler conservando el agregado de identificadores
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestNew_JSON(t *testing.T) {
+	var output bytes.Buffer
+	logger := New(&output, Options{Format: FormatJSON, Level: slog.LevelInfo})
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(WithRequestID(context.Background(), "req-123"),
+		trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID}))
+
+	logger.With("component", "test").InfoContext(ctx, "petición atendida", "status", 200)
+	logger.DebugContext(ctx, "no se escribe por debajo del nivel")
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("líneas = %d, esperaba 1:\n%s", len(lines), output.String())
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("la línea no es JSON: %v", err)
+	}
+	want := map[string]interface{}{
+		"level":      "INFO",
+		"msg":        "petición atendida",
+		"component":  "test",
+		"status":     float64(200),
+		"request_id": "req-123",
+		"trace_id":   traceID.String(),
+		"span_id":    spanID.String(),
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %v, esperaba %v", key, entry[key], value)
+		}
+	}
+}
+
+func TestNew_Text(t *testing.T) {
+	var output bytes.Buffer
+	logger := New(&output, Options{Format: FormatText, Level: slog.LevelDebug})
+
+	logger.DebugContext(context.Background(), "sin petición")
+	logger.WarnContext(WithRequestID(context.Background(), "abc"), "con petición")
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("líneas = %d, esperaba 2:\n%s", len(lines), output.String())
+	}
+	if !strings.Contains(lines[0], "level=DEBUG") || strings.Contains(lines[0], "request_id") {
+		t.Errorf("línea = %q, esperaba nivel DEBUG y sin request_id", lines[0])
+	}
+	if !strings.Contains(lines[1], "level=WARN") || !strings.Contains(lines[1], "request_id=abc") {
+		t.Errorf("línea = %q, esperaba nivel WARN y request_id=abc", lines[1])
+	}
+}
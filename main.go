@@ -1,15 +1,29 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"helloworld/config"
+	"helloworld/handlers"
+	"helloworld/health"
+	"helloworld/logging"
+	"helloworld/metrics"
+	"helloworld/migrations"
 	"helloworld/repositories"
 	"helloworld/routes"
+	"helloworld/server"
 	"helloworld/services"
+	"helloworld/tracing"
 
 	_ "helloworld/docs" // docs generados por swag
 )
@@ -30,21 +44,95 @@ import (
 
 // @schemes http https
 func main() {
+	// Logs en JSON hasta conocer la configuración, para que sus advertencias también sean estructuradas
+	slog.SetDefault(logging.New(os.Stderr, logging.Options{Format: logging.FormatJSON, Level: slog.LevelInfo}))
+
 	// Cargar configuración
 	cfg := config.LoadConfig()
+	slog.SetDefault(logging.New(os.Stderr, logging.Options{Format: cfg.LogFormat, Level: cfg.LogLevel}))
+
+	// Subcomando de migraciones: go run main.go migrate up|down|status
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrateCommand(cfg, os.Args[2:]); err != nil {
+			slog.Error("Error en migraciones", "error", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
 
-	// Inicializar repositorio MySQL (requerido)
-	log.Println("Conectando a MySQL...")
-	userRepo, err := repositories.NewMySQLUserRepository(cfg.GetDSN())
+	// os.Exit no ejecuta los defer: run retorna el error para que libere sus recursos antes
+	if err := run(cfg); err != nil {
+		slog.Error("Error al ejecutar el servidor", "error", err.Error())
+		os.Exit(1)
+	}
+}
+
+// run inicia la API y la atiende hasta recibir SIGINT o SIGTERM. Cualquier error, también
+// durante el arranque, cierra el repositorio y envía las trazas pendientes antes de retornar.
+func run(cfg *config.Config) error {
+	// Configurar las trazas antes de crear los componentes que las generan
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.TracingExporter,
+		SampleRatio: cfg.TracingSampleRatio,
+	})
 	if err != nil {
-		log.Fatalf("Error al conectar con MySQL: %v. La aplicación requiere MySQL para funcionar.", err)
+		return fmt.Errorf("error al configurar las trazas (%s): %w", cfg.TracingExporter, err)
+	}
+	// Los defer se ejecutan en orden inverso: primero se cierra el repositorio y luego se
+	// envían las trazas, incluidas las de las últimas consultas
+	defer flushTraces(shutdownTracing, cfg.ShutdownTimeout)
+
+	// Inicializar repositorio según el driver configurado
+	userRepo, err := openUserRepository(cfg)
+	if err != nil {
+		return fmt.Errorf("error al inicializar el almacenamiento %s: %w", cfg.StorageDriver, err)
+	}
+	// El repositorio se cierra después de drenar las peticiones que todavía lo usan
+	defer closeRepository(userRepo)
+
+	readiness := health.NewReadiness()
+	checks := health.New(readiness,
+		health.WithCheckTimeout(cfg.HealthCheckTimeout),
+		health.WithCacheTTL(cfg.HealthCacheTTL),
+	)
+
+	m := metrics.New()
+
+	// Aplicar migraciones pendientes, verificar la base de datos en /readyz y exponer las
+	// estadísticas de su pool en /metrics (los repositorios en memoria no las necesitan)
+	if sqlRepo, ok := userRepo.(sqlUserRepository); ok {
+		if cfg.MigrateOnStart {
+			if err := migrateUp(sqlRepo.DB(), cfg.StorageDriver); err != nil {
+				return fmt.Errorf("error al aplicar migraciones: %w", err)
+			}
+		}
+		if err := registerDatabaseChecks(checks, sqlRepo.DB(), cfg.StorageDriver); err != nil {
+			return fmt.Errorf("error al configurar las verificaciones de la base de datos: %w", err)
+		}
+		if err := m.RegisterDBStats(sqlRepo.DB(), cfg.StorageDriver); err != nil {
+			return fmt.Errorf("error al registrar las métricas de la base de datos: %w", err)
+		}
 	}
-	log.Println("Conectado a MySQL exitosamente")
 
-	userService := services.NewUserService(userRepo)
+	serviceOptions := []services.Option{services.WithPurgeRetention(cfg.PurgeRetention)}
+	if cfg.EmailBlocklistFile != "" {
+		blocklist, err := loadEmailBlocklist(cfg.EmailBlocklistFile)
+		if err != nil {
+			return fmt.Errorf("error al cargar la lista de dominios de email bloqueados %s: %w", cfg.EmailBlocklistFile, err)
+		}
+		slog.Info("Lista de dominios de email bloqueados cargada", "domains", len(blocklist))
+		serviceOptions = append(serviceOptions, services.WithEmailBlocklist(blocklist))
+	}
+
+	// El servicio usa el repositorio instrumentado para medir la latencia de cada operación
+	instrumentedRepo := repositories.Instrument(userRepo, m.RepositoryObserver(cfg.StorageDriver))
+	userService := services.Trace(services.NewUserService(instrumentedRepo, serviceOptions...))
 
 	// Configurar rutas
-	handler := routes.SetupRoutes(userService)
+	handler := routes.SetupRoutes(userService, checks, m,
+		handlers.WithMaxBodyBytes(cfg.MaxBodyBytes),
+		handlers.WithMaxImportBytes(cfg.MaxImportBytes),
+	)
 
 	// Configurar servidor HTTP con timeouts
 	addr := fmt.Sprintf(":%s", cfg.Port)
@@ -56,21 +144,157 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	// Cerrar conexión MySQL al finalizar
-	defer func() {
-		if err := userRepo.Close(); err != nil {
-			log.Printf("Error al cerrar conexión MySQL: %v", err)
-		}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("error al iniciar el servidor en %s: %w", addr, err)
+	}
+
+	baseURL := "http://localhost" + addr
+	slog.Info("Servidor iniciado",
+		"addr", addr,
+		"api", baseURL+"/api/v1/users",
+		"livez", baseURL+"/livez",
+		"readyz", baseURL+"/readyz",
+		"metrics", baseURL+"/metrics",
+		"swagger", baseURL+"/swagger/index.html",
+	)
+
+	// SIGINT (Ctrl+C) y SIGTERM (docker stop, Kubernetes) inician el apagado ordenado
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// Tras la primera señal se restaura el comportamiento por defecto: una segunda termina el proceso sin esperar
+		<-ctx.Done()
+		stop()
 	}()
 
-	log.Printf("Servidor iniciado en http://localhost%s", addr)
-	log.Printf("Health check: http://localhost%s/health", addr)
-	log.Printf("API endpoints: http://localhost%s/api/v1/users", addr)
-	log.Printf("Swagger UI: http://localhost%s/swagger/index.html", addr)
+	err = server.Run(ctx, srv, listener, readiness, server.ShutdownOptions{
+		Delay:   cfg.ShutdownDelay,
+		Timeout: cfg.ShutdownTimeout,
+	})
+	if err != nil {
+		return fmt.Errorf("error al detener el servidor: %w", err)
+	}
+	return nil
+}
 
-	// Iniciar servidor
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Printf("Error al iniciar el servidor: %v", err)
-		return
+// closeRepository libera las conexiones del repositorio
+func closeRepository(repo io.Closer) {
+	if err := repo.Close(); err != nil {
+		slog.Error("Error al cerrar el repositorio", "error", err.Error())
+	}
+}
+
+// flushTraces envía los spans pendientes, esperando como máximo timeout
+func flushTraces(shutdown tracing.Shutdown, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		slog.Error("Error al enviar las trazas pendientes", "error", err.Error())
+	}
+}
+
+// closableUserRepository es un repositorio que mantiene recursos que deben liberarse al finalizar
+type closableUserRepository interface {
+	repositories.UserRepository
+	io.Closer
+}
+
+// sqlUserRepository es un repositorio respaldado por database/sql, sujeto a migraciones
+type sqlUserRepository interface {
+	closableUserRepository
+	DB() *sql.DB
+}
+
+// openUserRepository crea el repositorio correspondiente a cfg.StorageDriver
+func openUserRepository(cfg *config.Config) (closableUserRepository, error) {
+	switch cfg.StorageDriver {
+	case config.StorageDriverMySQL:
+		slog.Info("Conectando a MySQL", "host", cfg.DBHost, "port", cfg.DBPort, "database", cfg.DBName)
+		repo, err := repositories.NewMySQLUserRepository(cfg.GetDSN(), cfg.DBMaxOpenConns)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Conectado a MySQL")
+		return repo, nil
+	case config.StorageDriverPostgres:
+		slog.Info("Conectando a PostgreSQL", "host", cfg.PGHost, "port", cfg.PGPort, "database", cfg.PGName)
+		repo, err := repositories.NewPostgresUserRepository(cfg.GetPostgresDSN(), cfg.DBMaxOpenConns)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("Conectado a PostgreSQL")
+		return repo, nil
+	case config.StorageDriverSQLite:
+		slog.Info("Abriendo base de datos SQLite", "path", cfg.SQLitePath)
+		return repositories.NewSQLiteUserRepository(cfg.SQLitePath)
+	case config.StorageDriverMemory:
+		slog.Warn("Usando almacenamiento en memoria: los datos se pierden al reiniciar")
+		return repositories.NewMemoryUserRepository(), nil
+	default:
+		return nil, fmt.Errorf("driver de almacenamiento desconocido: %s", cfg.StorageDriver)
+	}
+}
+
+// registerDatabaseChecks registra las verificaciones de la base de datos: que responda,
+// que tenga aplicadas las migraciones y que su pool de conexiones no esté saturado. La
+// verificación del pool solo se registra si puede fallar: sin límite de conexiones
+// (DB_MAX_OPEN_CONNS=0) nunca se satura y con una única conexión (SQLite) esperar es normal.
+func registerDatabaseChecks(checks *health.Health, db *sql.DB, dialect string) error {
+	migrator, err := migrations.New(db, dialect)
+	if err != nil {
+		return err
+	}
+	checks.Register("database", health.PingChecker(db))
+	checks.Register("migrations", health.MigrationChecker(migrator))
+	if db.Stats().MaxOpenConnections > 1 {
+		checks.Register("connection_pool", health.PoolChecker(db))
+	}
+	return nil
+}
+
+// migrateUp aplica las migraciones pendientes del dialecto indicado
+func migrateUp(db *sql.DB, dialect string) error {
+	migrator, err := migrations.New(db, dialect)
+	if err != nil {
+		return err
 	}
+
+	applied, err := migrator.Up(context.Background())
+	for _, migration := range applied {
+		slog.Info("Migración aplicada", "version", migration.Version, "name", migration.Name)
+	}
+	return err
+}
+
+// runMigrateCommand ejecuta el subcomando migrate contra el almacenamiento configurado
+func runMigrateCommand(cfg *config.Config, args []string) error {
+	userRepo, err := openUserRepository(cfg)
+	if err != nil {
+		return err
+	}
+	defer userRepo.Close()
+
+	sqlRepo, ok := userRepo.(sqlUserRepository)
+	if !ok {
+		return fmt.Errorf("el driver %q no usa migraciones", cfg.StorageDriver)
+	}
+
+	migrator, err := migrations.New(sqlRepo.DB(), cfg.StorageDriver)
+	if err != nil {
+		return err
+	}
+
+	return migrations.RunCommand(context.Background(), migrator, args, os.Stdout)
+}
+
+// loadEmailBlocklist lee la lista de dominios de email bloqueados desde path
+func loadEmailBlocklist(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return services.LoadEmailBlocklist(file)
 }
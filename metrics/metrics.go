@@ -0,0 +1,116 @@
+// Package metrics expone métricas de Prometheus de las peticiones HTTP y de la base de datos
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"helloworld/repositories"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefija todas las métricas propias de la API
+const namespace = "users_api"
+
+// Metrics agrupa las métricas de la API en un registro propio, de modo que cada instancia
+// (por ejemplo, en los tests) es independiente del registro global de Prometheus
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests   *prometheus.CounterVec
+	duration   *prometheus.HistogramVec
+	inFlight   *prometheus.GaugeVec
+	repository *prometheus.HistogramVec
+}
+
+// New crea las métricas junto con las del runtime de Go y del proceso
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Peticiones HTTP atendidas, por método, ruta y status.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duración de las peticiones HTTP, por método, ruta y status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "Peticiones HTTP en curso, por método y ruta.",
+		}, []string{"method", "route"}),
+		repository: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_operation_duration_seconds",
+			Help:      "Duración de las operaciones del repositorio de usuarios, por driver, método y resultado (ok, rejected o error).",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"driver", "method", "outcome"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests, m.duration, m.inFlight, m.repository,
+	)
+	return m
+}
+
+// Handler expone las métricas en el formato de texto de Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RequestStarted registra el inicio de una petición y retorna la función que la registra como
+// terminada con su status. route debe ser la plantilla de la ruta, no la ruta recibida, para
+// que la cantidad de series no crezca con cada id.
+func (m *Metrics) RequestStarted(method, route string) (done func(status int)) {
+	start := time.Now()
+	inFlight := m.inFlight.WithLabelValues(method, route)
+	inFlight.Inc()
+
+	return func(status int) {
+		inFlight.Dec()
+		code := strconv.Itoa(status)
+		m.requests.WithLabelValues(method, route, code).Inc()
+		m.duration.WithLabelValues(method, route, code).Observe(time.Since(start).Seconds())
+	}
+}
+
+// RegisterDBStats expone las estadísticas del pool de conexiones de db (sql.DBStats) con la etiqueta db_name
+func (m *Metrics) RegisterDBStats(db *sql.DB, name string) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RepositoryObserver retorna la función que registra la duración de las operaciones de un
+// repositorio del driver indicado, para usar con repositories.Instrument
+func (m *Metrics) RepositoryObserver(driver string) repositories.OperationObserver {
+	return func(method string, duration time.Duration, err error) {
+		m.repository.WithLabelValues(driver, method, outcome(err)).Observe(duration.Seconds())
+	}
+}
+
+// outcome clasifica el resultado de una operación del repositorio. Los rechazos esperables
+// (usuario inexistente, email duplicado, conflicto de versión) se separan de las fallas para
+// que no se confundan con problemas de la base de datos.
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, repositories.ErrUserNotFound),
+		errors.Is(err, repositories.ErrEmailAlreadyExists),
+		errors.Is(err, repositories.ErrVersionConflict):
+		return "rejected"
+	default:
+		return "error"
+	}
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"helloworld/repositories"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	_ "modernc.org/sqlite"
+)
+
+// scrape retorna el texto que expone Handler
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /metrics status = %d, esperaba %d", rec.Code, http.StatusOK)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	return string(body)
+}
+
+func TestMetrics_RequestStarted(t *testing.T) {
+	m := New()
+
+	done := m.RequestStarted(http.MethodGet, "/api/v1/users/{id}")
+	if got := testutil.ToFloat64(m.inFlight.WithLabelValues(http.MethodGet, "/api/v1/users/{id}")); got != 1 {
+		t.Errorf("en curso = %v, esperaba 1", got)
+	}
+	done(http.StatusNotFound)
+
+	if got := testutil.ToFloat64(m.inFlight.WithLabelValues(http.MethodGet, "/api/v1/users/{id}")); got != 0 {
+		t.Errorf("en curso después de terminar = %v, esperaba 0", got)
+	}
+	if got := testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, "/api/v1/users/{id}", "404")); got != 1 {
+		t.Errorf("peticiones = %v, esperaba 1", got)
+	}
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		`users_api_http_requests_total{method="GET",route="/api/v1/users/{id}",status="404"} 1`,
+		`users_api_http_request_duration_seconds_count{method="GET",route="/api/v1/users/{id}",status="404"} 1`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("la exposición no contiene %q", want)
+		}
+	}
+}
+
+func TestMetrics_RepositoryObserver(t *testing.T) {
+	m := New()
+	observe := m.RepositoryObserver("sqlite")
+
+	observe("Create", time.Millisecond, nil)
+	observe("Update", time.Millisecond, &repositories.BatchError{Index: 2, Err: repositories.ErrVersionConflict})
+	observe("GetByID", time.Millisecond, fmt.Errorf("consulta: %w", repositories.ErrUserNotFound))
+	observe("GetAll", time.Millisecond, errors.New("conexión rechazada"))
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		`users_api_repository_operation_duration_seconds_count{driver="sqlite",method="Create",outcome="ok"} 1`,
+		`users_api_repository_operation_duration_seconds_count{driver="sqlite",method="Update",outcome="rejected"} 1`,
+		`users_api_repository_operation_duration_seconds_count{driver="sqlite",method="GetByID",outcome="rejected"} 1`,
+		`users_api_repository_operation_duration_seconds_count{driver="sqlite",method="GetAll",outcome="error"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("la exposición no contiene %q", want)
+		}
+	}
+}
+
+func TestMetrics_RegisterDBStats(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	defer db.Close()
+
+	m := New()
+	if err := m.RegisterDBStats(db, "sqlite"); err != nil {
+		t.Fatalf("RegisterDBStats() error = %v", err)
+	}
+	if body := scrape(t, m); !strings.Contains(body, `go_sql_max_open_connections{db_name="sqlite"}`) {
+		t.Errorf("la exposición no contiene las estadísticas del pool")
+	}
+}
@@ -15,8 +15,9 @@ func NewCORSMiddleware(handler http.Handler) *CORSMiddleware {
 // ServeHTTP implementa http.Handler
 func (m *CORSMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, If-Match, X-Request-ID, traceparent, tracestate")
+	w.Header().Set("Access-Control-Expose-Headers", "ETag, Link, X-Request-ID")
 
 	if r.Method == http.MethodOptions {
 		w.WriteHeader(http.StatusOK)
@@ -1,7 +1,7 @@
 package middleware
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 )
@@ -16,7 +16,8 @@ func NewLoggingMiddleware(handler http.Handler) *LoggingMiddleware {
 	return &LoggingMiddleware{handler: handler}
 }
 
-// ServeHTTP implementa http.Handler
+// ServeHTTP implementa http.Handler. Registra una línea por petición con el logger por defecto;
+// las respuestas 5xx y las peticiones abortadas con panic se registran con nivel ERROR.
 func (m *LoggingMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 
@@ -26,26 +27,53 @@ func (m *LoggingMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		statusCode:     http.StatusOK,
 	}
 
-	m.handler.ServeHTTP(wrapped, r)
+	// En un defer para registrar también las peticiones abortadas con panic (http.ErrAbortHandler,
+	// por ejemplo al cortar una exportación); el panic sigue hasta el servidor, que cierra la conexión
+	defer func() {
+		recovered := recover()
+		level := slog.LevelInfo
+		if wrapped.statusCode >= http.StatusInternalServerError || recovered != nil {
+			level = slog.LevelError
+		}
+		attrs := []interface{}{
+			"method", r.Method,
+			"uri", r.RequestURI,
+			"remote_addr", r.RemoteAddr,
+			"status", wrapped.statusCode,
+			"bytes", wrapped.bytes,
+			"duration_ms", float64(time.Since(start).Microseconds()) / 1000,
+		}
+		if recovered != nil {
+			attrs = append(attrs, "aborted", true)
+		}
+		slog.Log(r.Context(), level, "Petición atendida", attrs...)
+		if recovered != nil {
+			panic(recovered)
+		}
+	}()
 
-	duration := time.Since(start)
-	log.Printf(
-		"%s %s %s %d %v",
-		r.Method,
-		r.RequestURI,
-		r.RemoteAddr,
-		wrapped.statusCode,
-		duration,
-	)
+	m.handler.ServeHTTP(wrapped, r)
 }
 
-// responseWriter envuelve http.ResponseWriter para capturar el status code
+// responseWriter envuelve http.ResponseWriter para capturar el status code y los bytes escritos
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
+	bytes      int
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
+}
+
+// Unwrap expone el ResponseWriter original a http.ResponseController (Flush, deadlines)
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
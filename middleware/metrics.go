@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+
+	"helloworld/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+// unmatchedRoute es la etiqueta de las peticiones que no corresponden a ninguna ruta, para no
+// crear una serie por cada URL desconocida
+const unmatchedRoute = "unmatched"
+
+// standardMethods son los métodos que se etiquetan por su nombre; el resto se agrupa como OTHER
+var standardMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+}
+
+// MetricsMiddleware registra la cantidad, la duración y las peticiones en curso por ruta
+type MetricsMiddleware struct {
+	handler http.Handler
+	router  *mux.Router
+	metrics *metrics.Metrics
+}
+
+// NewMetricsMiddleware crea el middleware de métricas; router se usa para etiquetar cada
+// petición con la plantilla de su ruta (por ejemplo, /api/v1/users/{id})
+func NewMetricsMiddleware(handler http.Handler, router *mux.Router, m *metrics.Metrics) *MetricsMiddleware {
+	return &MetricsMiddleware{handler: handler, router: router, metrics: m}
+}
+
+// ServeHTTP implementa http.Handler
+func (m *MetricsMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	wrapped := &responseWriter{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
+	}
+
+	done := m.metrics.RequestStarted(normalizeMethod(r.Method), routeTemplate(m.router, r))
+	// En un defer para registrar también las peticiones abortadas con panic (http.ErrAbortHandler)
+	defer func() { done(wrapped.statusCode) }()
+
+	m.handler.ServeHTTP(wrapped, r)
+}
+
+// routeTemplate retorna la plantilla de la ruta de router que atiende r, o unmatchedRoute
+func routeTemplate(router *mux.Router, r *http.Request) string {
+	var match mux.RouteMatch
+	if !router.Match(r, &match) || match.Route == nil {
+		return unmatchedRoute
+	}
+	template, err := match.Route.GetPathTemplate()
+	if err != nil {
+		return unmatchedRoute
+	}
+	return template
+}
+
+// normalizeMethod limita los valores de method en métricas y spans a los métodos estándar
+func normalizeMethod(method string) string {
+	if standardMethods[method] {
+		return method
+	}
+	return "OTHER"
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"helloworld/metrics"
+
+	"github.com/gorilla/mux"
+)
+
+func TestMetricsMiddleware_RouteTemplate(t *testing.T) {
+	m := metrics.New()
+	router := mux.NewRouter()
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodDelete)
+	handler := NewMetricsMiddleware(router, router, m)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodDelete, "/api/v1/users/1", nil),
+		httptest.NewRequest(http.MethodDelete, "/api/v1/users/2", nil),
+		httptest.NewRequest(http.MethodGet, "/inexistente/3", nil),
+		httptest.NewRequest("PROPFIND", "/api/v1/users/4", nil),
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	for _, want := range []string{
+		`users_api_http_requests_total{method="DELETE",route="/api/v1/users/{id}",status="204"} 2`,
+		`users_api_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`users_api_http_requests_total{method="OTHER",route="unmatched",status="405"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("la exposición no contiene %q", want)
+		}
+	}
+	if strings.Contains(string(body), "/api/v1/users/1") {
+		t.Errorf("la etiqueta route no debe contener el id de la petición")
+	}
+}
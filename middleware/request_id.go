@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+
+	"helloworld/logging"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader es el header con el que se recibe y se informa el identificador de la petición
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength es la longitud máxima de un X-Request-ID aceptado
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware asigna a cada petición un identificador, lo guarda en el contexto para
+// que aparezca en todos los logs de la petición y lo devuelve en la respuesta
+type RequestIDMiddleware struct {
+	handler http.Handler
+}
+
+// NewRequestIDMiddleware crea una nueva instancia del middleware de identificador de petición
+func NewRequestIDMiddleware(handler http.Handler) *RequestIDMiddleware {
+	return &RequestIDMiddleware{handler: handler}
+}
+
+// ServeHTTP implementa http.Handler
+func (m *RequestIDMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := r.Header.Get(RequestIDHeader)
+	if !validRequestID(id) {
+		id = newRequestID(r)
+		r.Header.Set(RequestIDHeader, id)
+	}
+
+	w.Header().Set(RequestIDHeader, id)
+	m.handler.ServeHTTP(w, r.WithContext(logging.WithRequestID(r.Context(), id)))
+}
+
+// newRequestID usa el id de la traza de la petición, si la hay, para que logs y trazas coincidan
+func newRequestID(r *http.Request) string {
+	if span := trace.SpanContextFromContext(r.Context()); span.HasTraceID() {
+		return span.TraceID().String()
+	}
+	return uuid.NewString()
+}
+
+// validRequestID acepta identificadores de hasta maxRequestIDLength caracteres ASCII visibles, de
+// modo que un valor del cliente no pueda insertar saltos de línea ni controles en los logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"helloworld/logging"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var received string
+	handler := NewRequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = logging.RequestID(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		header   string
+		generate bool
+	}{
+		{name: "acepta el recibido", header: "req-123"},
+		{name: "genera uno si falta", generate: true},
+		{name: "reemplaza uno con saltos de línea", header: "req\nlevel=ERROR", generate: true},
+		{name: "reemplaza uno demasiado largo", header: strings.Repeat("a", maxRequestIDLength+1), generate: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+			if tt.header != "" {
+				req.Header.Set(RequestIDHeader, tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			got := rec.Header().Get(RequestIDHeader)
+			if got != received {
+				t.Errorf("header = %q, contexto = %q; esperaba el mismo identificador", got, received)
+			}
+			if tt.generate && (got == tt.header || !validRequestID(got)) {
+				t.Errorf("identificador = %q, esperaba uno generado", got)
+			}
+			if !tt.generate && got != tt.header {
+				t.Errorf("identificador = %q, esperaba %q", got, tt.header)
+			}
+		})
+	}
+}
+
+func TestLoggingMiddleware_RequestID(t *testing.T) {
+	var output bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&output, logging.Options{Format: logging.FormatJSON}))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	handler := NewRequestIDMiddleware(NewLoggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		slog.WarnContext(r.Context(), "Error del repositorio")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("no disponible")) // nolint:errcheck // ResponseRecorder no falla
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users?limit=5", nil)
+	req.Header.Set(RequestIDHeader, "req-123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("líneas = %d, esperaba 2:\n%s", len(lines), output.String())
+	}
+	for _, line := range lines {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("la línea no es JSON: %v", err)
+		}
+		if entry["request_id"] != "req-123" {
+			t.Errorf("request_id = %v, esperaba req-123 en %s", entry["request_id"], line)
+		}
+	}
+
+	var access map[string]interface{}
+	_ = json.Unmarshal([]byte(lines[1]), &access) // nolint:errcheck // Validado en el ciclo anterior
+	want := map[string]interface{}{
+		"level":  "ERROR",
+		"method": "GET",
+		"uri":    "/api/v1/users?limit=5",
+		"status": float64(http.StatusServiceUnavailable),
+		"bytes":  float64(len("no disponible")),
+	}
+	for key, value := range want {
+		if access[key] != value {
+			t.Errorf("%s = %v, esperaba %v", key, access[key], value)
+		}
+	}
+}
+
+func TestLoggingMiddleware_Aborted(t *testing.T) {
+	var output bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&output, logging.Options{Format: logging.FormatJSON}))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	handler := NewLoggingMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("id,name\n")) // nolint:errcheck // ResponseRecorder no falla
+		panic(http.ErrAbortHandler)
+	}))
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != http.ErrAbortHandler {
+				t.Errorf("panic = %v, esperaba %v", recovered, http.ErrAbortHandler)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/users/export", nil))
+	}()
+
+	var access map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &access); err != nil {
+		t.Fatalf("línea de acceso = %q, esperaba un JSON: %v", output.String(), err)
+	}
+	want := map[string]interface{}{
+		"level":   "ERROR",
+		"uri":     "/api/v1/users/export",
+		"status":  float64(http.StatusOK),
+		"bytes":   float64(len("id,name\n")),
+		"aborted": true,
+	}
+	for key, value := range want {
+		if access[key] != value {
+			t.Errorf("%s = %v, esperaba %v", key, access[key], value)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer crea el span de cada petición HTTP con el proveedor global vigente
+func tracer() trace.Tracer {
+	return otel.Tracer("helloworld/middleware")
+}
+
+// TracingMiddleware crea un span por petición, hijo de la traza del header traceparent si llega uno
+type TracingMiddleware struct {
+	handler http.Handler
+	router  *mux.Router
+}
+
+// NewTracingMiddleware crea el middleware de trazas; router se usa para nombrar cada span con
+// la plantilla de su ruta (por ejemplo, GET /api/v1/users/{id})
+func NewTracingMiddleware(handler http.Handler, router *mux.Router) *TracingMiddleware {
+	return &TracingMiddleware{handler: handler, router: router}
+}
+
+// ServeHTTP implementa http.Handler
+func (m *TracingMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+	route := routeTemplate(m.router, r)
+	method := normalizeMethod(r.Method)
+
+	ctx, span := tracer().Start(ctx, method+" "+route,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(method),
+			semconv.HTTPRoute(route),
+			semconv.URLPath(r.URL.Path),
+		),
+	)
+	wrapped := &responseWriter{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
+	}
+	defer func() {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(wrapped.statusCode))
+		// Los 4xx son errores del cliente: solo los 5xx marcan el span del servidor como fallido
+		if wrapped.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(wrapped.statusCode))
+		}
+		span.End()
+	}()
+
+	m.handler.ServeHTTP(wrapped, r.WithContext(ctx))
+}
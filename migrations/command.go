@@ -0,0 +1,60 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Usage describe los subcomandos aceptados por RunCommand
+const Usage = "uso: migrate up|down|status"
+
+// RunCommand ejecuta el subcomando de migraciones indicado en args y escribe el resultado en w
+func RunCommand(ctx context.Context, m *Migrator, args []string, w io.Writer) error {
+	if len(args) != 1 {
+		return errors.New(Usage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, migration := range applied {
+			fmt.Fprintf(w, "aplicada %d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(w, "sin migraciones pendientes")
+		}
+		return nil
+	case "down":
+		reverted, err := m.Down(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "revertida %d_%s\n", reverted.Version, reverted.Name)
+		return nil
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSIÓN\tNOMBRE\tESTADO\tAPLICADA")
+		for _, status := range statuses {
+			state, appliedAt := "pendiente", "-"
+			if status.Applied {
+				state = "aplicada"
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("subcomando desconocido %q; %s", args[0], Usage)
+	}
+}
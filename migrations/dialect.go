@@ -0,0 +1,222 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Dialectos soportados; coinciden con los valores de STORAGE_DRIVER
+const (
+	DialectMySQL    = "mysql"
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite"
+)
+
+const (
+	// lockName identifica el lock de migraciones en MySQL
+	lockName = "schema_migrations"
+	// lockKey identifica el advisory lock de migraciones en PostgreSQL
+	lockKey int64 = 7264839201
+	// lockTimeoutSeconds es el tiempo máximo de espera por el lock en MySQL y SQLite
+	lockTimeoutSeconds = 60
+	// lockRetryInterval es la pausa entre intentos de tomar el lock en SQLite
+	lockRetryInterval = 100 * time.Millisecond
+	// sqliteBusy es el código de error primario SQLITE_BUSY
+	sqliteBusy = 5
+)
+
+// dialect encapsula las diferencias de SQL entre motores
+type dialect interface {
+	// placeholder retorna el marcador del parámetro n (empezando en 1)
+	placeholder(n int) string
+	// lock impide que otra réplica migre concurrentemente; se mantiene mientras viva conn
+	lock(ctx context.Context, conn *sql.Conn) error
+	// unlock libera el lock tomado por lock
+	unlock(ctx context.Context, conn *sql.Conn) error
+	// begin inicia la transacción en la que se aplica una migración
+	begin(ctx context.Context, conn *sql.Conn) (migrationTx, error)
+	// versionTableExists indica si existe schema_migrations consultando el catálogo, sin crearla
+	versionTableExists(ctx context.Context, conn *sql.Conn) (bool, error)
+}
+
+// migrationTx aplica una migración de forma atómica; *sql.Tx la implementa
+type migrationTx interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	Commit() error
+	Rollback() error
+}
+
+// beginTx inicia una transacción normal; la usan los motores cuyo lock no es una transacción
+func beginTx(ctx context.Context, conn *sql.Conn) (migrationTx, error) {
+	return conn.BeginTx(ctx, nil)
+}
+
+// newDialect retorna el dialecto correspondiente a name
+func newDialect(name string) (dialect, error) {
+	switch name {
+	case DialectMySQL:
+		return mysqlDialect{}, nil
+	case DialectPostgres:
+		return postgresDialect{}, nil
+	case DialectSQLite:
+		return sqliteDialect{}, nil
+	default:
+		return nil, fmt.Errorf("dialecto de migraciones no soportado: %s", name)
+	}
+}
+
+// mysqlDialect usa GET_LOCK, que se asocia a la sesión de la conexión
+type mysqlDialect struct{}
+
+func (mysqlDialect) placeholder(int) string {
+	return "?"
+}
+
+func (mysqlDialect) lock(ctx context.Context, conn *sql.Conn) error {
+	var acquired sql.NullInt64
+	err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, lockTimeoutSeconds).Scan(&acquired)
+	if err != nil {
+		return err
+	}
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return fmt.Errorf("no se obtuvo el lock %q en %d segundos", lockName, lockTimeoutSeconds)
+	}
+	return nil
+}
+
+func (mysqlDialect) unlock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName)
+	return err
+}
+
+func (mysqlDialect) begin(ctx context.Context, conn *sql.Conn) (migrationTx, error) {
+	return beginTx(ctx, conn)
+}
+
+func (mysqlDialect) versionTableExists(ctx context.Context, conn *sql.Conn) (bool, error) {
+	return queryExists(ctx, conn, `SELECT COUNT(*) FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_name = 'schema_migrations'`)
+}
+
+// postgresDialect usa advisory locks de sesión
+type postgresDialect struct{}
+
+func (postgresDialect) placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (postgresDialect) lock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey)
+	return err
+}
+
+func (postgresDialect) unlock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", lockKey)
+	return err
+}
+
+func (postgresDialect) begin(ctx context.Context, conn *sql.Conn) (migrationTx, error) {
+	return beginTx(ctx, conn)
+}
+
+func (postgresDialect) versionTableExists(ctx context.Context, conn *sql.Conn) (bool, error) {
+	return queryExists(ctx, conn, `SELECT COUNT(*) FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_name = 'schema_migrations'`)
+}
+
+// sqliteDialect toma el lock de escritura de la base con BEGIN IMMEDIATE, de modo que otro
+// proceso que abra el mismo archivo espera a que terminen las migraciones. Todas se aplican
+// dentro de esa transacción, cada una en su propio savepoint.
+type sqliteDialect struct{}
+
+func (sqliteDialect) placeholder(int) string {
+	return "?"
+}
+
+func (sqliteDialect) lock(ctx context.Context, conn *sql.Conn) error {
+	return retryBusy(ctx, conn, "BEGIN IMMEDIATE")
+}
+
+// unlock confirma las migraciones aplicadas; la que falló ya se revirtió en su savepoint.
+// El COMMIT también puede encontrar la base ocupada mientras otro proceso intenta tomar el lock.
+func (sqliteDialect) unlock(ctx context.Context, conn *sql.Conn) error {
+	if err := retryBusy(ctx, conn, "COMMIT"); err != nil {
+		_, _ = conn.ExecContext(ctx, "ROLLBACK") // nolint:errcheck // El error del COMMIT es más relevante
+		return err
+	}
+	return nil
+}
+
+// retryBusy ejecuta statement reintentando mientras otra conexión tenga el lock de la base,
+// hasta lockTimeoutSeconds
+func retryBusy(ctx context.Context, conn *sql.Conn, statement string) error {
+	deadline := time.Now().Add(lockTimeoutSeconds * time.Second)
+	for {
+		_, err := conn.ExecContext(ctx, statement)
+		if err == nil || !isSQLiteBusy(err) {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("base de datos ocupada durante %d segundos: %w", lockTimeoutSeconds, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockRetryInterval):
+		}
+	}
+}
+
+func (sqliteDialect) begin(ctx context.Context, conn *sql.Conn) (migrationTx, error) {
+	if _, err := conn.ExecContext(ctx, "SAVEPOINT migration"); err != nil {
+		return nil, err
+	}
+	return &savepointTx{conn: conn}, nil
+}
+
+func (sqliteDialect) versionTableExists(ctx context.Context, conn *sql.Conn) (bool, error) {
+	return queryExists(ctx, conn, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'")
+}
+
+// queryExists ejecuta una consulta COUNT(*) e indica si contó alguna fila
+func queryExists(ctx context.Context, conn *sql.Conn, query string) (bool, error) {
+	var count int64
+	if err := conn.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// savepointTx aplica una migración dentro de la transacción abierta por sqliteDialect.lock
+type savepointTx struct {
+	conn *sql.Conn
+}
+
+func (tx *savepointTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tx.conn.ExecContext(ctx, query, args...)
+}
+
+func (tx *savepointTx) Commit() error {
+	_, err := tx.conn.ExecContext(context.Background(), "RELEASE SAVEPOINT migration")
+	return err
+}
+
+// Rollback deshace la migración y descarta el savepoint; la transacción exterior sigue abierta
+func (tx *savepointTx) Rollback() error {
+	if _, err := tx.conn.ExecContext(context.Background(), "ROLLBACK TO SAVEPOINT migration"); err != nil {
+		return err
+	}
+	_, err := tx.conn.ExecContext(context.Background(), "RELEASE SAVEPOINT migration")
+	return err
+}
+
+// isSQLiteBusy indica si err es SQLITE_BUSY (otra conexión tiene el lock de escritura). Se
+// consulta el código por interfaz para no depender del driver.
+func isSQLiteBusy(err error) bool {
+	var coded interface{ Code() int }
+	return errors.As(err, &coded) && coded.Code()&0xff == sqliteBusy
+}
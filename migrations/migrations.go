@@ -0,0 +1,206 @@
+// Package migrations aplica migraciones versionadas del esquema de base de datos.
+//
+// Las migraciones son archivos SQL embebidos en sql/<dialecto>/ con el formato
+// NNNN_descripcion.up.sql y NNNN_descripcion.down.sql. Las sentencias se separan
+// con ";" al final de línea; los bloques que contienen ";" internos (funciones,
+// triggers) se delimitan con "-- +migrate StatementBegin" y "-- +migrate StatementEnd".
+//
+// Una línea "-- +migrate Check <mensaje>" en un archivo up convierte la sentencia siguiente
+// en una verificación previa: si la consulta retorna filas, la migración falla con el mensaje
+// y los valores encontrados antes de ejecutar ninguna sentencia.
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed sql
+var sqlFiles embed.FS
+
+const (
+	statementBegin = "-- +migrate StatementBegin"
+	statementEnd   = "-- +migrate StatementEnd"
+	checkPrefix    = "-- +migrate Check "
+)
+
+// Check es una consulta que debe retornar cero filas para poder aplicar una migración
+type Check struct {
+	Message string
+	Query   string
+}
+
+// Migration representa una migración versionada con sus sentencias de subida y bajada
+type Migration struct {
+	Version int64
+	Name    string
+	Up      []string
+	Down    []string
+	// Checks se ejecutan antes de Up
+	Checks []Check
+}
+
+// loadMigrations lee las migraciones embebidas de un dialecto ordenadas por versión
+func loadMigrations(dialect string) ([]Migration, error) {
+	dir := path.Join("sql", dialect)
+	entries, err := fs.ReadDir(sqlFiles, dir)
+	if err != nil {
+		return nil, fmt.Errorf("error al leer migraciones de %s: %w", dialect, err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		version, name, direction, err := parseFileName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := fs.ReadFile(sqlFiles, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error al leer %s: %w", entry.Name(), err)
+		}
+
+		statements, checks, err := splitStatements(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("error en %s: %w", entry.Name(), err)
+		}
+		if direction == "down" && len(checks) > 0 {
+			return nil, fmt.Errorf("error en %s: las verificaciones solo se admiten en migraciones up", entry.Name())
+		}
+
+		migration, exists := byVersion[version]
+		if !exists {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		} else if migration.Name != name {
+			return nil, fmt.Errorf("versión %d duplicada: %s y %s", version, migration.Name, name)
+		}
+
+		if direction == "up" {
+			migration.Up = statements
+			migration.Checks = checks
+		} else {
+			migration.Down = statements
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == nil || migration.Down == nil {
+			return nil, fmt.Errorf("la migración %d_%s debe tener archivos up y down", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// parseFileName extrae versión, nombre y dirección de un archivo NNNN_nombre.(up|down).sql
+func parseFileName(fileName string) (version int64, name, direction string, err error) {
+	base := strings.TrimSuffix(fileName, ".sql")
+
+	switch {
+	case strings.HasSuffix(base, ".up"):
+		direction = "up"
+	case strings.HasSuffix(base, ".down"):
+		direction = "down"
+	default:
+		return 0, "", "", fmt.Errorf("archivo de migración sin dirección up/down: %s", fileName)
+	}
+	base = strings.TrimSuffix(base, "."+direction)
+
+	rawVersion, name, found := strings.Cut(base, "_")
+	if !found || name == "" {
+		return 0, "", "", fmt.Errorf("nombre de migración inválido: %s", fileName)
+	}
+
+	version, err = strconv.ParseInt(rawVersion, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("versión de migración inválida: %s", fileName)
+	}
+
+	return version, name, direction, nil
+}
+
+// splitStatements separa un archivo SQL en sentencias individuales y verificaciones previas.
+// Se ejecutan de a una porque el driver de MySQL no acepta múltiples sentencias por llamada.
+func splitStatements(content string) ([]string, []Check, error) {
+	statements := make([]string, 0)
+	checks := make([]Check, 0)
+	var current strings.Builder
+	inBlock := false
+	checkMessage := ""
+
+	// flush agrega la sentencia acumulada sin el ";" final
+	flush := func() {
+		statement := strings.TrimSuffix(strings.TrimSpace(current.String()), ";")
+		if statement = strings.TrimSpace(statement); statement != "" {
+			if checkMessage != "" {
+				checks = append(checks, Check{Message: checkMessage, Query: statement})
+				checkMessage = ""
+			} else {
+				statements = append(statements, statement)
+			}
+		}
+		current.Reset()
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case !inBlock && strings.HasPrefix(trimmed, checkPrefix):
+			flush()
+			if checkMessage = strings.TrimSpace(strings.TrimPrefix(trimmed, checkPrefix)); checkMessage == "" {
+				return nil, nil, fmt.Errorf("%q sin mensaje", strings.TrimSpace(checkPrefix))
+			}
+			continue
+		case trimmed == statementBegin:
+			if inBlock {
+				return nil, nil, fmt.Errorf("%q anidado", statementBegin)
+			}
+			flush()
+			inBlock = true
+			continue
+		case trimmed == statementEnd:
+			if !inBlock {
+				return nil, nil, fmt.Errorf("%q sin %q", statementEnd, statementBegin)
+			}
+			flush()
+			inBlock = false
+			continue
+		case !inBlock && strings.HasPrefix(trimmed, "--"):
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if !inBlock && strings.HasSuffix(trimmed, ";") {
+			flush()
+		}
+	}
+
+	if inBlock {
+		return nil, nil, fmt.Errorf("%q sin %q", statementBegin, statementEnd)
+	}
+	flush()
+	if checkMessage != "" {
+		return nil, nil, fmt.Errorf("verificación %q sin consulta", checkMessage)
+	}
+
+	return statements, checks, nil
+}
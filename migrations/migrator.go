@@ -0,0 +1,299 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNoMigrationApplied indica que no hay migraciones aplicadas para revertir
+var ErrNoMigrationApplied = errors.New("no hay migraciones aplicadas")
+
+// createVersionTable registra las migraciones aplicadas; la sintaxis es válida en todos los dialectos
+const createVersionTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+	version BIGINT PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+)`
+
+// Status describe el estado de una migración en la base de datos
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator aplica y revierte las migraciones embebidas de un dialecto
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect
+	migrations []Migration
+}
+
+// New crea un Migrator para la base de datos db usando las migraciones del dialecto indicado
+func New(db *sql.DB, dialectName string) (*Migrator, error) {
+	d, err := newDialect(dialectName)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := loadMigrations(dialectName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		dialect:    d,
+		migrations: migrations,
+	}, nil
+}
+
+// Migrations retorna las migraciones disponibles ordenadas por versión
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Up aplica todas las migraciones pendientes y retorna las que se aplicaron
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	applied := make([]Migration, 0)
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+
+			if err := m.check(ctx, conn, migration.Checks); err != nil {
+				return fmt.Errorf("no se puede aplicar la migración %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			insert := fmt.Sprintf("INSERT INTO schema_migrations (version, name) VALUES (%s, %s)",
+				m.dialect.placeholder(1), m.dialect.placeholder(2))
+			if err := m.run(ctx, conn, migration.Up, insert, migration.Version, migration.Name); err != nil {
+				return fmt.Errorf("error al aplicar migración %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down revierte la última migración aplicada y la retorna
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		done, err := m.appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+
+			remove := fmt.Sprintf("DELETE FROM schema_migrations WHERE version = %s", m.dialect.placeholder(1))
+			if err := m.run(ctx, conn, migration.Down, remove, migration.Version); err != nil {
+				return fmt.Errorf("error al revertir migración %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = &migration
+			return nil
+		}
+		return ErrNoMigrationApplied
+	})
+
+	return reverted, err
+}
+
+// Status retorna el estado de cada migración conocida. Solo lee la base de datos: no toma el
+// lock ni crea schema_migrations, y si la tabla no existe todas las migraciones están pendientes.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener conexión: %w", err)
+	}
+	defer conn.Close()
+
+	exists, err := m.dialect.versionTableExists(ctx, conn)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar tabla schema_migrations: %w", err)
+	}
+
+	done := make(map[int64]time.Time)
+	if exists {
+		if done, err = m.appliedVersions(ctx, conn); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, applied := done[migration.Version]
+		statuses = append(statuses, Status{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   applied,
+			AppliedAt: appliedAt,
+		})
+	}
+	return statuses, nil
+}
+
+// Version retorna la versión aplicada más alta, o 0 si no hay ninguna
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	for _, status := range statuses {
+		if status.Applied && status.Version > version {
+			version = status.Version
+		}
+	}
+	return version, nil
+}
+
+// withLock ejecuta fn sobre una conexión dedicada que mantiene el lock de migraciones
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("error al obtener conexión: %w", err)
+	}
+	defer conn.Close()
+
+	if err := m.dialect.lock(ctx, conn); err != nil {
+		return fmt.Errorf("error al obtener lock de migraciones: %w", err)
+	}
+	defer func() {
+		// Se usa un contexto propio para liberar el lock aunque ctx haya sido cancelado
+		if unlockErr := m.dialect.unlock(context.Background(), conn); unlockErr != nil && err == nil {
+			err = fmt.Errorf("error al liberar lock de migraciones: %w", unlockErr)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, createVersionTable); err != nil {
+		return fmt.Errorf("error al crear tabla schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// appliedVersions retorna las versiones aplicadas junto con su fecha de aplicación
+func (m *Migrator) appliedVersions(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error al leer schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var appliedAt sql.NullTime
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("error al escanear schema_migrations: %w", err)
+		}
+		applied[version] = appliedAt.Time
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar schema_migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+// maxCheckValues limita los valores que se informan cuando falla una verificación
+const maxCheckValues = 10
+
+// check ejecuta las verificaciones previas de una migración; falla con el mensaje de la primera
+// cuya consulta retorne filas, informando los valores de la primera columna
+func (m *Migrator) check(ctx context.Context, conn *sql.Conn, checks []Check) error {
+	for _, check := range checks {
+		values, found, err := m.checkValues(ctx, conn, check.Query)
+		if err != nil {
+			return fmt.Errorf("error al verificar %q: %w", check.Message, err)
+		}
+		if found > 0 {
+			if found > len(values) {
+				values = append(values, fmt.Sprintf("y %d más", found-len(values)))
+			}
+			return fmt.Errorf("%s: %s", check.Message, strings.Join(values, ", "))
+		}
+	}
+	return nil
+}
+
+// checkValues retorna hasta maxCheckValues valores de la primera columna y el total de filas
+func (m *Migrator) checkValues(ctx context.Context, conn *sql.Conn, query string) ([]string, int, error) {
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	values := make([]string, 0)
+	found := 0
+	for rows.Next() {
+		found++
+		if len(values) == maxCheckValues {
+			continue
+		}
+		dest := make([]interface{}, len(columns))
+		var value sql.NullString
+		dest[0] = &value
+		for i := 1; i < len(dest); i++ {
+			dest[i] = new(interface{})
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, 0, err
+		}
+		values = append(values, fmt.Sprintf("%q", value.String))
+	}
+	return values, found, rows.Err()
+}
+
+// run ejecuta las sentencias de una migración y actualiza schema_migrations en una transacción
+// (un savepoint en SQLite). MySQL confirma implícitamente las sentencias DDL, por lo que allí la
+// atomicidad es parcial.
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, statements []string, record string, args ...interface{}) error {
+	tx, err := m.dialect.begin(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			_ = tx.Rollback() // nolint:errcheck // El error de la sentencia es más relevante
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		_ = tx.Rollback() // nolint:errcheck // El error de la sentencia es más relevante
+		return err
+	}
+
+	return tx.Commit()
+}
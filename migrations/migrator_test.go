@@ -0,0 +1,380 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestLoadMigrations_AllDialects(t *testing.T) {
+	var versions []int64
+	for _, dialect := range []string{DialectMySQL, DialectPostgres, DialectSQLite} {
+		migrations, err := loadMigrations(dialect)
+		if err != nil {
+			t.Fatalf("loadMigrations(%s) error = %v", dialect, err)
+		}
+
+		current := make([]int64, 0, len(migrations))
+		for _, migration := range migrations {
+			current = append(current, migration.Version)
+		}
+
+		// Todos los dialectos deben tener las mismas versiones
+		if versions == nil {
+			versions = current
+		} else if !reflect.DeepEqual(versions, current) {
+			t.Errorf("versiones de %s = %v, esperaba %v", dialect, current, versions)
+		}
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	content := `-- comentario
+CREATE TABLE a (id INT);
+
+-- +migrate StatementBegin
+CREATE TRIGGER t AFTER UPDATE ON a
+BEGIN
+	UPDATE a SET id = 1;
+END;
+-- +migrate StatementEnd
+DROP TABLE b;
+-- +migrate Check hay filas duplicadas
+SELECT id FROM a
+GROUP BY id HAVING COUNT(*) > 1;
+UPDATE a SET id = 2;
+`
+	got, checks, err := splitStatements(content)
+	if err != nil {
+		t.Fatalf("splitStatements() error = %v", err)
+	}
+
+	want := []string{
+		"CREATE TABLE a (id INT)",
+		"CREATE TRIGGER t AFTER UPDATE ON a\nBEGIN\n\tUPDATE a SET id = 1;\nEND",
+		"DROP TABLE b",
+		"UPDATE a SET id = 2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitStatements() = %q, esperaba %q", got, want)
+	}
+	wantChecks := []Check{{Message: "hay filas duplicadas", Query: "SELECT id FROM a\nGROUP BY id HAVING COUNT(*) > 1"}}
+	if !reflect.DeepEqual(checks, wantChecks) {
+		t.Errorf("splitStatements() verificaciones = %q, esperaba %q", checks, wantChecks)
+	}
+
+	if _, _, err := splitStatements("-- +migrate StatementBegin\nSELECT 1;"); err == nil {
+		t.Errorf("splitStatements() esperaba error por bloque sin cerrar")
+	}
+	if _, _, err := splitStatements("-- +migrate Check sin consulta\n"); err == nil {
+		t.Errorf("splitStatements() esperaba error por verificación sin consulta")
+	}
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	migrator, err := New(db, DialectSQLite)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	total := len(migrator.Migrations())
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if len(applied) != total {
+		t.Errorf("Up() aplicó %d migraciones, esperaba %d", len(applied), total)
+	}
+
+	// Una segunda ejecución no debe aplicar nada
+	applied, err = migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if len(applied) != 0 {
+		t.Errorf("Up() repetido aplicó %d migraciones, esperaba 0", len(applied))
+	}
+
+	if _, err := db.Exec("INSERT INTO users (id, name, email, age) VALUES ('1', 'a', 'a@b.c', 1)"); err != nil {
+		t.Errorf("la tabla users no quedó utilizable: %v", err)
+	}
+
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+	latest := migrator.Migrations()[total-1].Version
+	if version != latest {
+		t.Errorf("Version() = %d, esperaba %d", version, latest)
+	}
+
+	// Revertir todas las migraciones una a una
+	for i := 0; i < total; i++ {
+		if _, err := migrator.Down(ctx); err != nil {
+			t.Fatalf("Down() error = %v", err)
+		}
+	}
+	if _, err := migrator.Down(ctx); !errors.Is(err, ErrNoMigrationApplied) {
+		t.Errorf("Down() sin migraciones error = %v, esperaba %v", err, ErrNoMigrationApplied)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	for _, status := range statuses {
+		if status.Applied {
+			t.Errorf("Status() migración %d sigue aplicada", status.Version)
+		}
+	}
+}
+
+func TestMigrator_StatusReadOnly(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	migrator, err := New(db, DialectSQLite)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() sin schema_migrations error = %v", err)
+	}
+	if len(statuses) != len(migrator.Migrations()) {
+		t.Fatalf("Status() retornó %d migraciones, esperaba %d", len(statuses), len(migrator.Migrations()))
+	}
+	for _, status := range statuses {
+		if status.Applied {
+			t.Errorf("Status() migración %d aplicada en una base vacía", status.Version)
+		}
+	}
+
+	// Status no debe crear objetos: un usuario sin permisos de DDL tiene que poder consultarlo
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'schema_migrations'").Scan(&tables); err != nil {
+		t.Fatalf("error al consultar sqlite_master: %v", err)
+	}
+	if tables != 0 {
+		t.Errorf("Status() creó la tabla schema_migrations")
+	}
+}
+
+func TestMigrator_UpSQLiteConcurrentProcesses(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "shared.sqlite")
+
+	// Cada *sql.DB simula un proceso distinto que abre el mismo archivo
+	const processes = 3
+	migrators := make([]*Migrator, processes)
+	for i := range migrators {
+		db, err := sql.Open("sqlite", "file:"+path)
+		if err != nil {
+			t.Fatalf("sql.Open() error = %v", err)
+		}
+		db.SetMaxOpenConns(1)
+		t.Cleanup(func() { db.Close() })
+
+		if migrators[i], err = New(db, DialectSQLite); err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	applied := make([]int, processes)
+	errs := make([]error, processes)
+	for i, migrator := range migrators {
+		wg.Add(1)
+		go func(i int, migrator *Migrator) {
+			defer wg.Done()
+			migrations, err := migrator.Up(ctx)
+			applied[i], errs[i] = len(migrations), err
+		}(i, migrator)
+	}
+	wg.Wait()
+
+	total := 0
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("Up() del proceso %d error = %v", i, err)
+		}
+		total += applied[i]
+	}
+	if want := len(migrators[0].Migrations()); total != want {
+		t.Errorf("los procesos aplicaron %d migraciones en total, esperaba %d (cada una una sola vez)", total, want)
+	}
+}
+
+func TestMigrator_UpSQLiteKeepsAppliedOnFailure(t *testing.T) {
+	ctx := context.Background()
+	migrator, err := New(newTestDB(t), DialectSQLite)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	known := len(migrator.Migrations())
+	migrator.migrations = append(migrator.migrations, Migration{
+		Version: 9999,
+		Name:    "broken",
+		Up:      []string{"CREATE TABLE broken (id INTEGER)", "INSERT INTO missing VALUES (1)"},
+	})
+
+	if _, err := migrator.Up(ctx); err == nil {
+		t.Fatal("Up() con una migración inválida no retornó error")
+	}
+
+	// Las migraciones anteriores quedan confirmadas y la inválida se revierte por completo
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	for i, status := range statuses {
+		if status.Applied != (i < known) {
+			t.Errorf("Status() migración %d aplicada = %v, esperaba %v", status.Version, status.Applied, i < known)
+		}
+	}
+	var tables int
+	if err := migrator.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'broken'").Scan(&tables); err != nil {
+		t.Fatalf("error al consultar sqlite_master: %v", err)
+	}
+	if tables != 0 {
+		t.Error("la tabla de la migración inválida no se revirtió")
+	}
+}
+
+// upTo aplica las migraciones anteriores a la llamada name y retorna su posición
+func upTo(t *testing.T, migrator *Migrator, name string) int {
+	t.Helper()
+	all := migrator.migrations
+	for i, migration := range all {
+		if migration.Name != name {
+			continue
+		}
+		migrator.migrations = all[:i]
+		defer func() { migrator.migrations = all }()
+		if _, err := migrator.Up(context.Background()); err != nil {
+			t.Fatalf("Up() error = %v", err)
+		}
+		return i
+	}
+	t.Fatalf("no se encontró la migración %s", name)
+	return -1
+}
+
+func TestMigrator_UpUniqueEmailDuplicates(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	migrator, err := New(db, DialectSQLite)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	unique := upTo(t, migrator, "unique_user_email")
+
+	for i, email := range []string{"Ana@x.com", "ana@x.com", "bruno@x.com"} {
+		if _, err := db.Exec("INSERT INTO users (id, name, email, age) VALUES (?, 'a', ?, 1)", i, email); err != nil {
+			t.Fatalf("error al insertar %q: %v", email, err)
+		}
+	}
+
+	_, err = migrator.Up(ctx)
+	if err == nil || !strings.Contains(err.Error(), "emails duplicados") || !strings.Contains(err.Error(), `"ana@x.com"`) {
+		t.Fatalf("Up() error = %v, esperaba informar los emails duplicados", err)
+	}
+	// Nada se aplicó: el índice anterior sigue y el único no se creó
+	var indexes []string
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'index' AND name IN ('idx_email', 'uq_users_email')")
+	if err != nil {
+		t.Fatalf("error al consultar sqlite_master: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("error al escanear sqlite_master: %v", err)
+		}
+		indexes = append(indexes, name)
+	}
+	if fmt.Sprint(indexes) != "[idx_email]" {
+		t.Errorf("índices = %v, esperaba [idx_email]", indexes)
+	}
+	if version, _ := migrator.Version(ctx); version != migrator.migrations[unique-1].Version {
+		t.Errorf("Version() = %d, esperaba %d", version, migrator.migrations[unique-1].Version)
+	}
+}
+
+func TestMigrator_UpNormalizeEmailsDuplicates(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	migrator, err := New(db, DialectSQLite)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// Aplicar hasta la migración anterior a la normalización de emails
+	all := migrator.migrations
+	normalize := upTo(t, migrator, "normalize_user_emails")
+
+	// Emails distintos que coinciden al normalizarse
+	for _, email := range []string{"a@x.com", " a@x.com", "b@x.com"} {
+		if _, err := db.Exec("INSERT INTO users (id, name, email, age) VALUES (?, 'a', ?, 1)", email, email); err != nil {
+			t.Fatalf("error al insertar %q: %v", email, err)
+		}
+	}
+
+	_, err = migrator.Up(ctx)
+	if err == nil || !strings.Contains(err.Error(), "coinciden al normalizarse") || !strings.Contains(err.Error(), `"a@x.com"`) {
+		t.Fatalf("Up() error = %v, esperaba informar los emails duplicados", err)
+	}
+
+	// La migración no se aplicó y los datos quedan intactos
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		t.Fatalf("Version() error = %v", err)
+	}
+	if version != all[normalize-1].Version {
+		t.Errorf("Version() = %d, esperaba %d", version, all[normalize-1].Version)
+	}
+	var email string
+	if err := db.QueryRow("SELECT email FROM users WHERE id = ' a@x.com'").Scan(&email); err != nil {
+		t.Fatalf("error al leer el usuario: %v", err)
+	}
+	if email != " a@x.com" {
+		t.Errorf("email = %q, esperaba que no se modificara", email)
+	}
+
+	// Tras unificar los usuarios la migración se aplica
+	if _, err := db.Exec("DELETE FROM users WHERE id = ' a@x.com'"); err != nil {
+		t.Fatalf("error al borrar el duplicado: %v", err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatalf("Up() tras unificar error = %v", err)
+	}
+}
+
+func TestNew_UnknownDialect(t *testing.T) {
+	if _, err := New(nil, "oracle"); err == nil {
+		t.Errorf("New() esperaba error para dialecto desconocido")
+	}
+}
@@ -0,0 +1,3 @@
+DROP INDEX uq_users_email ON users;
+
+CREATE INDEX idx_email ON users (email);
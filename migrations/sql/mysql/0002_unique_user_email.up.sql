@@ -0,0 +1,20 @@
+-- La colación utf8mb4_unicode_ci compara sin distinguir mayúsculas,
+-- por lo que un índice único sobre email ya es case-insensitive.
+-- El GROUP BY usa la misma colación que el índice (también ignora acentos y espacios finales),
+-- así que encuentra todos los emails que el índice rechazaría, y además los que solo difieren
+-- en espacios iniciales, que 0006 normaliza.
+-- +migrate Check hay usuarios con emails duplicados; deben unificarse antes de crear el índice único
+SELECT LOWER(TRIM(email)) FROM users GROUP BY LOWER(TRIM(email)) HAVING COUNT(*) > 1;
+
+-- MySQL no admite DROP INDEX IF EXISTS y el DDL se confirma implícitamente: el índice se
+-- elimina solo si existe para que la migración pueda reintentarse si falla la creación
+SET @drop_idx_email = (
+	SELECT IF(COUNT(*) > 0, 'DROP INDEX idx_email ON users', 'DO 0')
+	FROM information_schema.statistics
+	WHERE table_schema = DATABASE() AND table_name = 'users' AND index_name = 'idx_email'
+);
+PREPARE drop_idx_email FROM @drop_idx_email;
+EXECUTE drop_idx_email;
+DEALLOCATE PREPARE drop_idx_email;
+
+CREATE UNIQUE INDEX uq_users_email ON users (email);
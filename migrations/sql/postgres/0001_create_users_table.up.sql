@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS users (
+	id VARCHAR(36) PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	email VARCHAR(255) NOT NULL,
+	age INT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_email ON users (email);
+
+-- PostgreSQL no soporta ON UPDATE CURRENT_TIMESTAMP: updated_at se mantiene con un trigger
+-- +migrate StatementBegin
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+	NEW.updated_at = CURRENT_TIMESTAMP;
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+DROP TRIGGER IF EXISTS users_updated_at ON users;
+
+CREATE TRIGGER users_updated_at
+	BEFORE UPDATE ON users
+	FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_users_updated_at ON users (updated_at);
@@ -0,0 +1,8 @@
+-- Los emails se guardan normalizados (sin espacios y en minúsculas). Los dominios IDN
+-- existentes no pueden convertirse a punycode desde SQL y se normalizan al modificarse.
+-- Si dos usuarios quedarían con el mismo email, el UPDATE violaría uq_users_email (y en
+-- MySQL dejaría la tabla a medio actualizar): la migración no se aplica hasta unificarlos.
+-- +migrate Check hay usuarios cuyos emails coinciden al normalizarse; deben unificarse o corregirse antes de migrar
+SELECT LOWER(TRIM(email)) FROM users GROUP BY LOWER(TRIM(email)) HAVING COUNT(*) > 1;
+
+UPDATE users SET email = LOWER(TRIM(email));
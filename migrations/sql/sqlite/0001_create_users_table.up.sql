@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS users (
+	id VARCHAR(36) PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	email VARCHAR(255) NOT NULL,
+	age INT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_email ON users (email);
+
+-- SQLite no soporta ON UPDATE CURRENT_TIMESTAMP: updated_at se mantiene con un trigger
+-- +migrate StatementBegin
+CREATE TRIGGER IF NOT EXISTS users_updated_at
+	AFTER UPDATE ON users
+	FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+	UPDATE users SET updated_at = CURRENT_TIMESTAMP WHERE id = NEW.id;
+END;
+-- +migrate StatementEnd
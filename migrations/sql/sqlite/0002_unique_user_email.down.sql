@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS uq_users_email;
+
+CREATE INDEX IF NOT EXISTS idx_email ON users (email);
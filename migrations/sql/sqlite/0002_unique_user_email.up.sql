@@ -0,0 +1,7 @@
+-- Índice único sobre LOWER(email) para que la unicidad no distinga mayúsculas
+-- +migrate Check hay usuarios con emails duplicados; deben unificarse antes de crear el índice único
+SELECT LOWER(TRIM(email)) FROM users GROUP BY LOWER(TRIM(email)) HAVING COUNT(*) > 1;
+
+DROP INDEX IF EXISTS idx_email;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_users_email ON users (LOWER(email));
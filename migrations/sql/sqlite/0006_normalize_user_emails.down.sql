@@ -0,0 +1 @@
+-- La normalización no es reversible: el formato original de los emails no se conserva.
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/xml"
+
+	"helloworld/apperrors"
+)
+
+// MaxBatchSize es la cantidad máxima de elementos de una operación por lotes
+const MaxBatchSize = 1000
+
+// BatchMode indica cómo se aplica una operación por lotes
+type BatchMode string
+
+// Modos de las operaciones por lotes
+const (
+	BatchModeAtomic  BatchMode = "atomic"  // Se aplican todos los elementos o ninguno
+	BatchModePartial BatchMode = "partial" // Cada elemento se aplica por separado
+)
+
+// OrDefault retorna el modo, o BatchModeAtomic si no se indicó ninguno
+func (m BatchMode) OrDefault() BatchMode {
+	if m == "" {
+		return BatchModeAtomic
+	}
+	return m
+}
+
+// Valid indica si el modo es uno de los soportados
+func (m BatchMode) Valid() bool {
+	return m == BatchModeAtomic || m == BatchModePartial
+}
+
+// UserRef identifica un usuario y, opcionalmente, la versión que se espera que tenga
+// @Description Referencia a un usuario para operaciones por lotes
+type UserRef struct {
+	ID      string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"` // ID del usuario
+	Version int64  `json:"version,omitempty" example:"3"`                     // Versión esperada (omitida = cualquiera)
+}
+
+// ReplaceUserItem es un elemento del reemplazo por lotes
+// @Description Usuario a reemplazar en una operación por lotes
+type ReplaceUserItem struct {
+	ID      string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"` // ID del usuario
+	Version int64  `json:"version,omitempty" example:"3"`                     // Versión esperada (omitida = cualquiera)
+	ReplaceUserRequest
+}
+
+// BatchCreateRequest representa la solicitud de creación por lotes
+// @Description Usuarios a crear y modo de aplicación
+type BatchCreateRequest struct {
+	Mode  BatchMode           `json:"mode,omitempty" enums:"atomic,partial" example:"atomic"` // atomic (por defecto) o partial
+	Users []CreateUserRequest `json:"users"`                                                  // Usuarios a crear (máximo 1000)
+}
+
+// BatchReplaceRequest representa la solicitud de reemplazo por lotes
+// @Description Usuarios a reemplazar y modo de aplicación
+type BatchReplaceRequest struct {
+	Mode  BatchMode         `json:"mode,omitempty" enums:"atomic,partial" example:"atomic"` // atomic (por defecto) o partial
+	Users []ReplaceUserItem `json:"users"`                                                  // Usuarios a reemplazar (máximo 1000)
+}
+
+// BatchDeleteRequest representa la solicitud de eliminación por lotes
+// @Description Usuarios a eliminar y modo de aplicación
+type BatchDeleteRequest struct {
+	Mode  BatchMode `json:"mode,omitempty" enums:"atomic,partial" example:"atomic"` // atomic (por defecto) o partial
+	Users []UserRef `json:"users"`                                                  // Usuarios a eliminar (máximo 1000)
+}
+
+// BatchResult es el resultado de un elemento de una operación por lotes: el usuario
+// afectado o el error que impidió aplicarlo
+type BatchResult struct {
+	ID   string
+	User *User
+	Err  error
+}
+
+// BatchResponse representa la respuesta de una operación por lotes
+// @Description Resultado por elemento de una operación por lotes
+type BatchResponse struct {
+	XMLName   xml.Name            `json:"-" xml:"batch"`
+	Mode      BatchMode           `json:"mode" xml:"mode" example:"atomic"`      // Modo aplicado
+	Succeeded int                 `json:"succeeded" xml:"succeeded" example:"2"` // Elementos aplicados
+	Failed    int                 `json:"failed" xml:"failed" example:"0"`       // Elementos no aplicados
+	Results   []BatchItemResponse `json:"results" xml:"results>result"`          // Resultados en el orden de la petición
+}
+
+// BatchItemResponse representa el resultado de un elemento de una operación por lotes
+// @Description Resultado de un elemento: el usuario afectado o el error
+type BatchItemResponse struct {
+	Index  int                `json:"index" xml:"index" example:"0"`                                                  // Posición del elemento en la petición
+	Status int                `json:"status" xml:"status" example:"201"`                                              // Status HTTP equivalente a la operación individual
+	ID     string             `json:"id,omitempty" xml:"id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"` // ID del usuario afectado
+	User   *User              `json:"user,omitempty" xml:"user,omitempty"`                                            // Usuario resultante (creación y reemplazo)
+	Error  *apperrors.Problem `json:"error,omitempty" xml:"error,omitempty"`                                          // Error del elemento
+}
@@ -0,0 +1,221 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+)
+
+// UserField es un campo de User, identificado por su nombre JSON
+type UserField string
+
+// Campos de User que pueden seleccionarse
+const (
+	UserFieldID        UserField = "id"
+	UserFieldName      UserField = "name"
+	UserFieldEmail     UserField = "email"
+	UserFieldAge       UserField = "age"
+	UserFieldVersion   UserField = "version"
+	UserFieldCreatedAt UserField = "created_at"
+	UserFieldUpdatedAt UserField = "updated_at"
+	UserFieldDeletedAt UserField = "deleted_at"
+)
+
+// AllUserFields son todos los campos de User, en el orden en que aparecen en las respuestas
+var AllUserFields = UserFields{
+	UserFieldID, UserFieldName, UserFieldEmail, UserFieldAge,
+	UserFieldVersion, UserFieldCreatedAt, UserFieldUpdatedAt, UserFieldDeletedAt,
+}
+
+// ErrInvalidFields indica una selección de campos con un campo inexistente o vacío
+var ErrInvalidFields = errors.New("selección de campos inválida; use id, name, email, age, version, created_at, updated_at o deleted_at separados por comas")
+
+// Valid indica si el campo es uno de los de User
+func (f UserField) Valid() bool {
+	for _, field := range AllUserFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// UserFields es una selección de campos de User (sparse fieldset), en el orden de AllUserFields.
+// Una selección vacía equivale a todos los campos.
+type UserFields []UserField
+
+// ParseUserFields interpreta una lista de campos separados por comas. Los repetidos se ignoran.
+func ParseUserFields(raw string) (UserFields, error) {
+	selected := make(map[UserField]bool)
+	for _, name := range strings.Split(raw, ",") {
+		field := UserField(strings.TrimSpace(name))
+		if !field.Valid() {
+			return nil, ErrInvalidFields
+		}
+		selected[field] = true
+	}
+	fields := make(UserFields, 0, len(selected))
+	for _, field := range AllUserFields {
+		if selected[field] {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// Valid indica si todos los campos de la selección son campos de User
+func (f UserFields) Valid() bool {
+	for _, field := range f {
+		if !field.Valid() {
+			return false
+		}
+	}
+	return true
+}
+
+// Has indica si la selección incluye field
+func (f UserFields) Has(field UserField) bool {
+	if len(f) == 0 {
+		return true
+	}
+	for _, selected := range f {
+		if selected == field {
+			return true
+		}
+	}
+	return false
+}
+
+// With retorna la selección ampliada con extra. Una selección vacía ya incluye todos los campos.
+func (f UserFields) With(extra ...UserField) UserFields {
+	if len(f) == 0 {
+		return f
+	}
+	selected := make(map[UserField]bool, len(f)+len(extra))
+	for _, field := range append(append(UserFields(nil), f...), extra...) {
+		selected[field] = true
+	}
+	fields := make(UserFields, 0, len(selected))
+	for _, field := range AllUserFields {
+		if selected[field] {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// OrAll retorna la selección, o AllUserFields si está vacía
+func (f UserFields) OrAll() UserFields {
+	if len(f) == 0 {
+		return AllUserFields
+	}
+	return f
+}
+
+// String retorna la selección en el mismo formato que acepta ParseUserFields
+func (f UserFields) String() string {
+	names := make([]string, len(f))
+	for i, field := range f {
+		names[i] = string(field)
+	}
+	return strings.Join(names, ",")
+}
+
+// Project retorna los campos seleccionados de user. Como en User, deleted_at se omite si es nil.
+func (f UserFields) Project(user *User) PartialUser {
+	partial := make(PartialUser, len(f.OrAll()))
+	for _, field := range f.OrAll() {
+		if value := user.fieldValue(field); value != nil {
+			partial[field] = value
+		}
+	}
+	return partial
+}
+
+// fieldValue retorna el valor de field, o nil si es un deleted_at vacío
+func (u *User) fieldValue(field UserField) interface{} {
+	switch field {
+	case UserFieldID:
+		return u.ID
+	case UserFieldName:
+		return u.Name
+	case UserFieldEmail:
+		return u.Email
+	case UserFieldAge:
+		return u.Age
+	case UserFieldVersion:
+		return u.Version
+	case UserFieldCreatedAt:
+		return u.CreatedAt
+	case UserFieldUpdatedAt:
+		return u.UpdatedAt
+	case UserFieldDeletedAt:
+		if u.DeletedAt == nil {
+			return nil
+		}
+		return *u.DeletedAt
+	default:
+		return nil
+	}
+}
+
+// PartialUser es un usuario limitado a los campos de una selección. Se serializa con los
+// mismos nombres y en el mismo orden que User.
+type PartialUser map[UserField]interface{}
+
+// Fields retorna los campos presentes, en el orden de AllUserFields
+func (p PartialUser) Fields() UserFields {
+	fields := make(UserFields, 0, len(p))
+	for _, field := range AllUserFields {
+		if _, ok := p[field]; ok {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// MarshalJSON escribe los campos en el orden de AllUserFields en lugar del orden alfabético de los mapas
+func (p PartialUser) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, field := range p.Fields() {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		value, err := json.Marshal(p[field])
+		if err != nil {
+			return nil, err
+		}
+		b.WriteString(`"` + string(field) + `":`)
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// MarshalXML escribe el elemento user con los campos en el orden de AllUserFields
+func (p PartialUser) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Name = xml.Name{Local: "user"}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, field := range p.Fields() {
+		if err := e.EncodeElement(p[field], xml.StartElement{Name: xml.Name{Local: string(field)}}); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// PartialUserListResponse es la respuesta paginada del listado cuando se seleccionan campos
+type PartialUserListResponse struct {
+	XMLName    xml.Name      `json:"-" xml:"users"`
+	Fields     UserFields    `json:"-" xml:"-"` // Campos seleccionados, usados como columnas en CSV
+	Data       []PartialUser `json:"data" xml:"data>user"`
+	Total      int           `json:"total" xml:"total"`
+	Limit      int           `json:"limit" xml:"limit"`
+	Offset     int           `json:"offset,omitempty" xml:"offset,omitempty"`
+	NextCursor string        `json:"next_cursor,omitempty" xml:"next_cursor,omitempty"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/xml"
+
+	"helloworld/apperrors"
+)
+
+// ImportRow es una fila leída de un archivo de importación
+type ImportRow struct {
+	Line int               // Línea del archivo donde está la fila
+	User CreateUserRequest // Datos de la fila
+	Err  error             // Motivo por el que la fila no pudo leerse; no detiene la importación
+}
+
+// ImportFailure es una fila de una importación que no se aplicó
+type ImportFailure struct {
+	Line int
+	Err  error
+}
+
+// ImportResult es el resultado de una importación
+type ImportResult struct {
+	DryRun   bool
+	Rows     int             // Filas de datos leídas
+	Imported int             // Filas creadas (o válidas, con DryRun)
+	Failures []ImportFailure // Filas no aplicadas, ordenadas por línea
+}
+
+// ImportReport representa el informe de una importación
+// @Description Resultado de una importación con los errores por fila
+type ImportReport struct {
+	XMLName  xml.Name         `json:"-" xml:"import_report"`
+	DryRun   bool             `json:"dry_run" xml:"dry_run" example:"false"` // Si solo se validaron las filas
+	Rows     int              `json:"rows" xml:"rows" example:"3"`           // Filas de datos leídas
+	Imported int              `json:"imported" xml:"imported" example:"2"`   // Filas creadas (o válidas, con dry_run)
+	Failed   int              `json:"failed" xml:"failed" example:"1"`       // Filas con errores
+	Errors   []ImportRowError `json:"errors" xml:"errors>error"`             // Errores por fila, ordenados por línea
+}
+
+// ImportRowError representa el error de una fila de una importación
+// @Description Error de una fila de una importación
+type ImportRowError struct {
+	Line  int               `json:"line" xml:"line" example:"3"` // Línea del archivo (en CSV la cabecera es la línea 1)
+	Error apperrors.Problem `json:"error" xml:"error"`           // Error de la fila
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Límites de paginación del listado de usuarios
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ErrInvalidCursor indica que el cursor recibido no pudo decodificarse
+var ErrInvalidCursor = errors.New("cursor inválido")
+
+// Cursor identifica una posición en el listado: el valor del campo de orden y el id
+// del último usuario de la página. Sort registra el orden con el que fue generado.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    string `json:"i"`
+}
+
+// NewCursor crea el cursor que apunta después de user en el orden indicado
+func NewCursor(sort UserSort, user *User) *Cursor {
+	var value string
+	switch sort.Field {
+	case SortByName:
+		value = user.Name
+	case SortByEmail:
+		value = user.Email
+	case SortByAge:
+		value = strconv.Itoa(user.Age)
+	case SortByUpdatedAt:
+		value = user.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		value = user.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return &Cursor{Sort: sort.String(), Value: value, ID: user.ID}
+}
+
+// Encode serializa el cursor en un string opaco apto para URLs
+func (c Cursor) Encode() string {
+	// nolint:errcheck // Cursor solo contiene tipos serializables
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// SortValue retorna el valor del cursor con el tipo del campo de orden:
+// int para age, time.Time para created_at y updated_at y string para el resto
+func (c Cursor) SortValue(field SortField) (interface{}, error) {
+	switch field {
+	case SortByAge:
+		age, err := strconv.Atoi(c.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return age, nil
+	case SortByCreatedAt, SortByUpdatedAt:
+		instant, err := time.Parse(time.RFC3339Nano, c.Value)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		return instant, nil
+	default:
+		return c.Value, nil
+	}
+}
+
+// DecodeCursor reconstruye un cursor generado por Cursor.Encode
+func DecodeCursor(encoded string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	sort, err := ParseUserSort(cursor.Sort)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if _, err := cursor.SortValue(sort.Field); err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+// UserPage es una página del listado de usuarios.
+// Limit y Offset reflejan los valores efectivamente aplicados.
+type UserPage struct {
+	Users      []*User
+	Total      int
+	Limit      int
+	Offset     int
+	NextCursor *Cursor
+}
+
+// UserListResponse representa la respuesta paginada del listado de usuarios
+// @Description Página de usuarios con metadatos de paginación
+type UserListResponse struct {
+	XMLName    xml.Name `json:"-" xml:"users"`
+	Data       []*User  `json:"data" xml:"data>user"`                                                 // Usuarios de la página
+	Total      int      `json:"total" xml:"total" example:"42"`                                       // Total de usuarios
+	Limit      int      `json:"limit" xml:"limit" example:"20"`                                       // Tamaño de página aplicado
+	Offset     int      `json:"offset,omitempty" xml:"offset,omitempty" example:"0"`                  // Offset aplicado (solo paginación por offset)
+	NextCursor string   `json:"next_cursor,omitempty" xml:"next_cursor,omitempty" example:"eyJjIjoi"` // Cursor de la página siguiente
+}
@@ -0,0 +1,17 @@
+package models
+
+import "encoding/xml"
+
+// MessageResponse representa una respuesta con un mensaje informativo
+// @Description Mensaje sobre el resultado de la operación
+type MessageResponse struct {
+	XMLName xml.Name `json:"-" xml:"response"`
+	Message string   `json:"message" xml:"message" example:"usuario eliminado correctamente"` // Mensaje informativo
+}
+
+// PurgeResponse representa el resultado de la purga de usuarios eliminados
+// @Description Cantidad de usuarios purgados
+type PurgeResponse struct {
+	XMLName xml.Name `json:"-" xml:"purge"`
+	Purged  int64    `json:"purged" xml:"purged" example:"3"` // Usuarios borrados definitivamente
+}
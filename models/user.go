@@ -1,30 +1,43 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"encoding/xml"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AnyVersion indica que una operación no exige una versión concreta del usuario
+const AnyVersion int64 = 0
 
 // User representa un usuario en el sistema
 // @Description Usuario del sistema
 type User struct {
-	ID    string `json:"id" example:"550e8400-e29b-41d4-a716-446655440000"` // ID único del usuario
-	Name  string `json:"name" example:"Juan Pérez"`                         // Nombre del usuario
-	Email string `json:"email" example:"juan@example.com"`                  // Email del usuario
-	Age   int    `json:"age" example:"30"`                                  // Edad del usuario
+	XMLName   xml.Name   `json:"-" xml:"user"`
+	ID        string     `json:"id" xml:"id" example:"550e8400-e29b-41d4-a716-446655440000"`                     // ID único del usuario
+	Name      string     `json:"name" xml:"name" example:"Juan Pérez"`                                           // Nombre del usuario
+	Email     string     `json:"email" xml:"email" example:"juan@example.com"`                                   // Email del usuario
+	Age       int        `json:"age" xml:"age" example:"30"`                                                     // Edad del usuario
+	Version   int64      `json:"version" xml:"version" example:"1"`                                              // Versión del usuario; se incrementa en cada modificación
+	CreatedAt time.Time  `json:"created_at" xml:"created_at" example:"2024-01-15T09:30:00Z"`                     // Fecha de alta del usuario
+	UpdatedAt time.Time  `json:"updated_at" xml:"updated_at" example:"2024-01-20T18:45:00Z"`                     // Fecha de la última modificación
+	DeletedAt *time.Time `json:"deleted_at,omitempty" xml:"deleted_at,omitempty" example:"2024-01-31T10:00:00Z"` // Fecha de eliminación lógica (solo usuarios eliminados)
 }
 
 // CreateUserRequest representa la solicitud para crear un usuario
 // @Description Datos requeridos para crear un nuevo usuario
 type CreateUserRequest struct {
-	Name  string `json:"name" example:"Juan Pérez" binding:"required"`        // Nombre del usuario
-	Email string `json:"email" example:"juan@example.com" binding:"required"` // Email del usuario
-	Age   int    `json:"age" example:"30" binding:"required"`                 // Edad del usuario
+	Name  string `json:"name" example:"Juan Pérez" binding:"required" maxLength:"100"`        // Nombre del usuario
+	Email string `json:"email" example:"juan@example.com" binding:"required" maxLength:"254"` // Email del usuario
+	Age   int    `json:"age" example:"30" binding:"required" minimum:"1" maximum:"150"`       // Edad del usuario
 }
 
-// UpdateUserRequest representa la solicitud para actualizar un usuario
-// @Description Datos opcionales para actualizar un usuario (actualización parcial)
-type UpdateUserRequest struct {
-	Name  *string `json:"name,omitempty" example:"Juan Pérez"`        // Nuevo nombre (opcional)
-	Email *string `json:"email,omitempty" example:"juan@example.com"` // Nuevo email (opcional)
-	Age   *int    `json:"age,omitempty" example:"31"`                 // Nueva edad (opcional)
+// ReplaceUserRequest representa la solicitud para reemplazar los datos de un usuario (PUT)
+// @Description Datos completos del usuario; los campos omitidos no conservan su valor anterior
+type ReplaceUserRequest struct {
+	Name  string `json:"name" example:"Juan Pérez" binding:"required" maxLength:"100"`        // Nombre del usuario
+	Email string `json:"email" example:"juan@example.com" binding:"required" maxLength:"254"` // Email del usuario
+	Age   int    `json:"age" example:"31" binding:"required" minimum:"1" maximum:"150"`       // Edad del usuario
 }
 
 // NewUser crea una nueva instancia de User con un ID generado
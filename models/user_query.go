@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// SortField es un campo por el que puede ordenarse el listado de usuarios
+type SortField string
+
+// Campos de ordenamiento soportados
+const (
+	SortByName      SortField = "name"
+	SortByEmail     SortField = "email"
+	SortByAge       SortField = "age"
+	SortByCreatedAt SortField = "created_at"
+	SortByUpdatedAt SortField = "updated_at"
+)
+
+// ErrInvalidSort indica un campo de ordenamiento no soportado
+var ErrInvalidSort = errors.New("campo de ordenamiento inválido; use name, email, age, created_at o updated_at con '-' opcional para orden descendente")
+
+// Valid indica si el campo es uno de los soportados
+func (f SortField) Valid() bool {
+	switch f {
+	case SortByName, SortByEmail, SortByAge, SortByCreatedAt, SortByUpdatedAt:
+		return true
+	default:
+		return false
+	}
+}
+
+// UserSort define el orden del listado; el id desempata siempre en la misma dirección
+type UserSort struct {
+	Field SortField
+	Desc  bool
+}
+
+// DefaultUserSort es el orden del listado cuando no se indica otro
+var DefaultUserSort = UserSort{Field: SortByCreatedAt, Desc: true}
+
+// ParseUserSort interpreta el formato "campo" (ascendente) o "-campo" (descendente)
+func ParseUserSort(raw string) (UserSort, error) {
+	sort := UserSort{Field: SortField(raw)}
+	if strings.HasPrefix(raw, "-") {
+		sort = UserSort{Field: SortField(raw[1:]), Desc: true}
+	}
+	if !sort.Field.Valid() {
+		return UserSort{}, ErrInvalidSort
+	}
+	return sort, nil
+}
+
+// String retorna el orden en el mismo formato que acepta ParseUserSort
+func (s UserSort) String() string {
+	if s.Desc {
+		return "-" + string(s.Field)
+	}
+	return string(s.Field)
+}
+
+// UserFilter restringe el listado de usuarios; los campos vacíos o nil no filtran
+type UserFilter struct {
+	NamePrefix     string     // Nombre que comienza con este prefijo (sin distinguir mayúsculas)
+	Email          string     // Email exacto (sin distinguir mayúsculas)
+	Emails         []string   // Alguno de estos emails (sin distinguir mayúsculas); vacía no filtra
+	MinAge         *int       // Edad mínima inclusive
+	MaxAge         *int       // Edad máxima inclusive
+	CreatedAfter   *time.Time // Creado en este instante o después
+	CreatedBefore  *time.Time // Creado estrictamente antes de este instante
+	UpdatedSince   *time.Time // Modificado en este instante o después (sincronización incremental)
+	IncludeDeleted bool       // Incluir usuarios eliminados lógicamente
+}
+
+// UserQuery define los parámetros del listado de usuarios.
+// Si Cursor no es nil se usa paginación keyset y Offset debe ser 0.
+// Un Sort con Field vacío equivale a DefaultUserSort.
+// Fields limita los campos que se leen de cada usuario; vacía lee todos.
+// SkipTotal evita contar los usuarios del filtro (UserPage.Total queda en 0); lo usan las
+// verificaciones de existencia, que no necesitan el total.
+type UserQuery struct {
+	Filter    UserFilter
+	Sort      UserSort
+	Limit     int
+	Offset    int
+	Cursor    *Cursor
+	Fields    UserFields
+	SkipTotal bool
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"helloworld/models"
+)
+
+// OperationObserver recibe el método, la duración y el error de cada operación de un repositorio
+type OperationObserver func(method string, duration time.Duration, err error)
+
+// instrumentedUserRepository mide cada operación de otro UserRepository
+type instrumentedUserRepository struct {
+	repo    UserRepository
+	observe OperationObserver
+}
+
+// Instrument envuelve repo para informar a observe la duración de cada operación.
+// La duración de Stream incluye la de fn, que suele escribir en la respuesta HTTP.
+func Instrument(repo UserRepository, observe OperationObserver) UserRepository {
+	return &instrumentedUserRepository{repo: repo, observe: observe}
+}
+
+// measure informa la duración desde start; se usa con defer para incluir el error final
+func (r *instrumentedUserRepository) measure(method string, start time.Time, err *error) {
+	r.observe(method, time.Since(start), *err)
+}
+
+func (r *instrumentedUserRepository) Create(ctx context.Context, user *models.User) (err error) {
+	defer r.measure("Create", time.Now(), &err)
+	return r.repo.Create(ctx, user)
+}
+
+func (r *instrumentedUserRepository) GetByID(ctx context.Context, id string) (user *models.User, err error) {
+	defer r.measure("GetByID", time.Now(), &err)
+	return r.repo.GetByID(ctx, id)
+}
+
+func (r *instrumentedUserRepository) GetFieldsByID(ctx context.Context, id string, fields models.UserFields) (user *models.User, err error) {
+	defer r.measure("GetFieldsByID", time.Now(), &err)
+	return r.repo.GetFieldsByID(ctx, id, fields)
+}
+
+func (r *instrumentedUserRepository) GetAll(ctx context.Context, query models.UserQuery) (page *models.UserPage, err error) {
+	defer r.measure("GetAll", time.Now(), &err)
+	return r.repo.GetAll(ctx, query)
+}
+
+func (r *instrumentedUserRepository) Update(ctx context.Context, id string, user *models.User) (err error) {
+	defer r.measure("Update", time.Now(), &err)
+	return r.repo.Update(ctx, id, user)
+}
+
+func (r *instrumentedUserRepository) Delete(ctx context.Context, id string, version int64) (err error) {
+	defer r.measure("Delete", time.Now(), &err)
+	return r.repo.Delete(ctx, id, version)
+}
+
+func (r *instrumentedUserRepository) Restore(ctx context.Context, id string) (err error) {
+	defer r.measure("Restore", time.Now(), &err)
+	return r.repo.Restore(ctx, id)
+}
+
+func (r *instrumentedUserRepository) Purge(ctx context.Context, deletedBefore time.Time) (purged int64, err error) {
+	defer r.measure("Purge", time.Now(), &err)
+	return r.repo.Purge(ctx, deletedBefore)
+}
+
+func (r *instrumentedUserRepository) CreateBatch(ctx context.Context, users []*models.User) (err error) {
+	defer r.measure("CreateBatch", time.Now(), &err)
+	return r.repo.CreateBatch(ctx, users)
+}
+
+func (r *instrumentedUserRepository) UpdateBatch(ctx context.Context, users []*models.User) (err error) {
+	defer r.measure("UpdateBatch", time.Now(), &err)
+	return r.repo.UpdateBatch(ctx, users)
+}
+
+func (r *instrumentedUserRepository) DeleteBatch(ctx context.Context, refs []models.UserRef) (err error) {
+	defer r.measure("DeleteBatch", time.Now(), &err)
+	return r.repo.DeleteBatch(ctx, refs)
+}
+
+func (r *instrumentedUserRepository) Stream(ctx context.Context, filter models.UserFilter, sort models.UserSort, fn func(*models.User) error) (err error) {
+	defer r.measure("Stream", time.Now(), &err)
+	return r.repo.Stream(ctx, filter, sort, fn)
+}
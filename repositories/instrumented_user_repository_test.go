@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"helloworld/models"
+)
+
+// observation es una operación informada por el repositorio instrumentado
+type observation struct {
+	method string
+	err    error
+}
+
+func TestInstrument(t *testing.T) {
+	ctx := context.Background()
+	var observed []observation
+	repo := Instrument(NewMemoryUserRepository(), func(method string, duration time.Duration, err error) {
+		if duration < 0 {
+			t.Errorf("%s: duración negativa %v", method, duration)
+		}
+		observed = append(observed, observation{method: method, err: err})
+	})
+
+	user := &models.User{ID: "1", Name: "Juan Pérez", Email: "juan@example.com", Age: 30}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := repo.GetByID(ctx, "inexistente"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("GetByID() error = %v, esperaba %v", err, ErrUserNotFound)
+	}
+	count := 0
+	err := repo.Stream(ctx, models.UserFilter{}, models.DefaultUserSort, func(*models.User) error {
+		count++
+		return nil
+	})
+	if err != nil || count != 1 {
+		t.Fatalf("Stream() = %d usuarios, error %v; esperaba 1 usuario", count, err)
+	}
+
+	want := []observation{{"Create", nil}, {"GetByID", ErrUserNotFound}, {"Stream", nil}}
+	if len(observed) != len(want) {
+		t.Fatalf("operaciones observadas = %v, esperaba %v", observed, want)
+	}
+	for i, got := range observed {
+		if got.method != want[i].method || !errors.Is(got.err, want[i].err) {
+			t.Errorf("operación %d = %v, esperaba %v", i, got, want[i])
+		}
+	}
+}
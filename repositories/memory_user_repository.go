@@ -0,0 +1,477 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"helloworld/models"
+)
+
+// memoryRecord guarda un usuario junto con su fecha de eliminación lógica
+type memoryRecord struct {
+	user      models.User
+	deletedAt *time.Time
+}
+
+// MemoryUserRepository implementa UserRepository en memoria.
+// Es seguro para uso concurrente y los datos se pierden al reiniciar.
+type MemoryUserRepository struct {
+	mu      sync.RWMutex
+	records map[string]*memoryRecord
+	now     func() time.Time
+}
+
+// NewMemoryUserRepository crea una nueva instancia del repositorio en memoria
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		records: make(map[string]*memoryRecord),
+		now:     time.Now,
+	}
+}
+
+// Close no libera recursos; existe para cumplir el mismo contrato que MySQLUserRepository
+func (r *MemoryUserRepository) Close() error {
+	return nil
+}
+
+// Create guarda un nuevo usuario
+func (r *MemoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.emailTaken(user.Email, "") {
+		return ErrEmailAlreadyExists
+	}
+
+	now := r.now()
+	user.Version = 1
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	r.records[user.ID] = &memoryRecord{user: *user}
+	return nil
+}
+
+// GetByID obtiene un usuario por su ID
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	record, exists := r.records[id]
+	if !exists || record.deletedAt != nil {
+		return nil, ErrUserNotFound
+	}
+
+	user := record.user
+	return &user, nil
+}
+
+// GetFieldsByID obtiene un usuario por su ID. En memoria no hay lecturas que evitar,
+// por lo que retorna el usuario completo.
+func (r *MemoryUserRepository) GetFieldsByID(ctx context.Context, id string, fields models.UserFields) (*models.User, error) {
+	return r.GetByID(ctx, id)
+}
+
+// GetAll obtiene una página de usuarios filtrada y ordenada según query
+func (r *MemoryUserRepository) GetAll(ctx context.Context, query models.UserQuery) (*models.UserPage, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if query.Limit <= 0 {
+		query.Limit = models.DefaultPageLimit
+	}
+	if query.Sort.Field == "" {
+		query.Sort = models.DefaultUserSort
+	}
+	if !query.Sort.Field.Valid() {
+		return nil, models.ErrInvalidSort
+	}
+
+	r.mu.RLock()
+	records := make([]memoryRecord, 0, len(r.records))
+	for _, record := range r.records {
+		if matchesFilter(record, query.Filter) {
+			records = append(records, *record)
+		}
+	}
+	r.mu.RUnlock()
+
+	// Mismo orden que los repositorios SQL: campo de orden y luego id en la misma dirección
+	sort.Slice(records, func(i, j int) bool {
+		return recordBefore(records[i], records[j], query.Sort)
+	})
+
+	start := query.Offset
+	if query.Cursor != nil {
+		cursor, err := cursorRecord(query.Cursor, query.Sort.Field)
+		if err != nil {
+			return nil, err
+		}
+		start = sort.Search(len(records), func(i int) bool {
+			return recordBefore(cursor, records[i], query.Sort)
+		})
+	}
+	if start > len(records) {
+		start = len(records)
+	}
+
+	end := start + query.Limit
+	// Contar es gratuito en memoria; SkipTotal solo deja el total en 0 como en los repositorios SQL
+	page := &models.UserPage{
+		Users: make([]*models.User, 0, query.Limit),
+	}
+	if !query.SkipTotal {
+		page.Total = len(records)
+	}
+	if end < len(records) {
+		last := records[end-1]
+		page.NextCursor = models.NewCursor(query.Sort, &last.user)
+	} else {
+		end = len(records)
+	}
+
+	for _, record := range records[start:end] {
+		page.Users = append(page.Users, record.toUser())
+	}
+
+	return page, nil
+}
+
+// toUser retorna una copia del usuario con su fecha de eliminación
+func (record memoryRecord) toUser() *models.User {
+	user := record.user
+	if record.deletedAt != nil {
+		deletedAt := *record.deletedAt
+		user.DeletedAt = &deletedAt
+	}
+	return &user
+}
+
+// matchesFilter indica si el registro cumple todas las condiciones del filtro
+func matchesFilter(record *memoryRecord, filter models.UserFilter) bool {
+	user := record.user
+	switch {
+	case !filter.IncludeDeleted && record.deletedAt != nil:
+		return false
+	case filter.NamePrefix != "" && !strings.HasPrefix(strings.ToLower(user.Name), strings.ToLower(filter.NamePrefix)):
+		return false
+	case filter.Email != "" && !strings.EqualFold(user.Email, filter.Email):
+		return false
+	case len(filter.Emails) > 0 && !containsFold(filter.Emails, user.Email):
+		return false
+	case filter.MinAge != nil && user.Age < *filter.MinAge:
+		return false
+	case filter.MaxAge != nil && user.Age > *filter.MaxAge:
+		return false
+	case filter.CreatedAfter != nil && user.CreatedAt.Before(*filter.CreatedAfter):
+		return false
+	case filter.CreatedBefore != nil && !user.CreatedAt.Before(*filter.CreatedBefore):
+		return false
+	case filter.UpdatedSince != nil && user.UpdatedAt.Before(*filter.UpdatedSince):
+		return false
+	default:
+		return true
+	}
+}
+
+// containsFold indica si values contiene value sin distinguir mayúsculas
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// cursorRecord construye un registro ficticio con la posición del cursor
+func cursorRecord(cursor *models.Cursor, field models.SortField) (memoryRecord, error) {
+	record := memoryRecord{user: models.User{ID: cursor.ID}}
+
+	value, err := cursor.SortValue(field)
+	if err != nil {
+		return record, err
+	}
+
+	switch v := value.(type) {
+	case int:
+		record.user.Age = v
+	case time.Time:
+		record.user.CreatedAt = v
+		record.user.UpdatedAt = v
+	case string:
+		record.user.Name = v
+		record.user.Email = v
+	}
+	return record, nil
+}
+
+// recordBefore indica si a precede a b en el orden indicado
+func recordBefore(a, b memoryRecord, order models.UserSort) bool {
+	var cmp int
+	switch order.Field {
+	case models.SortByName:
+		cmp = strings.Compare(a.user.Name, b.user.Name)
+	case models.SortByEmail:
+		cmp = strings.Compare(a.user.Email, b.user.Email)
+	case models.SortByAge:
+		cmp = a.user.Age - b.user.Age
+	case models.SortByUpdatedAt:
+		cmp = a.user.UpdatedAt.Compare(b.user.UpdatedAt)
+	default:
+		cmp = a.user.CreatedAt.Compare(b.user.CreatedAt)
+	}
+	if cmp == 0 {
+		cmp = strings.Compare(a.user.ID, b.user.ID)
+	}
+
+	if order.Desc {
+		return cmp > 0
+	}
+	return cmp < 0
+}
+
+// Update actualiza un usuario existente si user.Version coincide con la versión almacenada
+func (r *MemoryUserRepository) Update(ctx context.Context, id string, user *models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.updateLocked(id, user)
+}
+
+// updateLocked aplica Update; debe llamarse con el lock tomado
+func (r *MemoryUserRepository) updateLocked(id string, user *models.User) error {
+	record, exists := r.records[id]
+	if !exists || record.deletedAt != nil {
+		return ErrUserNotFound
+	}
+	if record.user.Version != user.Version {
+		return ErrVersionConflict
+	}
+	if r.emailTaken(user.Email, id) {
+		return ErrEmailAlreadyExists
+	}
+
+	record.user.Name = user.Name
+	record.user.Email = user.Email
+	record.user.Age = user.Age
+	record.user.Version++
+	record.user.UpdatedAt = r.now()
+	user.Version = record.user.Version
+	user.CreatedAt = record.user.CreatedAt
+	user.UpdatedAt = record.user.UpdatedAt
+	return nil
+}
+
+// emailTaken indica si otro usuario distinto de exceptID usa el email, sin distinguir mayúsculas.
+// Debe llamarse con el lock tomado.
+func (r *MemoryUserRepository) emailTaken(email, exceptID string) bool {
+	for id, record := range r.records {
+		if id != exceptID && strings.EqualFold(record.user.Email, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delete elimina lógicamente un usuario marcando su fecha de eliminación.
+// Si version no es models.AnyVersion, solo elimina si coincide con la versión almacenada.
+func (r *MemoryUserRepository) Delete(ctx context.Context, id string, version int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.deleteLocked(id, version)
+}
+
+// deleteLocked aplica Delete; debe llamarse con el lock tomado
+func (r *MemoryUserRepository) deleteLocked(id string, version int64) error {
+	record, exists := r.records[id]
+	if !exists || record.deletedAt != nil {
+		return ErrUserNotFound
+	}
+	if version != models.AnyVersion && record.user.Version != version {
+		return ErrVersionConflict
+	}
+
+	deletedAt := r.now()
+	record.deletedAt = &deletedAt
+	record.user.Version++
+	record.user.UpdatedAt = deletedAt
+	return nil
+}
+
+// Restore revierte la eliminación lógica de un usuario
+func (r *MemoryUserRepository) Restore(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	record, exists := r.records[id]
+	if !exists || record.deletedAt == nil {
+		return ErrUserNotFound
+	}
+
+	record.deletedAt = nil
+	record.user.Version++
+	record.user.UpdatedAt = r.now()
+	return nil
+}
+
+// Purge elimina definitivamente los usuarios eliminados antes de deletedBefore
+func (r *MemoryUserRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for id, record := range r.records {
+		if record.deletedAt != nil && record.deletedAt.Before(deletedBefore) {
+			delete(r.records, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// CreateBatch guarda todos los usuarios, o ninguno si algún email ya está en uso o se repite en el lote
+func (r *MemoryUserRepository) CreateBatch(ctx context.Context, users []*models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	emails := make(map[string]bool, len(users))
+	for _, user := range users {
+		email := strings.ToLower(user.Email)
+		if emails[email] || r.emailTaken(user.Email, "") {
+			return ErrEmailAlreadyExists
+		}
+		emails[email] = true
+	}
+
+	now := r.now()
+	for _, user := range users {
+		user.Version = 1
+		user.CreatedAt = now
+		user.UpdatedAt = now
+		r.records[user.ID] = &memoryRecord{user: *user}
+	}
+	return nil
+}
+
+// UpdateBatch actualiza todos los usuarios, o ninguno si alguno falla
+func (r *MemoryUserRepository) UpdateBatch(ctx context.Context, users []*models.User) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]string, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return r.applyBatchLocked(ids, func(i int) error {
+		return r.updateLocked(users[i].ID, users[i])
+	})
+}
+
+// DeleteBatch elimina lógicamente todos los usuarios, o ninguno si alguno falla
+func (r *MemoryUserRepository) DeleteBatch(ctx context.Context, refs []models.UserRef) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		ids = append(ids, ref.ID)
+	}
+	return r.applyBatchLocked(ids, func(i int) error {
+		return r.deleteLocked(refs[i].ID, refs[i].Version)
+	})
+}
+
+// applyBatchLocked ejecuta apply sobre cada elemento del lote, cuyo usuario es ids[i]. Guarda una
+// copia de cada registro antes de modificarlo para restaurarlos si algún elemento falla.
+// Debe llamarse con el lock tomado.
+func (r *MemoryUserRepository) applyBatchLocked(ids []string, apply func(i int) error) error {
+	originals := make(map[string]memoryRecord)
+	for i, id := range ids {
+		if record, exists := r.records[id]; exists {
+			if _, saved := originals[id]; !saved {
+				originals[id] = *record
+			}
+		}
+		if err := apply(i); err != nil {
+			for id, original := range originals {
+				*r.records[id] = original
+			}
+			return &BatchError{Index: i, Err: err}
+		}
+	}
+	return nil
+}
+
+// Stream recorre los usuarios que cumplen filter en el orden sort. Trabaja sobre una copia tomada
+// al inicio, por lo que fn puede usar el repositorio y no ve las escrituras posteriores.
+func (r *MemoryUserRepository) Stream(ctx context.Context, filter models.UserFilter, order models.UserSort, fn func(*models.User) error) error {
+	if order.Field == "" {
+		order = models.DefaultUserSort
+	}
+	if !order.Field.Valid() {
+		return models.ErrInvalidSort
+	}
+
+	r.mu.RLock()
+	records := make([]memoryRecord, 0, len(r.records))
+	for _, record := range r.records {
+		if matchesFilter(record, filter) {
+			records = append(records, *record)
+		}
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		return recordBefore(records[i], records[j], order)
+	})
+
+	for _, record := range records {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(record.toUser()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
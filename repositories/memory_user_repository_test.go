@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"helloworld/models"
+)
+
+func TestMemoryUserRepository_CRUD(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryUserRepository()
+
+	user := &models.User{ID: "1", Name: "Juan Pérez", Email: "juan@example.com", Age: 30}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Modificar el puntero original no debe afectar lo almacenado
+	user.Name = "Modificado"
+	got, err := repo.GetByID(ctx, "1")
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if got.Name != "Juan Pérez" {
+		t.Errorf("GetByID() nombre = %v, esperaba %v", got.Name, "Juan Pérez")
+	}
+
+	got.Age = 31
+	if err := repo.Update(ctx, "1", got); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	updated, _ := repo.GetByID(ctx, "1")
+	if updated.Age != 31 {
+		t.Errorf("Update() edad = %v, esperaba %v", updated.Age, 31)
+	}
+
+	if err := repo.Delete(ctx, "1", models.AnyVersion); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := repo.GetByID(ctx, "1"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("GetByID() después de Delete error = %v, esperaba %v", err, ErrUserNotFound)
+	}
+}
+
+func TestMemoryUserRepository_NotFound(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryUserRepository()
+
+	if err := repo.Update(ctx, "inexistente", &models.User{}); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Update() error = %v, esperaba %v", err, ErrUserNotFound)
+	}
+	if err := repo.Delete(ctx, "inexistente", models.AnyVersion); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("Delete() error = %v, esperaba %v", err, ErrUserNotFound)
+	}
+}
+
+func TestMemoryUserRepository_GetAllOrder(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryUserRepository()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	times := []time.Time{base, base.Add(time.Second), base.Add(time.Second)}
+	i := 0
+	repo.now = func() time.Time {
+		now := times[i]
+		i++
+		return now
+	}
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := repo.Create(ctx, &models.User{ID: id, Email: id + "@example.com"}); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	page, err := repo.GetAll(ctx, models.UserQuery{})
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+
+	want := []string{"c", "b", "a"}
+	for i, user := range page.Users {
+		if user.ID != want[i] {
+			t.Errorf("GetAll()[%d] = %v, esperaba %v", i, user.ID, want[i])
+		}
+	}
+}
+
+func TestMemoryUserRepository_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryUserRepository()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := fmt.Sprintf("user-%d", i)
+			_ = repo.Create(ctx, &models.User{ID: id, Email: id + "@example.com"})
+			_, _ = repo.GetByID(ctx, id)
+			_, _ = repo.GetAll(ctx, models.UserQuery{})
+		}(i)
+	}
+	wg.Wait()
+
+	page, _ := repo.GetAll(ctx, models.UserQuery{Limit: models.MaxPageLimit})
+	if page.Total != 50 {
+		t.Errorf("GetAll() total = %d, esperaba %d", page.Total, 50)
+	}
+}
+
+func TestMemoryUserRepository_CanceledContext(t *testing.T) {
+	repo := NewMemoryUserRepository()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := repo.Create(ctx, &models.User{ID: "1"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Create() error = %v, esperaba %v", err, context.Canceled)
+	}
+	if _, err := repo.GetAll(ctx, models.UserQuery{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetAll() error = %v, esperaba %v", err, context.Canceled)
+	}
+}
@@ -1,57 +1,56 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"helloworld/models"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 )
 
+// mysqlListDialect usa marcadores "?" y valores time.Time nativos
+var mysqlListDialect = sqlDialect{
+	placeholder:      questionPlaceholders,
+	timeArg:          nativeTimeArg,
+	isDuplicateEmail: isMySQLDuplicateEmail,
+	// La colación utf8mb4_unicode_ci ya ignora mayúsculas: LOWER impediría usar uq_users_email
+	emailKey: "email",
+}
+
 // MySQLUserRepository implementa UserRepository usando MySQL
 type MySQLUserRepository struct {
-	db *sql.DB
+	db *tracedDB
 }
 
-// NewMySQLUserRepository crea una nueva instancia del repositorio MySQL
-func NewMySQLUserRepository(dsn string) (*MySQLUserRepository, error) {
+// NewMySQLUserRepository crea una nueva instancia del repositorio MySQL con un pool de hasta
+// maxOpenConns conexiones (0 = sin límite)
+func NewMySQLUserRepository(dsn string, maxOpenConns int) (*MySQLUserRepository, error) {
 	db, err := sql.Open("mysql", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("error al abrir conexión a MySQL: %w", err)
 	}
+	db.SetMaxOpenConns(maxOpenConns)
 
 	// Verificar la conexión
 	if err := db.Ping(); err != nil {
+		_ = db.Close() // nolint:errcheck // El error original es más relevante
 		return nil, fmt.Errorf("error al conectar con MySQL: %w", err)
 	}
 
-	// Crear la tabla si no existe
-	if err := createTableIfNotExists(db); err != nil {
-		return nil, fmt.Errorf("error al crear tabla: %w", err)
-	}
-
 	return &MySQLUserRepository{
-		db: db,
+		db: newTracedDB(db, semconv.DBSystemMySQL),
 	}, nil
 }
 
-// createTableIfNotExists crea la tabla de usuarios si no existe
-func createTableIfNotExists(db *sql.DB) error {
-	query := `
-	CREATE TABLE IF NOT EXISTS users (
-		id VARCHAR(36) PRIMARY KEY,
-		name VARCHAR(255) NOT NULL,
-		email VARCHAR(255) NOT NULL,
-		age INT NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-		INDEX idx_email (email)
-	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
-	`
-
-	_, err := db.Exec(query)
-	return err
+// DB retorna la conexión subyacente, usada por el subsistema de migraciones
+func (r *MySQLUserRepository) DB() *sql.DB {
+	return r.db.DB
 }
 
 // Close cierra la conexión a la base de datos
@@ -60,63 +59,70 @@ func (r *MySQLUserRepository) Close() error {
 }
 
 // Create guarda un nuevo usuario
-func (r *MySQLUserRepository) Create(user *models.User) error {
+func (r *MySQLUserRepository) Create(ctx context.Context, user *models.User) error {
 	query := "INSERT INTO users (id, name, email, age) VALUES (?, ?, ?, ?)"
-	_, err := r.db.Exec(query, user.ID, user.Name, user.Email, user.Age)
+	_, err := r.db.ExecContext(ctx, query, user.ID, user.Name, user.Email, user.Age)
 	if err != nil {
+		if isMySQLDuplicateEmail(err) {
+			return ErrEmailAlreadyExists
+		}
 		return fmt.Errorf("error al crear usuario: %w", err)
 	}
-	return nil
+	user.Version = 1
+	return loadTimestamps(ctx, r.db, mysqlListDialect, user.ID, user)
 }
 
 // GetByID obtiene un usuario por su ID
-func (r *MySQLUserRepository) GetByID(id string) (*models.User, error) {
-	query := "SELECT id, name, email, age FROM users WHERE id = ?"
-	row := r.db.QueryRow(query, id)
+func (r *MySQLUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	return getUser(ctx, r.db, mysqlListDialect, id, nil)
+}
 
-	var user models.User
-	err := row.Scan(&user.ID, &user.Name, &user.Email, &user.Age)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, ErrUserNotFound
-		}
-		return nil, fmt.Errorf("error al obtener usuario: %w", err)
-	}
+// GetFieldsByID obtiene un usuario por su ID leyendo solo las columnas de fields
+func (r *MySQLUserRepository) GetFieldsByID(ctx context.Context, id string, fields models.UserFields) (*models.User, error) {
+	return getUser(ctx, r.db, mysqlListDialect, id, fields)
+}
 
-	return &user, nil
+// GetAll obtiene una página de usuarios filtrada y ordenada según query (por defecto, models.DefaultUserSort)
+func (r *MySQLUserRepository) GetAll(ctx context.Context, query models.UserQuery) (*models.UserPage, error) {
+	return listUsers(ctx, r.db, mysqlListDialect, query)
 }
 
-// GetAll obtiene todos los usuarios
-func (r *MySQLUserRepository) GetAll() ([]*models.User, error) {
-	query := "SELECT id, name, email, age FROM users ORDER BY created_at DESC"
-	rows, err := r.db.Query(query)
+// Update actualiza un usuario existente si user.Version coincide con la versión almacenada
+func (r *MySQLUserRepository) Update(ctx context.Context, id string, user *models.User) error {
+	query := "UPDATE users SET name = ?, email = ?, age = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL AND version = ?"
+	result, err := r.db.ExecContext(ctx, query, user.Name, user.Email, user.Age, id, user.Version)
 	if err != nil {
-		return nil, fmt.Errorf("error al obtener usuarios: %w", err)
+		if isMySQLDuplicateEmail(err) {
+			return ErrEmailAlreadyExists
+		}
+		return fmt.Errorf("error al actualizar usuario: %w", err)
 	}
-	defer rows.Close()
 
-	users := make([]*models.User, 0)
-	for rows.Next() {
-		var user models.User
-		if err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.Age); err != nil {
-			return nil, fmt.Errorf("error al escanear usuario: %w", err)
-		}
-		users = append(users, &user)
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al verificar filas afectadas: %w", err)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("error al iterar usuarios: %w", err)
+	if rowsAffected == 0 {
+		return writeConflict(ctx, r.db, mysqlListDialect, id)
 	}
 
-	return users, nil
+	user.Version++
+	return loadTimestamps(ctx, r.db, mysqlListDialect, id, user)
 }
 
-// Update actualiza un usuario existente
-func (r *MySQLUserRepository) Update(id string, user *models.User) error {
-	query := "UPDATE users SET name = ?, email = ?, age = ? WHERE id = ?"
-	result, err := r.db.Exec(query, user.Name, user.Email, user.Age, id)
+// Delete elimina lógicamente un usuario marcando deleted_at.
+// Si version no es models.AnyVersion, solo elimina si coincide con la versión almacenada.
+func (r *MySQLUserRepository) Delete(ctx context.Context, id string, version int64) error {
+	query := "UPDATE users SET deleted_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = ? AND deleted_at IS NULL"
+	args := []interface{}{id}
+	if version != models.AnyVersion {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
 	if err != nil {
-		return fmt.Errorf("error al actualizar usuario: %w", err)
+		return fmt.Errorf("error al eliminar usuario: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -125,18 +131,18 @@ func (r *MySQLUserRepository) Update(id string, user *models.User) error {
 	}
 
 	if rowsAffected == 0 {
-		return ErrUserNotFound
+		return writeConflict(ctx, r.db, mysqlListDialect, id)
 	}
 
 	return nil
 }
 
-// Delete elimina un usuario
-func (r *MySQLUserRepository) Delete(id string) error {
-	query := "DELETE FROM users WHERE id = ?"
-	result, err := r.db.Exec(query, id)
+// Restore revierte la eliminación lógica de un usuario
+func (r *MySQLUserRepository) Restore(ctx context.Context, id string) error {
+	query := "UPDATE users SET deleted_at = NULL, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL"
+	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
-		return fmt.Errorf("error al eliminar usuario: %w", err)
+		return fmt.Errorf("error al restaurar usuario: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
@@ -150,3 +156,46 @@ func (r *MySQLUserRepository) Delete(id string) error {
 
 	return nil
 }
+
+// Purge elimina definitivamente los usuarios eliminados antes de deletedBefore
+func (r *MySQLUserRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	query := "DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ?"
+	result, err := r.db.ExecContext(ctx, query, mysqlListDialect.timeArg(deletedBefore))
+	if err != nil {
+		return 0, fmt.Errorf("error al purgar usuarios: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error al verificar filas afectadas: %w", err)
+	}
+
+	return purged, nil
+}
+
+// CreateBatch guarda todos los usuarios con INSERT multi-fila, o ninguno si alguno falla
+func (r *MySQLUserRepository) CreateBatch(ctx context.Context, users []*models.User) error {
+	return createUsersBatch(ctx, r.db, mysqlListDialect, users)
+}
+
+// UpdateBatch actualiza todos los usuarios en una transacción, o ninguno si alguno falla
+func (r *MySQLUserRepository) UpdateBatch(ctx context.Context, users []*models.User) error {
+	return updateUsersBatch(ctx, r.db, mysqlListDialect, users)
+}
+
+// DeleteBatch elimina lógicamente todos los usuarios en una transacción, o ninguno si alguno falla
+func (r *MySQLUserRepository) DeleteBatch(ctx context.Context, refs []models.UserRef) error {
+	return deleteUsersBatch(ctx, r.db, mysqlListDialect, refs)
+}
+
+// Stream recorre los usuarios que cumplen filter a medida que se leen de la base de datos
+func (r *MySQLUserRepository) Stream(ctx context.Context, filter models.UserFilter, sort models.UserSort, fn func(*models.User) error) error {
+	return streamUsers(ctx, r.db, mysqlListDialect, filter, sort, fn)
+}
+
+// isMySQLDuplicateEmail indica si err es una violación del índice único de email
+// (ER_DUP_ENTRY sobre uq_users_email, no sobre la clave primaria)
+func isMySQLDuplicateEmail(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 && strings.Contains(mysqlErr.Message, uniqueEmailIndex)
+}
@@ -0,0 +1,198 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"helloworld/models"
+
+	"github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// postgresListDialect usa marcadores "$n" y valores time.Time nativos
+var postgresListDialect = sqlDialect{
+	placeholder:      dollarPlaceholders,
+	timeArg:          nativeTimeArg,
+	isDuplicateEmail: isPostgresDuplicateEmail,
+	emailKey:         "LOWER(email)",
+}
+
+// PostgresUserRepository implementa UserRepository usando PostgreSQL
+type PostgresUserRepository struct {
+	db *tracedDB
+}
+
+// NewPostgresUserRepository crea una nueva instancia del repositorio PostgreSQL con un pool de hasta
+// maxOpenConns conexiones (0 = sin límite)
+func NewPostgresUserRepository(dsn string, maxOpenConns int) (*PostgresUserRepository, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir conexión a PostgreSQL: %w", err)
+	}
+	db.SetMaxOpenConns(maxOpenConns)
+
+	// Verificar la conexión
+	if err := db.Ping(); err != nil {
+		_ = db.Close() // nolint:errcheck // El error original es más relevante
+		return nil, fmt.Errorf("error al conectar con PostgreSQL: %w", err)
+	}
+
+	return &PostgresUserRepository{
+		db: newTracedDB(db, semconv.DBSystemPostgreSQL),
+	}, nil
+}
+
+// DB retorna la conexión subyacente, usada por el subsistema de migraciones
+func (r *PostgresUserRepository) DB() *sql.DB {
+	return r.db.DB
+}
+
+// Close cierra la conexión a la base de datos
+func (r *PostgresUserRepository) Close() error {
+	return r.db.Close()
+}
+
+// Create guarda un nuevo usuario
+func (r *PostgresUserRepository) Create(ctx context.Context, user *models.User) error {
+	query := "INSERT INTO users (id, name, email, age) VALUES ($1, $2, $3, $4)"
+	_, err := r.db.ExecContext(ctx, query, user.ID, user.Name, user.Email, user.Age)
+	if err != nil {
+		if isPostgresDuplicateEmail(err) {
+			return ErrEmailAlreadyExists
+		}
+		return fmt.Errorf("error al crear usuario: %w", err)
+	}
+	user.Version = 1
+	return loadTimestamps(ctx, r.db, postgresListDialect, user.ID, user)
+}
+
+// GetByID obtiene un usuario por su ID
+func (r *PostgresUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	return getUser(ctx, r.db, postgresListDialect, id, nil)
+}
+
+// GetFieldsByID obtiene un usuario por su ID leyendo solo las columnas de fields
+func (r *PostgresUserRepository) GetFieldsByID(ctx context.Context, id string, fields models.UserFields) (*models.User, error) {
+	return getUser(ctx, r.db, postgresListDialect, id, fields)
+}
+
+// GetAll obtiene una página de usuarios filtrada y ordenada según query (por defecto, models.DefaultUserSort)
+func (r *PostgresUserRepository) GetAll(ctx context.Context, query models.UserQuery) (*models.UserPage, error) {
+	return listUsers(ctx, r.db, postgresListDialect, query)
+}
+
+// Update actualiza un usuario existente si user.Version coincide con la versión almacenada
+func (r *PostgresUserRepository) Update(ctx context.Context, id string, user *models.User) error {
+	query := "UPDATE users SET name = $1, email = $2, age = $3, version = version + 1 WHERE id = $4 AND deleted_at IS NULL AND version = $5"
+	result, err := r.db.ExecContext(ctx, query, user.Name, user.Email, user.Age, id, user.Version)
+	if err != nil {
+		if isPostgresDuplicateEmail(err) {
+			return ErrEmailAlreadyExists
+		}
+		return fmt.Errorf("error al actualizar usuario: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al verificar filas afectadas: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return writeConflict(ctx, r.db, postgresListDialect, id)
+	}
+
+	user.Version++
+	return loadTimestamps(ctx, r.db, postgresListDialect, id, user)
+}
+
+// Delete elimina lógicamente un usuario marcando deleted_at.
+// Si version no es models.AnyVersion, solo elimina si coincide con la versión almacenada.
+func (r *PostgresUserRepository) Delete(ctx context.Context, id string, version int64) error {
+	query := "UPDATE users SET deleted_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = $1 AND deleted_at IS NULL"
+	args := []interface{}{id}
+	if version != models.AnyVersion {
+		query += " AND version = $2"
+		args = append(args, version)
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error al eliminar usuario: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al verificar filas afectadas: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return writeConflict(ctx, r.db, postgresListDialect, id)
+	}
+
+	return nil
+}
+
+// Restore revierte la eliminación lógica de un usuario
+func (r *PostgresUserRepository) Restore(ctx context.Context, id string) error {
+	query := "UPDATE users SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("error al restaurar usuario: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al verificar filas afectadas: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// Purge elimina definitivamente los usuarios eliminados antes de deletedBefore
+func (r *PostgresUserRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	query := "DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < $1"
+	result, err := r.db.ExecContext(ctx, query, postgresListDialect.timeArg(deletedBefore))
+	if err != nil {
+		return 0, fmt.Errorf("error al purgar usuarios: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error al verificar filas afectadas: %w", err)
+	}
+
+	return purged, nil
+}
+
+// CreateBatch guarda todos los usuarios con INSERT multi-fila, o ninguno si alguno falla
+func (r *PostgresUserRepository) CreateBatch(ctx context.Context, users []*models.User) error {
+	return createUsersBatch(ctx, r.db, postgresListDialect, users)
+}
+
+// UpdateBatch actualiza todos los usuarios en una transacción, o ninguno si alguno falla
+func (r *PostgresUserRepository) UpdateBatch(ctx context.Context, users []*models.User) error {
+	return updateUsersBatch(ctx, r.db, postgresListDialect, users)
+}
+
+// DeleteBatch elimina lógicamente todos los usuarios en una transacción, o ninguno si alguno falla
+func (r *PostgresUserRepository) DeleteBatch(ctx context.Context, refs []models.UserRef) error {
+	return deleteUsersBatch(ctx, r.db, postgresListDialect, refs)
+}
+
+// Stream recorre los usuarios que cumplen filter a medida que se leen de la base de datos
+func (r *PostgresUserRepository) Stream(ctx context.Context, filter models.UserFilter, sort models.UserSort, fn func(*models.User) error) error {
+	return streamUsers(ctx, r.db, postgresListDialect, filter, sort, fn)
+}
+
+// isPostgresDuplicateEmail indica si err es una violación del índice único de email
+func isPostgresDuplicateEmail(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == uniqueEmailIndex
+}
@@ -0,0 +1,166 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"helloworld/models"
+)
+
+// batchInsertRows es la cantidad de filas por sentencia INSERT; mantiene la cantidad de
+// parámetros por debajo del límite de todos los motores soportados
+const batchInsertRows = 500
+
+// createUsersBatch inserta users con sentencias INSERT multi-fila dentro de una transacción
+func createUsersBatch(ctx context.Context, db *tracedDB, d sqlDialect, users []*models.User) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error al iniciar transacción: %w", err)
+	}
+	defer tx.Rollback() // nolint:errcheck // Sin efecto tras Commit
+
+	for start := 0; start < len(users); start += batchInsertRows {
+		end := start + batchInsertRows
+		if end > len(users) {
+			end = len(users)
+		}
+		chunk := users[start:end]
+
+		args := &sqlArgs{dialect: d}
+		rows := make([]string, 0, len(chunk))
+		for _, user := range chunk {
+			rows = append(rows, fmt.Sprintf("(%s, %s, %s, %s)", args.add(user.ID), args.add(user.Name), args.add(user.Email), args.add(user.Age)))
+		}
+		query := "INSERT INTO users (id, name, email, age) VALUES " + strings.Join(rows, ", ")
+		if _, err := tx.ExecContext(ctx, query, args.values...); err != nil {
+			if d.isDuplicateEmail(err) {
+				return ErrEmailAlreadyExists
+			}
+			return fmt.Errorf("error al crear usuarios: %w", err)
+		}
+
+		if err := loadBatchTimestamps(ctx, tx, d, chunk); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error al confirmar transacción: %w", err)
+	}
+	for _, user := range users {
+		user.Version = 1
+	}
+	return nil
+}
+
+// loadBatchTimestamps completa las fechas asignadas a users con una única consulta
+func loadBatchTimestamps(ctx context.Context, db sqlQuerier, d sqlDialect, users []*models.User) error {
+	args := &sqlArgs{dialect: d}
+	byID := make(map[string]*models.User, len(users))
+	placeholders := make([]string, 0, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+		placeholders = append(placeholders, args.add(user.ID))
+	}
+
+	query := "SELECT id, created_at, updated_at FROM users WHERE id IN (" + strings.Join(placeholders, ", ") + ")"
+	rows, err := db.QueryContext(ctx, query, args.values...)
+	if err != nil {
+		return fmt.Errorf("error al obtener fechas de los usuarios: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var user models.User
+		if err := rows.Scan(&id, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return fmt.Errorf("error al obtener fechas de los usuarios: %w", err)
+		}
+		if target, ok := byID[id]; ok {
+			target.CreatedAt = user.CreatedAt
+			target.UpdatedAt = user.UpdatedAt
+		}
+	}
+	return rows.Err()
+}
+
+// updateUsersBatch aplica la actualización condicional de cada usuario dentro de una transacción.
+// Ante el primer error revierte todo y retorna *BatchError con el índice del usuario.
+func updateUsersBatch(ctx context.Context, db *tracedDB, d sqlDialect, users []*models.User) error {
+	query := fmt.Sprintf("UPDATE users SET name = %s, email = %s, age = %s, version = version + 1 WHERE id = %s AND deleted_at IS NULL AND version = %s",
+		d.placeholder(1), d.placeholder(2), d.placeholder(3), d.placeholder(4), d.placeholder(5))
+
+	return inBatchTx(ctx, db, len(users), func(tx *tracedTx, i int) error {
+		user := users[i]
+		result, err := tx.ExecContext(ctx, query, user.Name, user.Email, user.Age, user.ID, user.Version)
+		if err != nil {
+			if d.isDuplicateEmail(err) {
+				return ErrEmailAlreadyExists
+			}
+			return fmt.Errorf("error al actualizar usuario: %w", err)
+		}
+		if err := requireAffected(ctx, tx, d, result, user.ID); err != nil {
+			return err
+		}
+		user.Version++
+		return loadTimestamps(ctx, tx, d, user.ID, user)
+	})
+}
+
+// deleteUsersBatch elimina lógicamente cada usuario dentro de una transacción, verificando su
+// versión salvo que sea models.AnyVersion. Ante el primer error revierte todo y retorna *BatchError.
+func deleteUsersBatch(ctx context.Context, db *tracedDB, d sqlDialect, refs []models.UserRef) error {
+	query := fmt.Sprintf("UPDATE users SET deleted_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = %s AND deleted_at IS NULL", d.placeholder(1))
+	versionedQuery := query + " AND version = " + d.placeholder(2)
+
+	return inBatchTx(ctx, db, len(refs), func(tx *tracedTx, i int) error {
+		ref := refs[i]
+		var (
+			result sql.Result
+			err    error
+		)
+		if ref.Version == models.AnyVersion {
+			result, err = tx.ExecContext(ctx, query, ref.ID)
+		} else {
+			result, err = tx.ExecContext(ctx, versionedQuery, ref.ID, ref.Version)
+		}
+		if err != nil {
+			return fmt.Errorf("error al eliminar usuario: %w", err)
+		}
+		return requireAffected(ctx, tx, d, result, ref.ID)
+	})
+}
+
+// inBatchTx ejecuta apply para cada índice en una transacción y la confirma solo si todos tuvieron éxito
+func inBatchTx(ctx context.Context, db *tracedDB, n int, apply func(tx *tracedTx, i int) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error al iniciar transacción: %w", err)
+	}
+	defer tx.Rollback() // nolint:errcheck // Sin efecto tras Commit
+
+	for i := 0; i < n; i++ {
+		if err := apply(tx, i); err != nil {
+			return &BatchError{Index: i, Err: err}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error al confirmar transacción: %w", err)
+	}
+	return nil
+}
+
+// requireAffected retorna el motivo por el que una escritura condicional sobre id no afectó filas
+func requireAffected(ctx context.Context, db sqlQuerier, d sqlDialect, result sql.Result, id string) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al verificar filas afectadas: %w", err)
+	}
+	if rowsAffected == 0 {
+		return writeConflict(ctx, db, d, id)
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"helloworld/models"
+)
+
+// sqlDialect describe cómo difieren los motores SQL en las consultas compartidas entre repositorios
+type sqlDialect struct {
+	// placeholder retorna el marcador del parámetro n (empezando en 1)
+	placeholder func(n int) string
+	// timeArg convierte un instante al valor que el motor compara correctamente con las columnas de fecha
+	timeArg func(t time.Time) interface{}
+	// isDuplicateEmail indica si un error del driver es una violación del índice único de email
+	isDuplicateEmail func(err error) bool
+	// emailKey es la expresión del índice único de email, que compara sin distinguir mayúsculas;
+	// los filtros por email la usan para que la búsqueda aproveche el índice
+	emailKey string
+}
+
+// sqlQuerier es la parte común de *sql.DB y *sql.Tx usada por las consultas compartidas
+type sqlQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+var (
+	questionPlaceholders = func(int) string { return "?" }
+	dollarPlaceholders   = func(n int) string { return fmt.Sprintf("$%d", n) }
+	nativeTimeArg        = func(t time.Time) interface{} { return t }
+)
+
+// sortColumns traduce cada campo de orden a su columna; es la única fuente de
+// identificadores interpolados en el SQL, el resto de los valores van como parámetros
+var sortColumns = map[models.SortField]string{
+	models.SortByName:      "name",
+	models.SortByEmail:     "email",
+	models.SortByAge:       "age",
+	models.SortByCreatedAt: "created_at",
+	models.SortByUpdatedAt: "updated_at",
+}
+
+// userFieldColumns traduce cada campo de models.User a su columna; como sortColumns, es una
+// fuente fija de identificadores para las consultas que seleccionan solo algunos campos
+var userFieldColumns = map[models.UserField]string{
+	models.UserFieldID:        "id",
+	models.UserFieldName:      "name",
+	models.UserFieldEmail:     "email",
+	models.UserFieldAge:       "age",
+	models.UserFieldVersion:   "version",
+	models.UserFieldCreatedAt: "created_at",
+	models.UserFieldUpdatedAt: "updated_at",
+	models.UserFieldDeletedAt: "deleted_at",
+}
+
+// rowScanner es la parte común de *sql.Row y *sql.Rows usada por scanUser
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// likeEscaper escapa los comodines de LIKE usando "!" como carácter de escape,
+// que no requiere tratamiento especial en ningún dialecto
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// sqlArgs acumula parámetros y genera sus marcadores según el dialecto
+type sqlArgs struct {
+	dialect sqlDialect
+	values  []interface{}
+}
+
+// add agrega un parámetro y retorna su marcador
+func (a *sqlArgs) add(value interface{}) string {
+	if t, ok := value.(time.Time); ok {
+		value = a.dialect.timeArg(t)
+	}
+	a.values = append(a.values, value)
+	return a.dialect.placeholder(len(a.values))
+}
+
+// filterConditions traduce el filtro a condiciones SQL parametrizadas
+func filterConditions(filter models.UserFilter, args *sqlArgs) []string {
+	conditions := make([]string, 0, 8)
+
+	if !filter.IncludeDeleted {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+
+	if filter.NamePrefix != "" {
+		pattern := likeEscaper.Replace(strings.ToLower(filter.NamePrefix)) + "%"
+		conditions = append(conditions, fmt.Sprintf("LOWER(name) LIKE %s ESCAPE '!'", args.add(pattern)))
+	}
+	if filter.Email != "" {
+		conditions = append(conditions, fmt.Sprintf("%s = %s", args.dialect.emailKey, args.add(strings.ToLower(filter.Email))))
+	}
+	if len(filter.Emails) > 0 {
+		placeholders := make([]string, len(filter.Emails))
+		for i, email := range filter.Emails {
+			placeholders[i] = args.add(strings.ToLower(email))
+		}
+		conditions = append(conditions, fmt.Sprintf("%s IN (%s)", args.dialect.emailKey, strings.Join(placeholders, ", ")))
+	}
+	if filter.MinAge != nil {
+		conditions = append(conditions, fmt.Sprintf("age >= %s", args.add(*filter.MinAge)))
+	}
+	if filter.MaxAge != nil {
+		conditions = append(conditions, fmt.Sprintf("age <= %s", args.add(*filter.MaxAge)))
+	}
+	if filter.CreatedAfter != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at >= %s", args.add(*filter.CreatedAfter)))
+	}
+	if filter.CreatedBefore != nil {
+		conditions = append(conditions, fmt.Sprintf("created_at < %s", args.add(*filter.CreatedBefore)))
+	}
+	if filter.UpdatedSince != nil {
+		conditions = append(conditions, fmt.Sprintf("updated_at >= %s", args.add(*filter.UpdatedSince)))
+	}
+
+	return conditions
+}
+
+// whereClause une las condiciones con AND
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// listUsers ejecuta el listado filtrado, ordenado y paginado de usuarios
+func listUsers(ctx context.Context, db *tracedDB, d sqlDialect, query models.UserQuery) (*models.UserPage, error) {
+	if query.Limit <= 0 {
+		query.Limit = models.DefaultPageLimit
+	}
+	if query.Sort.Field == "" {
+		query.Sort = models.DefaultUserSort
+	}
+	column, ok := sortColumns[query.Sort.Field]
+	if !ok {
+		return nil, models.ErrInvalidSort
+	}
+
+	var total int
+	if !query.SkipTotal {
+		countArgs := &sqlArgs{dialect: d}
+		countQuery := "SELECT COUNT(*) FROM users" + whereClause(filterConditions(query.Filter, countArgs))
+		if err := db.QueryRowContext(ctx, countQuery, countArgs.values...).Scan(&total); err != nil {
+			return nil, fmt.Errorf("error al contar usuarios: %w", err)
+		}
+	}
+
+	args := &sqlArgs{dialect: d}
+	conditions := filterConditions(query.Filter, args)
+
+	direction, comparison := "ASC", ">"
+	if query.Sort.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if query.Cursor != nil {
+		value, err := query.Cursor.SortValue(query.Sort.Field)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, fmt.Sprintf("(%s %s %s OR (%s = %s AND id %s %s))",
+			column, comparison, args.add(value), column, args.add(value), comparison, args.add(query.Cursor.ID)))
+	}
+
+	// El cursor de la página siguiente necesita el id y el campo de orden aunque no se hayan seleccionado
+	fields := query.Fields.With(models.UserFieldID, models.UserField(query.Sort.Field))
+
+	var b strings.Builder
+	b.WriteString(selectUsers(fields))
+	b.WriteString(whereClause(conditions))
+	// Se pide una fila extra para saber si existe una página siguiente
+	fmt.Fprintf(&b, " ORDER BY %s %s, id %s LIMIT %s", column, direction, direction, args.add(query.Limit+1))
+	if query.Cursor == nil {
+		fmt.Fprintf(&b, " OFFSET %s", args.add(query.Offset))
+	}
+
+	rows, err := db.QueryContext(ctx, b.String(), args.values...)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener usuarios: %w", err)
+	}
+	defer rows.Close()
+
+	page := &models.UserPage{
+		Users: make([]*models.User, 0, query.Limit),
+		Total: total,
+	}
+	for rows.Next() {
+		user, err := scanUser(rows, fields)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(page.Users) == query.Limit {
+			page.NextCursor = models.NewCursor(query.Sort, page.Users[len(page.Users)-1])
+			break
+		}
+		page.Users = append(page.Users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error al iterar usuarios: %w", err)
+	}
+
+	return page, nil
+}
+
+// streamUsers recorre los usuarios que cumplen filter en el orden sort, llamando a fn con cada uno
+// a medida que se leen de la base de datos. Un error de fn detiene el recorrido y se retorna sin envolver.
+func streamUsers(ctx context.Context, db *tracedDB, d sqlDialect, filter models.UserFilter, sort models.UserSort, fn func(*models.User) error) error {
+	if sort.Field == "" {
+		sort = models.DefaultUserSort
+	}
+	column, ok := sortColumns[sort.Field]
+	if !ok {
+		return models.ErrInvalidSort
+	}
+	direction := "ASC"
+	if sort.Desc {
+		direction = "DESC"
+	}
+
+	args := &sqlArgs{dialect: d}
+	query := selectUsers(nil) + whereClause(filterConditions(filter, args)) +
+		fmt.Sprintf(" ORDER BY %s %s, id %s", column, direction, direction)
+
+	rows, err := db.QueryContext(ctx, query, args.values...)
+	if err != nil {
+		return fmt.Errorf("error al obtener usuarios: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		user, err := scanUser(rows, nil)
+		if err != nil {
+			return err
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error al iterar usuarios: %w", err)
+	}
+	return nil
+}
+
+// streamUsersPaged recorre los usuarios como streamUsers, pero leyendo páginas de pageSize por
+// cursor keyset: la conexión se libera entre páginas mientras fn escribe la respuesta. A
+// diferencia de streamUsers no es una lectura consistente: un usuario modificado durante el
+// recorrido puede aparecer en otra posición u omitirse si cambia el campo de orden.
+func streamUsersPaged(ctx context.Context, db *tracedDB, d sqlDialect, filter models.UserFilter, sort models.UserSort, pageSize int, fn func(*models.User) error) error {
+	query := models.UserQuery{Filter: filter, Sort: sort, Limit: pageSize, SkipTotal: true}
+	for {
+		page, err := listUsers(ctx, db, d, query)
+		if err != nil {
+			return err
+		}
+		for _, user := range page.Users {
+			if err := fn(user); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == nil {
+			return nil
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+// getUser obtiene los campos fields del usuario id no eliminado (todos si fields está vacía)
+func getUser(ctx context.Context, db *tracedDB, d sqlDialect, id string, fields models.UserFields) (*models.User, error) {
+	query := selectUsers(fields) + fmt.Sprintf(" WHERE id = %s AND deleted_at IS NULL", d.placeholder(1))
+	user, err := scanUser(db.QueryRowContext(ctx, query, id), fields)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// selectUsers retorna el SELECT de las columnas de fields, o de todas si fields está vacía
+func selectUsers(fields models.UserFields) string {
+	columns := make([]string, 0, len(models.AllUserFields))
+	for _, field := range fields.OrAll() {
+		columns = append(columns, userFieldColumns[field])
+	}
+	return "SELECT " + strings.Join(columns, ", ") + " FROM users"
+}
+
+// scanUser lee una fila de una consulta basada en selectUsers(fields); los campos no
+// seleccionados quedan vacíos
+func scanUser(row rowScanner, fields models.UserFields) (*models.User, error) {
+	var user models.User
+	var deletedAt sql.NullTime
+	targets := map[models.UserField]interface{}{
+		models.UserFieldID:        &user.ID,
+		models.UserFieldName:      &user.Name,
+		models.UserFieldEmail:     &user.Email,
+		models.UserFieldAge:       &user.Age,
+		models.UserFieldVersion:   &user.Version,
+		models.UserFieldCreatedAt: &user.CreatedAt,
+		models.UserFieldUpdatedAt: &user.UpdatedAt,
+		models.UserFieldDeletedAt: &deletedAt,
+	}
+	dest := make([]interface{}, 0, len(targets))
+	for _, field := range fields.OrAll() {
+		dest = append(dest, targets[field])
+	}
+
+	if err := row.Scan(dest...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error al escanear usuario: %w", err)
+	}
+	if deletedAt.Valid {
+		user.DeletedAt = &deletedAt.Time
+	}
+	return &user, nil
+}
+
+// writeConflict determina por qué una escritura condicional sobre id no afectó filas:
+// ErrUserNotFound si el usuario no existe o está eliminado, ErrVersionConflict si cambió su versión
+func writeConflict(ctx context.Context, db sqlQuerier, d sqlDialect, id string) error {
+	query := fmt.Sprintf("SELECT 1 FROM users WHERE id = %s AND deleted_at IS NULL", d.placeholder(1))
+
+	var exists int
+	if err := db.QueryRowContext(ctx, query, id).Scan(&exists); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("error al verificar usuario: %w", err)
+	}
+	return ErrVersionConflict
+}
+
+// loadTimestamps completa en user las fechas que la base de datos asignó al usuario id
+func loadTimestamps(ctx context.Context, db sqlQuerier, d sqlDialect, id string, user *models.User) error {
+	query := fmt.Sprintf("SELECT created_at, updated_at FROM users WHERE id = %s", d.placeholder(1))
+	if err := db.QueryRowContext(ctx, query, id).Scan(&user.CreatedAt, &user.UpdatedAt); err != nil {
+		return fmt.Errorf("error al obtener fechas del usuario: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer crea un span por cada consulta SQL. Se resuelve en cada llamada para usar el
+// proveedor que instale tracing.Setup después de crear el repositorio.
+func tracer() trace.Tracer {
+	return otel.Tracer("helloworld/repositories")
+}
+
+// maxStatementLength limita el tamaño del atributo db.query.text (los INSERT por lotes son largos)
+const maxStatementLength = 2048
+
+// tracedDB es un *sql.DB cuyas consultas crean spans con la sentencia sanitizada
+type tracedDB struct {
+	*sql.DB
+	system attribute.KeyValue
+}
+
+func newTracedDB(db *sql.DB, system attribute.KeyValue) *tracedDB {
+	return &tracedDB{DB: db, system: system}
+}
+
+func (db *tracedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tracedExec(ctx, db.DB, db.system, query, args)
+}
+
+func (db *tracedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return tracedQuery(ctx, db.DB, db.system, query, args)
+}
+
+func (db *tracedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return tracedQueryRow(ctx, db.DB, db.system, query, args)
+}
+
+// BeginTx inicia una transacción cuyas consultas también crean spans
+func (db *tracedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*tracedTx, error) {
+	tx, err := db.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedTx{Tx: tx, system: db.system}, nil
+}
+
+// tracedTx es un *sql.Tx cuyas consultas crean spans con la sentencia sanitizada
+type tracedTx struct {
+	*sql.Tx
+	system attribute.KeyValue
+}
+
+func (tx *tracedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tracedExec(ctx, tx.Tx, tx.system, query, args)
+}
+
+func (tx *tracedTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return tracedQuery(ctx, tx.Tx, tx.system, query, args)
+}
+
+func (tx *tracedTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return tracedQueryRow(ctx, tx.Tx, tx.system, query, args)
+}
+
+func tracedExec(ctx context.Context, db sqlQuerier, system attribute.KeyValue, query string, args []interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, system, query)
+	result, err := db.ExecContext(ctx, query, args...)
+	endQuerySpan(span, err)
+	return result, err
+}
+
+// tracedQuery mide la ejecución de la consulta; la lectura de las filas queda fuera del span
+func tracedQuery(ctx context.Context, db sqlQuerier, system attribute.KeyValue, query string, args []interface{}) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, system, query)
+	rows, err := db.QueryContext(ctx, query, args...)
+	endQuerySpan(span, err)
+	return rows, err
+}
+
+func tracedQueryRow(ctx context.Context, db sqlQuerier, system attribute.KeyValue, query string, args []interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, system, query)
+	row := db.QueryRowContext(ctx, query, args...)
+	// Err informa los errores de la consulta; sql.ErrNoRows recién aparece en Scan y no es una falla
+	endQuerySpan(span, row.Err())
+	return row
+}
+
+// startQuerySpan inicia el span de query. Los argumentos nunca se registran y la sentencia se
+// sanitiza por si algún valor se escribió en ella.
+func startQuerySpan(ctx context.Context, system attribute.KeyValue, query string) (context.Context, trace.Span) {
+	operation := sqlOperation(query)
+	return tracer().Start(ctx, operation+" users",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			system,
+			semconv.DBOperationName(operation),
+			semconv.DBCollectionName("users"),
+			semconv.DBQueryText(sanitizeStatement(query)),
+		),
+	)
+}
+
+func endQuerySpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// sqlOperation retorna la primera palabra de la sentencia en mayúsculas (SELECT, INSERT, ...)
+func sqlOperation(query string) string {
+	operation, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	return strings.ToUpper(operation)
+}
+
+// sanitizeStatement reemplaza los literales de texto y numéricos por "?", une los espacios y
+// limita la longitud a maxStatementLength. Los marcadores ($1, ?) y los identificadores con
+// dígitos se conservan.
+func sanitizeStatement(query string) string {
+	var b strings.Builder
+	var prev byte
+	pendingSpace := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' {
+			pendingSpace = b.Len() > 0
+			prev = c
+			continue
+		}
+		if pendingSpace {
+			b.WriteByte(' ')
+			pendingSpace = false
+		}
+
+		switch {
+		case c == '\'':
+			// Saltar hasta la comilla de cierre; '' es una comilla escapada dentro del literal
+			for i++; i < len(query); i++ {
+				if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			b.WriteByte('?')
+		case isDigit(c) && !isIdentifierByte(prev):
+			for i+1 < len(query) && (isDigit(query[i+1]) || query[i+1] == '.') {
+				i++
+			}
+			b.WriteByte('?')
+		default:
+			b.WriteByte(c)
+		}
+		prev = query[min(i, len(query)-1)]
+	}
+
+	statement := b.String()
+	if len(statement) > maxStatementLength {
+		statement = statement[:maxStatementLength] + "..."
+	}
+	return statement
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentifierByte indica si c puede preceder a un dígito dentro de un identificador o marcador
+func isIdentifierByte(c byte) bool {
+	return c == '_' || c == '$' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
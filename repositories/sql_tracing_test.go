@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"helloworld/models"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// recordSpans instala un proveedor de trazas que guarda los spans terminados durante el test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+// spanAttribute retorna el valor del atributo key de span, o "" si no lo tiene
+func spanAttribute(span sdktrace.ReadOnlySpan, key string) string {
+	for _, attr := range span.Attributes() {
+		if string(attr.Key) == key {
+			return attr.Value.Emit()
+		}
+	}
+	return ""
+}
+
+func TestSanitizeStatement(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT id FROM users WHERE id = ?", "SELECT id FROM users WHERE id = ?"},
+		{"SELECT id\n\t FROM users  WHERE id = $1 AND age > $12", "SELECT id FROM users WHERE id = $1 AND age > $12"},
+		{"SELECT id FROM users WHERE email = 'juan@example.com' AND age >= 18", "SELECT id FROM users WHERE email = ? AND age >= ?"},
+		{"SELECT id FROM users WHERE name = 'O''Brien' LIMIT 20", "SELECT id FROM users WHERE name = ? LIMIT ?"},
+		{"SELECT 1 FROM users WHERE version = 2.5", "SELECT ? FROM users WHERE version = ?"},
+		{"CREATE UNIQUE INDEX uq_users_email2 ON users (email)", "CREATE UNIQUE INDEX uq_users_email2 ON users (email)"},
+		{"SELECT id FROM users WHERE name = 'sin cerrar", "SELECT id FROM users WHERE name = ?"},
+	}
+
+	for _, tt := range tests {
+		if got := sanitizeStatement(tt.query); got != tt.want {
+			t.Errorf("sanitizeStatement(%q) = %q, esperaba %q", tt.query, got, tt.want)
+		}
+	}
+
+	long := "INSERT INTO users (id) VALUES " + strings.Repeat("(?), ", 1000)
+	if got := sanitizeStatement(long); len(got) != maxStatementLength+len("...") {
+		t.Errorf("sanitizeStatement() de una sentencia larga tiene %d bytes, esperaba %d", len(got), maxStatementLength+len("..."))
+	}
+}
+
+func TestSQLiteUserRepository_QuerySpans(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+	recorder := recordSpans(t)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "petición")
+	user := &models.User{ID: "1", Name: "Juan Pérez", Email: "juan@example.com", Age: 30}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := repo.Create(ctx, &models.User{ID: "2", Name: "Otro", Email: "juan@example.com", Age: 40}); !errors.Is(err, ErrEmailAlreadyExists) {
+		t.Fatalf("Create() con email duplicado error = %v, esperaba %v", err, ErrEmailAlreadyExists)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	var insert, failed sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		if span.Name() != "INSERT users" {
+			continue
+		}
+		if span.Status().Code == codes.Error {
+			failed = span
+		} else {
+			insert = span
+		}
+	}
+	if insert == nil || failed == nil {
+		t.Fatalf("spans = %d, esperaba un INSERT exitoso y uno fallido", len(spans))
+	}
+
+	if insert.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("el span de la consulta no es hijo del span de la petición")
+	}
+	if got := spanAttribute(insert, "db.system"); got != "sqlite" {
+		t.Errorf("db.system = %q, esperaba sqlite", got)
+	}
+	if got := spanAttribute(insert, "db.operation.name"); got != "INSERT" {
+		t.Errorf("db.operation.name = %q, esperaba INSERT", got)
+	}
+	statement := spanAttribute(insert, "db.query.text")
+	if !strings.HasPrefix(statement, "INSERT INTO users") || strings.Contains(statement, "juan@example.com") {
+		t.Errorf("db.query.text = %q, esperaba la sentencia sin los argumentos", statement)
+	}
+}
@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"helloworld/models"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// sqliteTimeLayout es el formato en que CURRENT_TIMESTAMP guarda las fechas (UTC)
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+// sqliteListDialect compara created_at como texto, por lo que los instantes se formatean
+// igual que CURRENT_TIMESTAMP
+var sqliteListDialect = sqlDialect{
+	placeholder: questionPlaceholders,
+	timeArg: func(t time.Time) interface{} {
+		return t.UTC().Format(sqliteTimeLayout)
+	},
+	isDuplicateEmail: isSQLiteDuplicateEmail,
+	emailKey:         "LOWER(email)",
+}
+
+// SQLiteUserRepository implementa UserRepository usando SQLite
+type SQLiteUserRepository struct {
+	db *tracedDB
+}
+
+// NewSQLiteUserRepository crea una nueva instancia del repositorio SQLite.
+// path es la ruta del archivo de base de datos (":memory:" para una base temporal).
+func NewSQLiteUserRepository(path string) (*SQLiteUserRepository, error) {
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("error al abrir base de datos SQLite: %w", err)
+	}
+
+	// SQLite admite un único escritor; una sola conexión evita errores SQLITE_BUSY
+	// y mantiene los datos cuando se usa ":memory:"
+	db.SetMaxOpenConns(1)
+
+	// Verificar la conexión
+	if err := db.Ping(); err != nil {
+		_ = db.Close() // nolint:errcheck // El error original es más relevante
+		return nil, fmt.Errorf("error al conectar con SQLite: %w", err)
+	}
+
+	return &SQLiteUserRepository{
+		db: newTracedDB(db, semconv.DBSystemSqlite),
+	}, nil
+}
+
+// DB retorna la conexión subyacente, usada por el subsistema de migraciones
+func (r *SQLiteUserRepository) DB() *sql.DB {
+	return r.db.DB
+}
+
+// Close cierra la conexión a la base de datos
+func (r *SQLiteUserRepository) Close() error {
+	return r.db.Close()
+}
+
+// Create guarda un nuevo usuario
+func (r *SQLiteUserRepository) Create(ctx context.Context, user *models.User) error {
+	query := "INSERT INTO users (id, name, email, age) VALUES (?, ?, ?, ?)"
+	_, err := r.db.ExecContext(ctx, query, user.ID, user.Name, user.Email, user.Age)
+	if err != nil {
+		if isSQLiteDuplicateEmail(err) {
+			return ErrEmailAlreadyExists
+		}
+		return fmt.Errorf("error al crear usuario: %w", err)
+	}
+	user.Version = 1
+	return loadTimestamps(ctx, r.db, sqliteListDialect, user.ID, user)
+}
+
+// GetByID obtiene un usuario por su ID
+func (r *SQLiteUserRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
+	return getUser(ctx, r.db, sqliteListDialect, id, nil)
+}
+
+// GetFieldsByID obtiene un usuario por su ID leyendo solo las columnas de fields
+func (r *SQLiteUserRepository) GetFieldsByID(ctx context.Context, id string, fields models.UserFields) (*models.User, error) {
+	return getUser(ctx, r.db, sqliteListDialect, id, fields)
+}
+
+// GetAll obtiene una página de usuarios filtrada y ordenada según query (por defecto, models.DefaultUserSort)
+func (r *SQLiteUserRepository) GetAll(ctx context.Context, query models.UserQuery) (*models.UserPage, error) {
+	return listUsers(ctx, r.db, sqliteListDialect, query)
+}
+
+// Update actualiza un usuario existente si user.Version coincide con la versión almacenada
+func (r *SQLiteUserRepository) Update(ctx context.Context, id string, user *models.User) error {
+	query := "UPDATE users SET name = ?, email = ?, age = ?, version = version + 1 WHERE id = ? AND deleted_at IS NULL AND version = ?"
+	result, err := r.db.ExecContext(ctx, query, user.Name, user.Email, user.Age, id, user.Version)
+	if err != nil {
+		if isSQLiteDuplicateEmail(err) {
+			return ErrEmailAlreadyExists
+		}
+		return fmt.Errorf("error al actualizar usuario: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al verificar filas afectadas: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return writeConflict(ctx, r.db, sqliteListDialect, id)
+	}
+
+	user.Version++
+	return loadTimestamps(ctx, r.db, sqliteListDialect, id, user)
+}
+
+// Delete elimina lógicamente un usuario marcando deleted_at.
+// Si version no es models.AnyVersion, solo elimina si coincide con la versión almacenada.
+func (r *SQLiteUserRepository) Delete(ctx context.Context, id string, version int64) error {
+	query := "UPDATE users SET deleted_at = CURRENT_TIMESTAMP, version = version + 1 WHERE id = ? AND deleted_at IS NULL"
+	args := []interface{}{id}
+	if version != models.AnyVersion {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("error al eliminar usuario: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al verificar filas afectadas: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return writeConflict(ctx, r.db, sqliteListDialect, id)
+	}
+
+	return nil
+}
+
+// Restore revierte la eliminación lógica de un usuario
+func (r *SQLiteUserRepository) Restore(ctx context.Context, id string) error {
+	query := "UPDATE users SET deleted_at = NULL, version = version + 1 WHERE id = ? AND deleted_at IS NOT NULL"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("error al restaurar usuario: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("error al verificar filas afectadas: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+// Purge elimina definitivamente los usuarios eliminados antes de deletedBefore
+func (r *SQLiteUserRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	query := "DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ?"
+	result, err := r.db.ExecContext(ctx, query, sqliteListDialect.timeArg(deletedBefore))
+	if err != nil {
+		return 0, fmt.Errorf("error al purgar usuarios: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("error al verificar filas afectadas: %w", err)
+	}
+
+	return purged, nil
+}
+
+// CreateBatch guarda todos los usuarios con INSERT multi-fila, o ninguno si alguno falla
+func (r *SQLiteUserRepository) CreateBatch(ctx context.Context, users []*models.User) error {
+	return createUsersBatch(ctx, r.db, sqliteListDialect, users)
+}
+
+// UpdateBatch actualiza todos los usuarios en una transacción, o ninguno si alguno falla
+func (r *SQLiteUserRepository) UpdateBatch(ctx context.Context, users []*models.User) error {
+	return updateUsersBatch(ctx, r.db, sqliteListDialect, users)
+}
+
+// DeleteBatch elimina lógicamente todos los usuarios en una transacción, o ninguno si alguno falla
+func (r *SQLiteUserRepository) DeleteBatch(ctx context.Context, refs []models.UserRef) error {
+	return deleteUsersBatch(ctx, r.db, sqliteListDialect, refs)
+}
+
+// sqliteStreamPageSize es la cantidad de usuarios que Stream lee por consulta
+const sqliteStreamPageSize = 500
+
+// Stream recorre los usuarios que cumplen filter leyéndolos por páginas. El repositorio tiene
+// una única conexión: un cursor abierto durante toda la exportación bloquearía a las demás
+// peticiones (y a /readyz) mientras el cliente descarga el archivo.
+func (r *SQLiteUserRepository) Stream(ctx context.Context, filter models.UserFilter, sort models.UserSort, fn func(*models.User) error) error {
+	return streamUsersPaged(ctx, r.db, sqliteListDialect, filter, sort, sqliteStreamPageSize, fn)
+}
+
+// isSQLiteDuplicateEmail indica si err es una violación del índice único de email
+func isSQLiteDuplicateEmail(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE &&
+		strings.Contains(sqliteErr.Error(), uniqueEmailIndex)
+}
@@ -0,0 +1,147 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"helloworld/migrations"
+	"helloworld/models"
+)
+
+func newTestSQLiteRepository(t *testing.T) *SQLiteUserRepository {
+	t.Helper()
+	repo, err := NewSQLiteUserRepository(filepath.Join(t.TempDir(), "users.sqlite"))
+	if err != nil {
+		t.Fatalf("NewSQLiteUserRepository() error = %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+
+	migrator, err := migrations.New(repo.DB(), migrations.DialectSQLite)
+	if err != nil {
+		t.Fatalf("migrations.New() error = %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	return repo
+}
+
+func TestSQLiteUserRepository_UpdatedAtTrigger(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestSQLiteRepository(t)
+
+	user := &models.User{ID: "1", Name: "Juan Pérez", Email: "juan@example.com", Age: 30}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Forzar una marca antigua para no depender de la resolución de CURRENT_TIMESTAMP
+	const old = "2000-01-01 00:00:00"
+	if _, err := repo.db.Exec("UPDATE users SET created_at = ?, updated_at = ? WHERE id = ?", old, old, user.ID); err != nil {
+		t.Fatalf("error al preparar marcas de tiempo: %v", err)
+	}
+
+	user.Age = 31
+	if err := repo.Update(ctx, user.ID, user); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	var createdAt, updatedAt time.Time
+	row := repo.db.QueryRow("SELECT created_at, updated_at FROM users WHERE id = ?", user.ID)
+	if err := row.Scan(&createdAt, &updatedAt); err != nil {
+		t.Fatalf("error al leer marcas de tiempo: %v", err)
+	}
+	want := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	if !createdAt.Equal(want) {
+		t.Errorf("created_at = %v, esperaba %v", createdAt, want)
+	}
+	if updatedAt.Equal(want) {
+		t.Errorf("updated_at no fue actualizado por el trigger")
+	}
+}
+
+func TestSQLiteUserRepository_FieldsProjection(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestSQLiteRepository(t)
+
+	user := &models.User{ID: "1", Name: "Juan Pérez", Email: "juan@example.com", Age: 30}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Solo se leen las columnas seleccionadas; el resto queda vacío
+	got, err := repo.GetFieldsByID(ctx, user.ID, models.UserFields{models.UserFieldName})
+	if err != nil {
+		t.Fatalf("GetFieldsByID() error = %v", err)
+	}
+	if want := (models.User{Name: user.Name}); *got != want {
+		t.Errorf("GetFieldsByID() = %+v, esperaba %+v", *got, want)
+	}
+
+	page, err := repo.GetAll(ctx, models.UserQuery{Fields: models.UserFields{models.UserFieldEmail}})
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	// Además del email se lee created_at, el campo del orden por defecto, y el id para el cursor
+	if got := page.Users[0]; got.Email != user.Email || got.ID != user.ID || got.CreatedAt.IsZero() || got.Name != "" || got.Age != 0 {
+		t.Errorf("GetAll() = %+v, esperaba solo id, email y created_at", *got)
+	}
+}
+
+func TestSQLiteUserRepository_CanceledContext(t *testing.T) {
+	repo := newTestSQLiteRepository(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := repo.Create(ctx, &models.User{ID: "1"}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Create() error = %v, esperaba %v", err, context.Canceled)
+	}
+	if _, err := repo.GetAll(ctx, models.UserQuery{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetAll() error = %v, esperaba %v", err, context.Canceled)
+	}
+}
+
+func TestSQLiteUserRepository_StreamReleasesConnection(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestSQLiteRepository(t)
+
+	// Más de dos páginas, con edades repetidas para recorrer empates por id
+	total := 2*sqliteStreamPageSize + 1
+	users := make([]*models.User, total)
+	for i := range users {
+		users[i] = &models.User{ID: fmt.Sprintf("user-%04d", i), Name: "Usuario", Email: fmt.Sprintf("u%d@example.com", i), Age: i % 7}
+	}
+	for start := 0; start < total; start += models.MaxBatchSize {
+		if err := repo.CreateBatch(ctx, users[start:min(start+models.MaxBatchSize, total)]); err != nil {
+			t.Fatalf("CreateBatch() error = %v", err)
+		}
+	}
+
+	seen := make(map[string]bool, total)
+	err := repo.Stream(ctx, models.UserFilter{}, models.UserSort{Field: models.SortByAge}, func(user *models.User) error {
+		if seen[user.ID] {
+			t.Fatalf("Stream() repitió el usuario %s", user.ID)
+		}
+		seen[user.ID] = true
+		// Mientras el cliente lee la exportación, la única conexión debe quedar libre
+		if len(seen)%sqliteStreamPageSize == 1 {
+			waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+			defer cancel()
+			if _, err := repo.GetByID(waitCtx, users[0].ID); err != nil {
+				t.Fatalf("GetByID() durante Stream() error = %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Stream() error = %v", err)
+	}
+	if len(seen) != total {
+		t.Errorf("Stream() recorrió %d usuarios, esperaba %d", len(seen), total)
+	}
+}
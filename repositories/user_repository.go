@@ -1,20 +1,78 @@
 package repositories
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"helloworld/models"
 )
 
 var (
-	ErrUserNotFound = errors.New("usuario no encontrado")
+	ErrUserNotFound       = errors.New("usuario no encontrado")
+	ErrEmailAlreadyExists = errors.New("ya existe un usuario con ese email")
+	ErrVersionConflict    = errors.New("el usuario fue modificado por otra operación")
 )
 
-// UserRepository define la interfaz para el almacenamiento y recuperación de usuarios
+// BatchError indica el elemento que impidió aplicar una operación por lotes; ninguno se aplicó
+type BatchError struct {
+	Index int
+	Err   error
+}
+
+// Error implementa la interfaz error
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("elemento %d del lote: %v", e.Index, e.Err)
+}
+
+// Unwrap expone la causa para errors.Is y errors.As
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// uniqueEmailIndex es el nombre del índice único sobre email creado por las migraciones
+const uniqueEmailIndex = "uq_users_email"
+
+// UserRepository define la interfaz para el almacenamiento y recuperación de usuarios.
+// Todas las operaciones respetan la cancelación y el deadline de ctx.
+// Create y Update retornan ErrEmailAlreadyExists si el email (sin distinguir mayúsculas) ya está en uso;
+// los usuarios eliminados lógicamente conservan su email hasta ser purgados.
+//
+// Delete realiza una eliminación lógica: GetByID, Update y Delete tratan a los usuarios
+// eliminados como inexistentes, y GetAll los omite salvo que se pida Filter.IncludeDeleted.
+//
+// Cada escritura incrementa la versión del usuario. Update es condicional: solo se aplica si
+// user.Version coincide con la versión almacenada, retorna ErrVersionConflict si otra escritura
+// se adelantó y, al aplicarse, deja en user.Version la nueva versión. Create inicia la versión en 1.
+// Create y Update completan user.CreatedAt y user.UpdatedAt con los valores almacenados.
+//
+// Las operaciones por lotes son atómicas: se aplican todos los elementos o ninguno.
+// CreateBatch usa INSERT multi-fila, por lo que un email duplicado retorna ErrEmailAlreadyExists
+// sin indicar el elemento; UpdateBatch y DeleteBatch retornan *BatchError con el índice del
+// elemento que falló. Tras un error los usuarios recibidos pueden tener versiones y fechas parciales.
+//
+// GetFieldsByID y GetAll con query.Fields leen solo los campos seleccionados cuando el
+// almacenamiento lo permite (los repositorios SQL los proyectan en el SELECT); el resto puede
+// quedar vacío. GetAll lee además el id y el campo de orden, necesarios para el cursor.
+//
+// Stream recorre todos los usuarios que cumplen filter en el orden sort sin cargarlos en memoria,
+// llamando a fn con cada uno; un error de fn detiene el recorrido y se retorna sin envolver.
+// fn no debe usar el repositorio: el repositorio SQLite tiene una única conexión, ocupada por el recorrido.
 type UserRepository interface {
-	Create(user *models.User) error
-	GetByID(id string) (*models.User, error)
-	GetAll() ([]*models.User, error)
-	Update(id string, user *models.User) error
-	Delete(id string) error
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id string) (*models.User, error)
+	GetFieldsByID(ctx context.Context, id string, fields models.UserFields) (*models.User, error)
+	GetAll(ctx context.Context, query models.UserQuery) (*models.UserPage, error)
+	Update(ctx context.Context, id string, user *models.User) error
+	// Delete acepta models.AnyVersion para eliminar sin verificar la versión
+	Delete(ctx context.Context, id string, version int64) error
+	// Restore revierte la eliminación lógica; retorna ErrUserNotFound si no hay un usuario eliminado con ese id
+	Restore(ctx context.Context, id string) error
+	// Purge elimina definitivamente los usuarios eliminados antes de deletedBefore y retorna cuántos borró
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	CreateBatch(ctx context.Context, users []*models.User) error
+	UpdateBatch(ctx context.Context, users []*models.User) error
+	DeleteBatch(ctx context.Context, refs []models.UserRef) error
+	Stream(ctx context.Context, filter models.UserFilter, sort models.UserSort, fn func(*models.User) error) error
 }
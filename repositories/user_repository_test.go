@@ -0,0 +1,567 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"helloworld/models"
+)
+
+// testRepositories retorna las implementaciones que pueden probarse sin servicios externos
+func testRepositories(t *testing.T) map[string]UserRepository {
+	return map[string]UserRepository{
+		"memory": NewMemoryUserRepository(),
+		"sqlite": newTestSQLiteRepository(t),
+	}
+}
+
+func TestUserRepository_Pagination(t *testing.T) {
+	for name, repo := range testRepositories(t) {
+		repo := repo
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			// Los usuarios se crean en el mismo segundo: el id desempata el orden
+			const total = 7
+			for i := 0; i < total; i++ {
+				user := &models.User{ID: fmt.Sprintf("user-%02d", i), Name: "n", Email: fmt.Sprintf("user%d@example.com", i), Age: 1}
+				if err := repo.Create(ctx, user); err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+			}
+
+			byCursor := collectPages(t, repo, func(page *models.UserPage, query *models.UserQuery) bool {
+				query.Cursor = page.NextCursor
+				return page.NextCursor != nil
+			})
+			byOffset := collectPages(t, repo, func(page *models.UserPage, query *models.UserQuery) bool {
+				query.Offset += len(page.Users)
+				return query.Offset < page.Total
+			})
+
+			for _, ids := range [][]string{byCursor, byOffset} {
+				if len(ids) != total {
+					t.Fatalf("se recorrieron %d usuarios, esperaba %d: %v", len(ids), total, ids)
+				}
+				for i, id := range ids {
+					if want := fmt.Sprintf("user-%02d", total-1-i); id != want {
+						t.Errorf("posición %d = %v, esperaba %v", i, id, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+// collectPages recorre el listado en páginas de 3 usando next para avanzar
+func collectPages(t *testing.T, repo UserRepository, next func(*models.UserPage, *models.UserQuery) bool) []string {
+	t.Helper()
+	query := models.UserQuery{Limit: 3}
+	ids := make([]string, 0)
+
+	for i := 0; i < 10; i++ {
+		page, err := repo.GetAll(context.Background(), query)
+		if err != nil {
+			t.Fatalf("GetAll() error = %v", err)
+		}
+		for _, user := range page.Users {
+			ids = append(ids, user.ID)
+		}
+		if !next(page, &query) {
+			return ids
+		}
+	}
+
+	t.Fatalf("la paginación no terminó")
+	return nil
+}
+
+func TestUserRepository_FilterAndSort(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+
+	for name, repo := range testRepositories(t) {
+		repo := repo
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			seed := []models.User{
+				{ID: "1", Name: "Ana López", Email: "ana@example.com", Age: 30},
+				{ID: "2", Name: "andrés", Email: "Andres@Example.com", Age: 25},
+				{ID: "3", Name: "Bruno", Email: "bruno@example.com", Age: 30},
+				{ID: "4", Name: "An_gel", Email: "angel@example.com", Age: 40},
+				{ID: "5", Name: "Carla", Email: "carla@example.com", Age: 30},
+			}
+			for i := range seed {
+				if err := repo.Create(ctx, &seed[i]); err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+			}
+
+			tests := []struct {
+				name  string
+				query models.UserQuery
+				want  []string
+			}{
+				{
+					name:  "prefijo de nombre sin distinguir mayúsculas",
+					query: models.UserQuery{Filter: models.UserFilter{NamePrefix: "AN"}, Sort: models.UserSort{Field: models.SortByName}},
+					want:  []string{"4", "1", "2"},
+				},
+				{
+					name:  "comodines escapados en el prefijo",
+					query: models.UserQuery{Filter: models.UserFilter{NamePrefix: "an_"}},
+					want:  []string{"4"},
+				},
+				{
+					name:  "email exacto sin distinguir mayúsculas",
+					query: models.UserQuery{Filter: models.UserFilter{Email: "andres@example.com"}},
+					want:  []string{"2"},
+				},
+				{
+					name:  "lista de emails sin distinguir mayúsculas",
+					query: models.UserQuery{Filter: models.UserFilter{Emails: []string{"ANDRES@example.com", "carla@example.com", "nadie@example.com"}}, Sort: models.UserSort{Field: models.SortByEmail}},
+					want:  []string{"2", "5"},
+				},
+				{
+					name:  "rango de edad ordenado por edad descendente",
+					query: models.UserQuery{Filter: models.UserFilter{MinAge: intPtr(26), MaxAge: intPtr(35)}, Sort: models.UserSort{Field: models.SortByAge, Desc: true}},
+					want:  []string{"5", "3", "1"},
+				},
+				{
+					name:  "orden por email ascendente",
+					query: models.UserQuery{Filter: models.UserFilter{MinAge: intPtr(30)}, Sort: models.UserSort{Field: models.SortByEmail}},
+					want:  []string{"1", "4", "3", "5"},
+				},
+			}
+
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					page, err := repo.GetAll(ctx, tt.query)
+					if err != nil {
+						t.Fatalf("GetAll() error = %v", err)
+					}
+					got := make([]string, 0, len(page.Users))
+					for _, user := range page.Users {
+						got = append(got, user.ID)
+					}
+					if fmt.Sprint(got) != fmt.Sprint(tt.want) || page.Total != len(tt.want) {
+						t.Errorf("GetAll() = %v (total %d), esperaba %v", got, page.Total, tt.want)
+					}
+				})
+			}
+
+			// SkipTotal no cuenta los usuarios pero retorna la página
+			page, err := repo.GetAll(ctx, models.UserQuery{Filter: models.UserFilter{MinAge: intPtr(30)}, Limit: 1, SkipTotal: true})
+			if err != nil {
+				t.Fatalf("GetAll() error = %v", err)
+			}
+			if len(page.Users) != 1 || page.Total != 0 {
+				t.Errorf("GetAll() con SkipTotal = %d usuarios (total %d), esperaba 1 (total 0)", len(page.Users), page.Total)
+			}
+
+			// El cursor debe respetar el orden por edad con empates
+			query := models.UserQuery{Sort: models.UserSort{Field: models.SortByAge}, Limit: 2}
+			ids := make([]string, 0)
+			for {
+				page, err := repo.GetAll(ctx, query)
+				if err != nil {
+					t.Fatalf("GetAll() error = %v", err)
+				}
+				for _, user := range page.Users {
+					ids = append(ids, user.ID)
+				}
+				if page.NextCursor == nil {
+					break
+				}
+				query.Cursor = page.NextCursor
+			}
+			if want := "[2 1 3 5 4]"; fmt.Sprint(ids) != want {
+				t.Errorf("recorrido por cursor = %v, esperaba %v", ids, want)
+			}
+		})
+	}
+}
+
+func TestUserRepository_UniqueEmail(t *testing.T) {
+	for name, repo := range testRepositories(t) {
+		repo := repo
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if err := repo.Create(ctx, &models.User{ID: "1", Name: "Ana", Email: "ana@example.com", Age: 30}); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if err := repo.Create(ctx, &models.User{ID: "2", Name: "Bruno", Email: "bruno@example.com", Age: 30}); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			err := repo.Create(ctx, &models.User{ID: "3", Name: "Otra Ana", Email: "ANA@example.com", Age: 30})
+			if !errors.Is(err, ErrEmailAlreadyExists) {
+				t.Errorf("Create() con email duplicado error = %v, esperaba %v", err, ErrEmailAlreadyExists)
+			}
+
+			err = repo.Update(ctx, "2", &models.User{Name: "Bruno", Email: "Ana@Example.com", Age: 30, Version: 1})
+			if !errors.Is(err, ErrEmailAlreadyExists) {
+				t.Errorf("Update() con email duplicado error = %v, esperaba %v", err, ErrEmailAlreadyExists)
+			}
+
+			// Actualizar el propio email cambiando mayúsculas no es un conflicto
+			if err := repo.Update(ctx, "1", &models.User{Name: "Ana", Email: "ANA@example.com", Age: 31, Version: 1}); err != nil {
+				t.Errorf("Update() del propio email error = %v", err)
+			}
+		})
+	}
+}
+
+func TestUserRepository_SoftDelete(t *testing.T) {
+	for name, repo := range testRepositories(t) {
+		repo := repo
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			for _, id := range []string{"a", "b"} {
+				if err := repo.Create(ctx, &models.User{ID: id, Name: id, Email: id + "@example.com", Age: 1}); err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+			}
+			if err := repo.Delete(ctx, "a", models.AnyVersion); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+
+			if _, err := repo.GetByID(ctx, "a"); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("GetByID() de eliminado error = %v, esperaba %v", err, ErrUserNotFound)
+			}
+			if err := repo.Update(ctx, "a", &models.User{Name: "x", Email: "x@example.com", Age: 2}); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("Update() de eliminado error = %v, esperaba %v", err, ErrUserNotFound)
+			}
+			if err := repo.Delete(ctx, "a", models.AnyVersion); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("Delete() repetido error = %v, esperaba %v", err, ErrUserNotFound)
+			}
+			// El email de un usuario eliminado sigue reservado hasta la purga
+			if err := repo.Create(ctx, &models.User{ID: "c", Email: "A@example.com"}); !errors.Is(err, ErrEmailAlreadyExists) {
+				t.Errorf("Create() con email de eliminado error = %v, esperaba %v", err, ErrEmailAlreadyExists)
+			}
+
+			page, err := repo.GetAll(ctx, models.UserQuery{})
+			if err != nil {
+				t.Fatalf("GetAll() error = %v", err)
+			}
+			if page.Total != 1 || page.Users[0].ID != "b" {
+				t.Errorf("GetAll() = %d usuarios (total %d), esperaba solo b", len(page.Users), page.Total)
+			}
+
+			page, err = repo.GetAll(ctx, models.UserQuery{Filter: models.UserFilter{IncludeDeleted: true}, Sort: models.UserSort{Field: models.SortByName}})
+			if err != nil {
+				t.Fatalf("GetAll() con eliminados error = %v", err)
+			}
+			if page.Total != 2 || page.Users[0].DeletedAt == nil || page.Users[1].DeletedAt != nil {
+				t.Errorf("GetAll() con eliminados = %+v, esperaba a eliminado y b activo", page.Users)
+			}
+
+			if purged, err := repo.Purge(ctx, time.Now().Add(-time.Hour)); err != nil || purged != 0 {
+				t.Errorf("Purge() dentro de la retención = %d, %v, esperaba 0", purged, err)
+			}
+
+			if err := repo.Restore(ctx, "a"); err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+			if _, err := repo.GetByID(ctx, "a"); err != nil {
+				t.Errorf("GetByID() después de Restore error = %v", err)
+			}
+			if err := repo.Restore(ctx, "b"); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("Restore() de activo error = %v, esperaba %v", err, ErrUserNotFound)
+			}
+
+			if err := repo.Delete(ctx, "a", models.AnyVersion); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if purged, err := repo.Purge(ctx, time.Now().Add(time.Hour)); err != nil || purged != 1 {
+				t.Errorf("Purge() = %d, %v, esperaba 1", purged, err)
+			}
+			if err := repo.Restore(ctx, "a"); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("Restore() de purgado error = %v, esperaba %v", err, ErrUserNotFound)
+			}
+		})
+	}
+}
+
+func TestUserRepository_ConditionalWrites(t *testing.T) {
+	for name, repo := range testRepositories(t) {
+		repo := repo
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			user := &models.User{ID: "1", Name: "Ana", Email: "ana@example.com", Age: 30}
+			if err := repo.Create(ctx, user); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if user.Version != 1 {
+				t.Errorf("Create() versión = %d, esperaba 1", user.Version)
+			}
+
+			// Dos lecturas concurrentes de la misma versión: la segunda escritura debe fallar
+			first, _ := repo.GetByID(ctx, "1")
+			second, _ := repo.GetByID(ctx, "1")
+
+			first.Age = 31
+			if err := repo.Update(ctx, "1", first); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			if first.Version != 2 {
+				t.Errorf("Update() versión = %d, esperaba 2", first.Version)
+			}
+
+			second.Name = "Perdida"
+			if err := repo.Update(ctx, "1", second); !errors.Is(err, ErrVersionConflict) {
+				t.Errorf("Update() con versión vieja error = %v, esperaba %v", err, ErrVersionConflict)
+			}
+			got, _ := repo.GetByID(ctx, "1")
+			if got.Name != "Ana" || got.Age != 31 || got.Version != 2 {
+				t.Errorf("GetByID() = %+v, la escritura perdida no debe aplicarse", got)
+			}
+
+			if err := repo.Delete(ctx, "1", 1); !errors.Is(err, ErrVersionConflict) {
+				t.Errorf("Delete() con versión vieja error = %v, esperaba %v", err, ErrVersionConflict)
+			}
+			if err := repo.Delete(ctx, "1", 2); err != nil {
+				t.Errorf("Delete() con versión actual error = %v", err)
+			}
+			if err := repo.Delete(ctx, "1", 3); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("Delete() de eliminado error = %v, esperaba %v", err, ErrUserNotFound)
+			}
+
+			if err := repo.Restore(ctx, "1"); err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+			if got, _ := repo.GetByID(ctx, "1"); got.Version != 4 {
+				t.Errorf("versión después de Delete y Restore = %d, esperaba 4", got.Version)
+			}
+		})
+	}
+}
+
+func TestUserRepository_Timestamps(t *testing.T) {
+	for name, repo := range testRepositories(t) {
+		repo := repo
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			user := &models.User{ID: "1", Name: "Ana", Email: "ana@example.com", Age: 30}
+			if err := repo.Create(ctx, user); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if user.CreatedAt.IsZero() || user.UpdatedAt.Before(user.CreatedAt) {
+				t.Errorf("Create() fechas = %v / %v, esperaba created_at <= updated_at", user.CreatedAt, user.UpdatedAt)
+			}
+
+			user.Age = 31
+			if err := repo.Update(ctx, "1", user); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			got, err := repo.GetByID(ctx, "1")
+			if err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			if !got.CreatedAt.Equal(user.CreatedAt) || !got.UpdatedAt.Equal(user.UpdatedAt) {
+				t.Errorf("GetByID() fechas = %v / %v, esperaba %v / %v", got.CreatedAt, got.UpdatedAt, user.CreatedAt, user.UpdatedAt)
+			}
+
+			for _, tt := range []struct {
+				since time.Time
+				want  int
+			}{
+				{since: user.UpdatedAt, want: 1},
+				{since: user.UpdatedAt.Add(time.Hour), want: 0},
+			} {
+				since := tt.since
+				query := models.UserQuery{Filter: models.UserFilter{UpdatedSince: &since}, Sort: models.UserSort{Field: models.SortByUpdatedAt}}
+				page, err := repo.GetAll(ctx, query)
+				if err != nil {
+					t.Fatalf("GetAll() error = %v", err)
+				}
+				if page.Total != tt.want {
+					t.Errorf("GetAll(updated_since=%v) total = %d, esperaba %d", since, page.Total, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestUserRepository_Batch(t *testing.T) {
+	for name, repo := range testRepositories(t) {
+		repo := repo
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			// Más usuarios que filas por sentencia para cubrir varios INSERT
+			users := make([]*models.User, batchInsertRows+2)
+			for i := range users {
+				users[i] = &models.User{ID: fmt.Sprintf("%04d", i), Name: "Usuario", Email: fmt.Sprintf("u%d@example.com", i), Age: 30}
+			}
+			if err := repo.CreateBatch(ctx, users); err != nil {
+				t.Fatalf("CreateBatch() error = %v", err)
+			}
+			last := users[len(users)-1]
+			if last.Version != 1 || last.CreatedAt.IsZero() {
+				t.Errorf("CreateBatch() usuario = %+v, esperaba versión 1 y fechas", last)
+			}
+
+			// Un email repetido impide crear todo el lote
+			duplicated := []*models.User{
+				{ID: "nuevo", Name: "Nuevo", Email: "nuevo@example.com", Age: 30},
+				{ID: "repetido", Name: "Repetido", Email: "U0@example.com", Age: 30},
+			}
+			if err := repo.CreateBatch(ctx, duplicated); !errors.Is(err, ErrEmailAlreadyExists) {
+				t.Errorf("CreateBatch() con email repetido error = %v, esperaba %v", err, ErrEmailAlreadyExists)
+			}
+			if _, err := repo.GetByID(ctx, "nuevo"); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("GetByID() tras lote fallido error = %v, esperaba %v", err, ErrUserNotFound)
+			}
+
+			// El segundo elemento tiene una versión vieja: el primero no debe aplicarse
+			first := &models.User{ID: "0000", Name: "Cambiado", Email: "u0@example.com", Age: 40, Version: 1}
+			stale := &models.User{ID: "0001", Name: "Cambiado", Email: "u1@example.com", Age: 40, Version: 7}
+			err := repo.UpdateBatch(ctx, []*models.User{first, stale})
+			var batchErr *BatchError
+			if !errors.As(err, &batchErr) || batchErr.Index != 1 || !errors.Is(err, ErrVersionConflict) {
+				t.Fatalf("UpdateBatch() error = %v, esperaba BatchError en el índice 1 con %v", err, ErrVersionConflict)
+			}
+			if got, _ := repo.GetByID(ctx, "0000"); got.Name != "Usuario" || got.Version != 1 {
+				t.Errorf("GetByID() = %+v, el lote fallido no debe aplicarse", got)
+			}
+
+			first = &models.User{ID: "0000", Name: "Cambiado", Email: "u0@example.com", Age: 40, Version: 1}
+			if err := repo.UpdateBatch(ctx, []*models.User{first}); err != nil || first.Version != 2 {
+				t.Fatalf("UpdateBatch() = %v, versión %d, esperaba versión 2", err, first.Version)
+			}
+
+			err = repo.DeleteBatch(ctx, []models.UserRef{{ID: "0000", Version: 2}, {ID: "inexistente"}})
+			if !errors.As(err, &batchErr) || batchErr.Index != 1 || !errors.Is(err, ErrUserNotFound) {
+				t.Fatalf("DeleteBatch() error = %v, esperaba BatchError en el índice 1 con %v", err, ErrUserNotFound)
+			}
+			if _, err := repo.GetByID(ctx, "0000"); err != nil {
+				t.Errorf("GetByID() tras lote fallido error = %v, el usuario no debe eliminarse", err)
+			}
+			if err := repo.DeleteBatch(ctx, []models.UserRef{{ID: "0000", Version: 2}, {ID: "0001"}}); err != nil {
+				t.Fatalf("DeleteBatch() error = %v", err)
+			}
+			if page, _ := repo.GetAll(ctx, models.UserQuery{Limit: 1}); page.Total != len(users)-2 {
+				t.Errorf("GetAll() total = %d, esperaba %d", page.Total, len(users)-2)
+			}
+		})
+	}
+}
+
+func TestUserRepository_Stream(t *testing.T) {
+	for name, repo := range testRepositories(t) {
+		repo := repo
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			for i := 0; i < 5; i++ {
+				user := &models.User{ID: fmt.Sprintf("user-%d", i), Name: fmt.Sprintf("Usuario %d", i), Email: fmt.Sprintf("u%d@example.com", i), Age: 20 + i}
+				if err := repo.Create(ctx, user); err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+			}
+			if err := repo.Delete(ctx, "user-4", models.AnyVersion); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+
+			var ids []string
+			minAge := 21
+			err := repo.Stream(ctx, models.UserFilter{MinAge: &minAge}, models.UserSort{Field: models.SortByAge, Desc: true}, func(user *models.User) error {
+				ids = append(ids, user.ID)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("Stream() error = %v", err)
+			}
+			if want := "user-3,user-2,user-1"; strings.Join(ids, ",") != want {
+				t.Errorf("Stream() = %v, esperaba %v", ids, want)
+			}
+
+			// Un error de fn detiene el recorrido y se retorna sin envolver
+			errStop := errors.New("detener")
+			calls := 0
+			err = repo.Stream(ctx, models.UserFilter{IncludeDeleted: true}, models.UserSort{}, func(user *models.User) error {
+				calls++
+				return errStop
+			})
+			if err != errStop || calls != 1 {
+				t.Errorf("Stream() = %v tras %d llamadas, esperaba %v tras 1", err, calls, errStop)
+			}
+		})
+	}
+}
+
+func TestUserRepository_Fields(t *testing.T) {
+	for name, repo := range testRepositories(t) {
+		repo := repo
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			for i := 0; i < 5; i++ {
+				user := &models.User{ID: fmt.Sprintf("user-%d", i), Name: fmt.Sprintf("Usuario %d", i), Email: fmt.Sprintf("u%d@example.com", i), Age: 20 + i}
+				if err := repo.Create(ctx, user); err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+			}
+
+			user, err := repo.GetFieldsByID(ctx, "user-1", models.UserFields{models.UserFieldName, models.UserFieldVersion})
+			if err != nil {
+				t.Fatalf("GetFieldsByID() error = %v", err)
+			}
+			if user.Name != "Usuario 1" || user.Version != 1 {
+				t.Errorf("GetFieldsByID() = %+v, esperaba name y version", user)
+			}
+			if _, err := repo.GetFieldsByID(ctx, "nope", models.UserFields{models.UserFieldName}); !errors.Is(err, ErrUserNotFound) {
+				t.Errorf("GetFieldsByID() error = %v, esperaba %v", err, ErrUserNotFound)
+			}
+
+			// El cursor necesita el id y el campo de orden aunque no se seleccionen
+			query := models.UserQuery{
+				Sort:   models.UserSort{Field: models.SortByAge},
+				Limit:  2,
+				Fields: models.UserFields{models.UserFieldName},
+			}
+			var names []string
+			for {
+				page, err := repo.GetAll(ctx, query)
+				if err != nil {
+					t.Fatalf("GetAll() error = %v", err)
+				}
+				for _, user := range page.Users {
+					names = append(names, user.Name)
+				}
+				if page.NextCursor == nil {
+					break
+				}
+				query.Cursor = page.NextCursor
+			}
+			if want := "Usuario 0,Usuario 1,Usuario 2,Usuario 3,Usuario 4"; strings.Join(names, ",") != want {
+				t.Errorf("GetAll() = %v, esperaba %v", names, want)
+			}
+		})
+	}
+}
+
+func TestFilterConditions_EmailUsesUniqueIndex(t *testing.T) {
+	filter := models.UserFilter{Email: "Ana@x.com", Emails: []string{"a@x.com", "b@x.com"}, IncludeDeleted: true}
+	tests := map[string]struct {
+		dialect sqlDialect
+		want    string
+	}{
+		"mysql":    {mysqlListDialect, "[email = ? email IN (?, ?)]"},
+		"postgres": {postgresListDialect, "[LOWER(email) = $1 LOWER(email) IN ($2, $3)]"},
+		"sqlite":   {sqliteListDialect, "[LOWER(email) = ? LOWER(email) IN (?, ?)]"},
+	}
+	for name, tt := range tests {
+		if got := fmt.Sprint(filterConditions(filter, &sqlArgs{dialect: tt.dialect})); got != tt.want {
+			t.Errorf("filterConditions(%s) = %s, esperaba %s", name, got, tt.want)
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"helloworld/handlers"
+	"helloworld/health"
+	"helloworld/metrics"
 	"helloworld/middleware"
 	"helloworld/services"
 
@@ -11,27 +13,41 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-// SetupRoutes configura todas las rutas de la API
-func SetupRoutes(userService services.UserService) http.Handler {
+// SetupRoutes configura todas las rutas de la API; checks responde las sondas de liveness y
+// readiness, m registra y expone en /metrics las métricas de las peticiones y handlerOptions
+// configura el handler de usuarios
+func SetupRoutes(userService services.UserService, checks *health.Health, m *metrics.Metrics, handlerOptions ...handlers.Option) http.Handler {
 	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(handlers.NotFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(handlers.MethodNotAllowed)
 
 	// Inicializar handlers
-	userHandler := handlers.NewUserHandler(userService)
+	userHandler := handlers.NewUserHandler(userService, handlerOptions...)
 
 	// Rutas de usuarios
 	api := router.PathPrefix("/api/v1").Subrouter()
+	api.Use(userHandler.RequireAcceptable)
 	api.HandleFunc("/users", userHandler.CreateUser).Methods("POST")
 	api.HandleFunc("/users", userHandler.GetAllUsers).Methods("GET")
+	api.HandleFunc("/users:batch", userHandler.CreateUsersBatch).Methods("POST")
+	api.HandleFunc("/users:batchUpdate", userHandler.ReplaceUsersBatch).Methods("POST")
+	api.HandleFunc("/users:batchDelete", userHandler.DeleteUsersBatch).Methods("POST")
+	api.HandleFunc("/users/export", userHandler.ExportUsers).Methods("GET")
+	api.HandleFunc("/users/import", userHandler.ImportUsers).Methods("POST")
+	api.HandleFunc("/users/purge", userHandler.PurgeDeletedUsers).Methods("POST")
 	api.HandleFunc("/users/{id}", userHandler.GetUser).Methods("GET")
-	api.HandleFunc("/users/{id}", userHandler.UpdateUser).Methods("PUT")
+	api.HandleFunc("/users/{id}", userHandler.ReplaceUser).Methods("PUT")
+	api.HandleFunc("/users/{id}", userHandler.PatchUser).Methods("PATCH")
 	api.HandleFunc("/users/{id}", userHandler.DeleteUser).Methods("DELETE")
+	api.HandleFunc("/users/{id}/restore", userHandler.RestoreUser).Methods("POST")
 
-	// Ruta de health check
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		// nolint:errcheck // Error de escritura en respuesta HTTP, no hay recuperación posible
-		_, _ = w.Write([]byte("OK"))
-	}).Methods("GET")
+	// Sondas de liveness y readiness; /health se mantiene como alias de /readyz
+	router.Handle("/livez", checks.LiveHandler()).Methods("GET")
+	router.Handle("/readyz", checks.ReadyHandler()).Methods("GET")
+	router.Handle("/health", checks.ReadyHandler()).Methods("GET")
+
+	// Métricas en el formato de Prometheus
+	router.Handle("/metrics", m.Handler()).Methods("GET")
 
 	// Ruta de Swagger UI
 	router.PathPrefix("/swagger/").Handler(httpSwagger.Handler(
@@ -41,9 +57,13 @@ func SetupRoutes(userService services.UserService) http.Handler {
 		httpSwagger.DomID("swagger-ui"),
 	)).Methods("GET")
 
-	// Aplicar middleware (orden inverso: primero CORS, luego logging)
+	// Aplicar middleware (orden inverso: la petición pasa por trazas, identificador de petición,
+	// logging, métricas y CORS, de modo que los logs incluyan el request_id y la traza)
 	var handler http.Handler = middleware.NewCORSMiddleware(router)
+	handler = middleware.NewMetricsMiddleware(handler, router, m)
 	handler = middleware.NewLoggingMiddleware(handler)
+	handler = middleware.NewRequestIDMiddleware(handler)
+	handler = middleware.NewTracingMiddleware(handler, router)
 
 	return handler
 }
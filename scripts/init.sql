@@ -7,9 +7,7 @@ CREATE DATABASE IF NOT EXISTS usersdb CHARACTER SET utf8mb4 COLLATE utf8mb4_unic
 -- Usar la base de datos
 USE usersdb;
 
--- La tabla se crea automáticamente por el repositorio MySQL
--- pero podemos agregar índices adicionales si es necesario
-
--- Crear índice adicional para búsquedas por email (si no existe)
--- CREATE INDEX IF NOT EXISTS idx_email ON users(email);
+-- Las tablas se crean y actualizan con las migraciones versionadas en migrations/sql/mysql,
+-- que la API aplica al iniciar (MIGRATE_ON_START) o con `go run main.go migrate up`.
+-- No agregar DDL de tablas aquí: cualquier cambio de esquema debe ser una nueva migración.
 
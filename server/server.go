@@ -0,0 +1,69 @@
+// Package server ejecuta el servidor HTTP y lo detiene ordenadamente
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"helloworld/health"
+)
+
+// ShutdownOptions configura el apagado ordenado
+type ShutdownOptions struct {
+	// Delay es el tiempo entre marcar la instancia como no lista y dejar de aceptar conexiones,
+	// para que los balanceadores la quiten de rotación antes de que rechace peticiones
+	Delay time.Duration
+	// Timeout es el tiempo máximo de espera para las peticiones en curso; al vencer se cierran
+	// las conexiones restantes
+	Timeout time.Duration
+}
+
+// Run atiende las conexiones de listener con srv hasta que ctx se cancele y luego apaga el
+// servidor ordenadamente: marca readiness como no lista, espera opts.Delay, deja de aceptar
+// conexiones y espera hasta opts.Timeout a que terminen las peticiones en curso.
+// Retorna nil si todas las peticiones terminaron a tiempo.
+func Run(ctx context.Context, srv *http.Server, listener net.Listener, readiness *health.Readiness, opts ShutdownOptions) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		// El servidor terminó sin que se pidiera el apagado
+		return fmt.Errorf("error del servidor HTTP: %w", err)
+	case <-ctx.Done():
+	}
+
+	readiness.Drain()
+	slog.Info("Apagado iniciado: instancia marcada como no lista")
+	if opts.Delay > 0 {
+		slog.Info("Esperando antes de dejar de aceptar conexiones", "delay", opts.Delay.String())
+		select {
+		case <-time.After(opts.Delay):
+		case err := <-serveErr:
+			return fmt.Errorf("error del servidor HTTP: %w", err)
+		}
+	}
+
+	slog.Info("Drenando peticiones en curso", "timeout", opts.Timeout.String())
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		// Las peticiones que no terminaron a tiempo se cortan
+		_ = srv.Close() // nolint:errcheck // El error de Shutdown es más relevante
+		return fmt.Errorf("no todas las peticiones terminaron a tiempo: %w", err)
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error del servidor HTTP: %w", err)
+	}
+
+	slog.Info("Servidor detenido")
+	return nil
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"helloworld/health"
+)
+
+// testServer es un servidor en ejecución cuyo handler bloquea cada petición hasta que se cierre release
+type testServer struct {
+	addr      string
+	readiness *health.Readiness
+	started   chan struct{}
+	release   chan struct{}
+	cancel    context.CancelFunc
+	done      chan error
+}
+
+func startServer(t *testing.T, opts ShutdownOptions) *testServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+
+	ts := &testServer{
+		addr:      listener.Addr().String(),
+		readiness: health.NewReadiness(),
+		started:   make(chan struct{}, 1),
+		release:   make(chan struct{}),
+		done:      make(chan error, 1),
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ts.started <- struct{}{}
+		<-ts.release
+		_, _ = io.WriteString(w, "OK") // nolint:errcheck // El test verifica la respuesta en el cliente
+	})}
+
+	var ctx context.Context
+	ctx, ts.cancel = context.WithCancel(context.Background())
+	go func() {
+		ts.done <- Run(ctx, srv, listener, ts.readiness, opts)
+	}()
+	return ts
+}
+
+// get hace una petición en segundo plano y envía su error al canal retornado
+func (ts *testServer) get() <-chan error {
+	response := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + ts.addr)
+		if err == nil {
+			body, _ := io.ReadAll(resp.Body) // nolint:errcheck // Un cuerpo incompleto falla la comparación
+			resp.Body.Close()
+			if string(body) != "OK" {
+				err = errors.New("cuerpo inesperado: " + string(body))
+			}
+		}
+		response <- err
+	}()
+	return response
+}
+
+func TestRun_DrainsInFlightRequests(t *testing.T) {
+	ts := startServer(t, ShutdownOptions{Timeout: 5 * time.Second})
+	response := ts.get()
+	<-ts.started
+
+	ts.cancel()
+	// El apagado marca la instancia como no lista y deja de aceptar conexiones
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", ts.addr)
+		if err != nil {
+			break
+		}
+		conn.Close()
+		if time.Now().After(deadline) {
+			t.Fatal("el servidor sigue aceptando conexiones durante el apagado")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if ts.readiness.Ready() {
+		t.Error("Ready() = true durante el apagado")
+	}
+
+	select {
+	case err := <-ts.done:
+		t.Fatalf("Run() terminó con una petición en curso: %v", err)
+	default:
+	}
+
+	close(ts.release)
+	if err := <-response; err != nil {
+		t.Errorf("la petición en curso falló: %v", err)
+	}
+	if err := <-ts.done; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+}
+
+func TestRun_DelayKeepsAcceptingConnections(t *testing.T) {
+	ts := startServer(t, ShutdownOptions{Delay: 300 * time.Millisecond, Timeout: time.Second})
+	close(ts.release)
+
+	ts.cancel()
+	time.Sleep(50 * time.Millisecond)
+	if ts.readiness.Ready() {
+		t.Error("Ready() = true durante el apagado")
+	}
+
+	// Durante Delay la instancia ya no está lista pero sigue atendiendo a quien todavía la usa
+	if err := <-ts.get(); err != nil {
+		t.Fatalf("petición durante Delay error = %v", err)
+	}
+	<-ts.started
+
+	if err := <-ts.done; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+}
+
+func TestRun_TimeoutClosesPendingRequests(t *testing.T) {
+	ts := startServer(t, ShutdownOptions{Timeout: 100 * time.Millisecond})
+	defer close(ts.release)
+
+	response := ts.get()
+	<-ts.started
+
+	ts.cancel()
+	if err := <-ts.done; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Run() error = %v, esperaba %v", err, context.DeadlineExceeded)
+	}
+	// La conexión pendiente se cierra al vencer el timeout
+	if err := <-response; err == nil {
+		t.Error("la petición pendiente no fue cortada")
+	}
+}
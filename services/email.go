@@ -0,0 +1,91 @@
+package services
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/mail"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// maxEmailLocalLength es la longitud máxima de la parte local según RFC 5321
+const maxEmailLocalLength = 64
+
+// errMalformedEmail indica una dirección que no es un addr-spec RFC 5322 válido
+var errMalformedEmail = errors.New("dirección de email mal formada")
+
+// normalizeEmail valida una dirección según RFC 5322 y la retorna en forma canónica:
+// sin espacios alrededor, en minúsculas y con el dominio en ASCII (punycode para dominios IDN).
+// Solo acepta la dirección sola, sin nombre visible ("Juan <juan@example.com>").
+func normalizeEmail(raw string) (string, error) {
+	trimmed := strings.TrimSpace(raw)
+
+	address, err := mail.ParseAddress(trimmed)
+	if err != nil || address.Name != "" {
+		return "", errMalformedEmail
+	}
+	// ParseAddress también acepta "<addr>", comentarios y espacios entre tokens; la forma
+	// canónica (con la parte local entre comillas solo si hace falta) debe coincidir con la entrada
+	if strings.TrimSuffix(strings.TrimPrefix(address.String(), "<"), ">") != trimmed {
+		return "", errMalformedEmail
+	}
+
+	at := strings.LastIndex(trimmed, "@")
+	local, domain := trimmed[:at], trimmed[at+1:]
+	if local == "" || len(local) > maxEmailLocalLength {
+		return "", errMalformedEmail
+	}
+
+	asciiDomain, err := idna.Lookup.ToASCII(domain)
+	if err != nil || !strings.Contains(asciiDomain, ".") {
+		return "", errMalformedEmail
+	}
+
+	return strings.ToLower(local) + "@" + strings.ToLower(asciiDomain), nil
+}
+
+// emailDomain retorna el dominio de una dirección ya normalizada
+func emailDomain(email string) string {
+	return email[strings.LastIndex(email, "@")+1:]
+}
+
+// LoadEmailBlocklist lee una lista de dominios de email descartables, uno por línea.
+// Las líneas vacías y las que comienzan con "#" se ignoran; los dominios IDN se aceptan
+// en Unicode o en punycode.
+func LoadEmailBlocklist(r io.Reader) (map[string]struct{}, error) {
+	domains := make(map[string]struct{})
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		domain, err := idna.Lookup.ToASCII(entry)
+		if err != nil {
+			return nil, fmt.Errorf("dominio inválido en la línea %d: %q", line, entry)
+		}
+		domains[strings.ToLower(domain)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error al leer la lista de dominios: %w", err)
+	}
+	return domains, nil
+}
+
+// isBlockedDomain indica si el dominio o alguno de sus dominios padre está en la lista de bloqueo
+func (s *userService) isBlockedDomain(domain string) bool {
+	for domain != "" {
+		if _, blocked := s.blockedDomains[domain]; blocked {
+			return true
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found {
+			break
+		}
+		domain = parent
+	}
+	return false
+}
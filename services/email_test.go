@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"helloworld/models"
+	"helloworld/validation"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want string
+	}{
+		{raw: "juan@example.com", want: "juan@example.com"},
+		{raw: "  Juan.Perez@Example.COM ", want: "juan.perez@example.com"},
+		{raw: "juan+tag@sub.example.com", want: "juan+tag@sub.example.com"},
+		{raw: `"juan perez"@example.com`, want: `"juan perez"@example.com`},
+		{raw: "josé@ejemplo.españa", want: "josé@ejemplo.xn--espaa-rta"},
+		{raw: "user@bücher.de", want: "user@xn--bcher-kva.de"},
+		{raw: "@@"},
+		{raw: "a@"},
+		{raw: "@example.com"},
+		{raw: "juan@localhost"},
+		{raw: "juan@@example.com"},
+		{raw: "juan example@example.com"},
+		{raw: "Juan <juan@example.com>"},
+		{raw: "juan@example..com"},
+		{raw: strings.Repeat("a", 65) + "@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := normalizeEmail(tt.raw)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("normalizeEmail(%q) = %q, esperaba error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("normalizeEmail(%q) = %q, %v, esperaba %q", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadEmailBlocklist(t *testing.T) {
+	blocklist, err := LoadEmailBlocklist(strings.NewReader("# descartables\n\nMailinator.com\ncorreo.españa\n"))
+	if err != nil {
+		t.Fatalf("LoadEmailBlocklist() error = %v", err)
+	}
+
+	service := NewUserService(newMockRepository(), WithEmailBlocklist(blocklist))
+	ctx := context.Background()
+
+	for _, email := range []string{"a@mailinator.com", "b@eu.MAILINATOR.com", "c@correo.españa"} {
+		_, err := service.CreateUser(ctx, models.CreateUserRequest{Name: "Ana", Email: email, Age: 30})
+		var errs validation.Errors
+		if !errors.As(err, &errs) || errs[0].Rule != ruleBlockedDomain {
+			t.Errorf("CreateUser(%q) error = %v, esperaba %s", email, err, ruleBlockedDomain)
+		}
+	}
+
+	user, err := service.CreateUser(ctx, models.CreateUserRequest{Name: "Ana", Email: " Ana@NotMailinator.com ", Age: 30})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if user.Email != "ana@notmailinator.com" {
+		t.Errorf("CreateUser() email = %q, esperaba el email normalizado", user.Email)
+	}
+
+	if _, err := LoadEmailBlocklist(strings.NewReader("ok.com\nno válido\n")); err == nil {
+		t.Errorf("LoadEmailBlocklist() con dominio inválido no retornó error")
+	}
+}
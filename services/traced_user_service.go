@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+
+	"helloworld/models"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer crea un span por cada operación del servicio de usuarios
+func tracer() trace.Tracer {
+	return otel.Tracer("helloworld/services")
+}
+
+// Atributos de los spans del servicio
+const (
+	attrUserID    = attribute.Key("user.id")
+	attrBatchMode = attribute.Key("batch.mode")
+	attrBatchSize = attribute.Key("batch.size")
+	attrDryRun    = attribute.Key("import.dry_run")
+)
+
+// tracedUserService crea un span por cada operación de otro UserService
+type tracedUserService struct {
+	service UserService
+}
+
+// Trace envuelve service para crear un span "UserService.<método>" por operación, hijo del span
+// de la petición HTTP y padre de los de las consultas del repositorio
+func Trace(service UserService) UserService {
+	return &tracedUserService{service: service}
+}
+
+// start inicia el span de method; se termina con endSpan
+func (s *tracedUserService) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, "UserService."+method, trace.WithAttributes(attrs...))
+}
+
+// endSpan registra el error de la operación, si lo hubo, y termina el span. Recibe un puntero
+// para usarse con defer y el error con nombre que retorna la operación.
+func endSpan(span trace.Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
+
+func (s *tracedUserService) CreateUser(ctx context.Context, req models.CreateUserRequest) (user *models.User, err error) {
+	ctx, span := s.start(ctx, "CreateUser")
+	defer endSpan(span, &err)
+	return s.service.CreateUser(ctx, req)
+}
+
+func (s *tracedUserService) GetUserByID(ctx context.Context, id string, fields models.UserFields) (user *models.User, err error) {
+	ctx, span := s.start(ctx, "GetUserByID", attrUserID.String(id))
+	defer endSpan(span, &err)
+	return s.service.GetUserByID(ctx, id, fields)
+}
+
+func (s *tracedUserService) GetAllUsers(ctx context.Context, query models.UserQuery) (page *models.UserPage, err error) {
+	ctx, span := s.start(ctx, "GetAllUsers")
+	defer endSpan(span, &err)
+	return s.service.GetAllUsers(ctx, query)
+}
+
+func (s *tracedUserService) ReplaceUser(ctx context.Context, id string, version int64, req models.ReplaceUserRequest) (user *models.User, err error) {
+	ctx, span := s.start(ctx, "ReplaceUser", attrUserID.String(id))
+	defer endSpan(span, &err)
+	return s.service.ReplaceUser(ctx, id, version, req)
+}
+
+func (s *tracedUserService) PatchUser(ctx context.Context, id string, version int64, patch UserPatch) (user *models.User, err error) {
+	ctx, span := s.start(ctx, "PatchUser", attrUserID.String(id))
+	defer endSpan(span, &err)
+	return s.service.PatchUser(ctx, id, version, patch)
+}
+
+func (s *tracedUserService) DeleteUser(ctx context.Context, id string, version int64) (err error) {
+	ctx, span := s.start(ctx, "DeleteUser", attrUserID.String(id))
+	defer endSpan(span, &err)
+	return s.service.DeleteUser(ctx, id, version)
+}
+
+func (s *tracedUserService) RestoreUser(ctx context.Context, id string) (user *models.User, err error) {
+	ctx, span := s.start(ctx, "RestoreUser", attrUserID.String(id))
+	defer endSpan(span, &err)
+	return s.service.RestoreUser(ctx, id)
+}
+
+func (s *tracedUserService) PurgeDeletedUsers(ctx context.Context) (purged int64, err error) {
+	ctx, span := s.start(ctx, "PurgeDeletedUsers")
+	defer endSpan(span, &err)
+	return s.service.PurgeDeletedUsers(ctx)
+}
+
+func (s *tracedUserService) CreateUsers(ctx context.Context, mode models.BatchMode, reqs []models.CreateUserRequest) (results []models.BatchResult, err error) {
+	ctx, span := s.start(ctx, "CreateUsers", attrBatchMode.String(string(mode)), attrBatchSize.Int(len(reqs)))
+	defer endSpan(span, &err)
+	return s.service.CreateUsers(ctx, mode, reqs)
+}
+
+func (s *tracedUserService) ReplaceUsers(ctx context.Context, mode models.BatchMode, items []models.ReplaceUserItem) (results []models.BatchResult, err error) {
+	ctx, span := s.start(ctx, "ReplaceUsers", attrBatchMode.String(string(mode)), attrBatchSize.Int(len(items)))
+	defer endSpan(span, &err)
+	return s.service.ReplaceUsers(ctx, mode, items)
+}
+
+func (s *tracedUserService) DeleteUsers(ctx context.Context, mode models.BatchMode, refs []models.UserRef) (results []models.BatchResult, err error) {
+	ctx, span := s.start(ctx, "DeleteUsers", attrBatchMode.String(string(mode)), attrBatchSize.Int(len(refs)))
+	defer endSpan(span, &err)
+	return s.service.DeleteUsers(ctx, mode, refs)
+}
+
+func (s *tracedUserService) ExportUsers(ctx context.Context, filter models.UserFilter, sort models.UserSort, fn func(*models.User) error) (err error) {
+	ctx, span := s.start(ctx, "ExportUsers")
+	defer endSpan(span, &err)
+	return s.service.ExportUsers(ctx, filter, sort, fn)
+}
+
+func (s *tracedUserService) ImportUsers(ctx context.Context, rows UserRowReader, dryRun bool) (result *models.ImportResult, err error) {
+	ctx, span := s.start(ctx, "ImportUsers", attrDryRun.Bool(dryRun))
+	defer endSpan(span, &err)
+	return s.service.ImportUsers(ctx, rows, dryRun)
+}
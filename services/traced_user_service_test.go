@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"helloworld/models"
+	"helloworld/repositories"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "petición")
+	service := Trace(NewUserService(newMockRepository()))
+
+	user, err := service.CreateUser(ctx, models.CreateUserRequest{Name: "Juan Pérez", Email: "juan@example.com", Age: 30})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if _, err := service.GetUserByID(ctx, "inexistente", nil); !errors.Is(err, repositories.ErrUserNotFound) {
+		t.Fatalf("GetUserByID() error = %v, esperaba %v", err, repositories.ErrUserNotFound)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("spans = %d, esperaba 3", len(spans))
+	}
+
+	created, missing := spans[0], spans[1]
+	if created.Name() != "UserService.CreateUser" || created.Status().Code == codes.Error {
+		t.Errorf("span = %s (%v), esperaba UserService.CreateUser sin error", created.Name(), created.Status())
+	}
+	if missing.Name() != "UserService.GetUserByID" || missing.Status().Code != codes.Error {
+		t.Errorf("span = %s (%v), esperaba UserService.GetUserByID con error", missing.Name(), missing.Status())
+	}
+	for _, span := range []sdktrace.ReadOnlySpan{created, missing} {
+		if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("el span %s no es hijo del span de la petición", span.Name())
+		}
+	}
+
+	var userID string
+	for _, attr := range missing.Attributes() {
+		if attr.Key == attrUserID {
+			userID = attr.Value.AsString()
+		}
+	}
+	if userID != "inexistente" {
+		t.Errorf("user.id = %q, esperaba inexistente", userID)
+	}
+	if user.ID == "" {
+		t.Errorf("CreateUser() no retornó el usuario creado")
+	}
+}
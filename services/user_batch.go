@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"helloworld/models"
+	"helloworld/repositories"
+)
+
+// CreateUsers crea un lote de usuarios. Cada elemento se valida como en CreateUser y los válidos
+// se guardan con una única operación del repositorio.
+func (s *userService) CreateUsers(ctx context.Context, mode models.BatchMode, reqs []models.CreateUserRequest) ([]models.BatchResult, error) {
+	mode = mode.OrDefault()
+	if err := validateBatch(mode, len(reqs)); err != nil {
+		return nil, err
+	}
+
+	results, users, indexes, err := s.prepareCreate(ctx, reqs, make(map[string]bool, len(reqs)))
+	if err != nil {
+		return nil, err
+	}
+	if len(users) == 0 {
+		return results, nil
+	}
+	if mode == models.BatchModeAtomic && len(users) < len(reqs) {
+		abortBatch(results)
+		return results, nil
+	}
+
+	if err := s.createPrepared(ctx, mode, users, indexes, results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// prepareCreate valida cada solicitud como newUser y retorna el resultado de cada una junto con
+// los usuarios a crear y su posición en reqs. emails acumula los emails ya usados en el lote
+// (o en lotes anteriores de la misma operación) y se considera ocupado cualquiera de ellos.
+// Los emails ya registrados se buscan con una única consulta para todo el lote; el índice
+// único sigue rechazando los que se registren concurrentemente.
+func (s *userService) prepareCreate(ctx context.Context, reqs []models.CreateUserRequest, emails map[string]bool) ([]models.BatchResult, []*models.User, []int, error) {
+	results := make([]models.BatchResult, len(reqs))
+	built := make([]*models.User, len(reqs))
+	candidates := make([]string, 0, len(reqs))
+	for i, req := range reqs {
+		user, err := s.buildUser(req)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		built[i] = user
+		candidates = append(candidates, user.Email)
+	}
+
+	taken, err := s.takenEmails(ctx, candidates)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	users := make([]*models.User, 0, len(candidates))
+	indexes := make([]int, 0, len(candidates))
+	for i, user := range built {
+		if user == nil {
+			continue
+		}
+		if taken[user.Email] || emails[user.Email] {
+			results[i].Err = repositories.ErrEmailAlreadyExists
+			continue
+		}
+		emails[user.Email] = true
+		users = append(users, user)
+		indexes = append(indexes, i)
+	}
+	return results, users, indexes, nil
+}
+
+// createPrepared guarda los usuarios preparados por prepareCreate y completa sus resultados.
+// Solo retorna error en modo atómico; en modo parcial los errores quedan en cada resultado.
+func (s *userService) createPrepared(ctx context.Context, mode models.BatchMode, users []*models.User, indexes []int, results []models.BatchResult) error {
+	if err := s.repo.CreateBatch(ctx, users); err != nil {
+		if mode == models.BatchModeAtomic {
+			return fmt.Errorf("error al crear usuarios: %w", err)
+		}
+		// El INSERT multi-fila no indica qué elemento falló (por ejemplo, un email registrado
+		// concurrentemente); en modo parcial se reintenta cada usuario por separado
+		for j, user := range users {
+			if err := s.repo.Create(ctx, user); err != nil {
+				results[indexes[j]].Err = fmt.Errorf("error al crear usuario: %w", err)
+				continue
+			}
+			results[indexes[j]] = models.BatchResult{ID: user.ID, User: user}
+		}
+		return nil
+	}
+
+	for j, user := range users {
+		results[indexes[j]] = models.BatchResult{ID: user.ID, User: user}
+	}
+	return nil
+}
+
+// ReplaceUsers reemplaza un lote de usuarios con las mismas reglas que ReplaceUser.
+// En modo atómico todas las escrituras se aplican en una única transacción.
+func (s *userService) ReplaceUsers(ctx context.Context, mode models.BatchMode, items []models.ReplaceUserItem) ([]models.BatchResult, error) {
+	mode = mode.OrDefault()
+	if err := validateBatch(mode, len(items)); err != nil {
+		return nil, err
+	}
+
+	results := make([]models.BatchResult, len(items))
+	if mode == models.BatchModePartial {
+		for i, item := range items {
+			user, err := s.ReplaceUser(ctx, item.ID, item.Version, item.ReplaceUserRequest)
+			results[i] = models.BatchResult{ID: item.ID, User: user, Err: err}
+		}
+		return results, nil
+	}
+
+	users := make([]*models.User, len(items))
+	emails := make(map[string]bool, len(items))
+	failed := false
+	for i, item := range items {
+		results[i].ID = item.ID
+		user, err := s.prepareReplace(ctx, item)
+		if err == nil && emails[user.Email] {
+			err = repositories.ErrEmailAlreadyExists
+		}
+		if err != nil {
+			results[i].Err = err
+			failed = true
+			continue
+		}
+		emails[user.Email] = true
+		users[i] = user
+	}
+	if failed {
+		abortBatch(results)
+		return results, nil
+	}
+
+	if err := s.repo.UpdateBatch(ctx, users); err != nil {
+		var batchErr *repositories.BatchError
+		if !errors.As(err, &batchErr) {
+			return nil, fmt.Errorf("error al actualizar usuarios: %w", err)
+		}
+		results[batchErr.Index].Err = fmt.Errorf("error al actualizar usuario: %w", batchErr.Err)
+		abortBatch(results)
+		return results, nil
+	}
+
+	for i, user := range users {
+		results[i].User = user
+	}
+	return results, nil
+}
+
+// DeleteUsers elimina lógicamente un lote de usuarios, verificando la versión de los que la indican.
+// En modo atómico todas las eliminaciones se aplican en una única transacción.
+func (s *userService) DeleteUsers(ctx context.Context, mode models.BatchMode, refs []models.UserRef) ([]models.BatchResult, error) {
+	mode = mode.OrDefault()
+	if err := validateBatch(mode, len(refs)); err != nil {
+		return nil, err
+	}
+
+	results := make([]models.BatchResult, len(refs))
+	for i, ref := range refs {
+		results[i].ID = ref.ID
+	}
+
+	if mode == models.BatchModePartial {
+		for i, ref := range refs {
+			results[i].Err = s.DeleteUser(ctx, ref.ID, ref.Version)
+		}
+		return results, nil
+	}
+
+	if err := s.repo.DeleteBatch(ctx, refs); err != nil {
+		var batchErr *repositories.BatchError
+		if !errors.As(err, &batchErr) {
+			return nil, fmt.Errorf("error al eliminar usuarios: %w", err)
+		}
+		results[batchErr.Index].Err = fmt.Errorf("error al eliminar usuario: %w", batchErr.Err)
+		abortBatch(results)
+	}
+	return results, nil
+}
+
+// prepareReplace valida item y retorna el usuario con los nuevos datos, sin guardarlo
+func (s *userService) prepareReplace(ctx context.Context, item models.ReplaceUserItem) (*models.User, error) {
+	if err := s.validateReplaceRequest(item.ReplaceUserRequest); err != nil {
+		return nil, err
+	}
+
+	user, err := s.getForWrite(ctx, item.ID, item.Version)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyReplace(ctx, user, item.ReplaceUserRequest); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// validateBatch verifica el modo y la cantidad de elementos de un lote
+func validateBatch(mode models.BatchMode, size int) error {
+	if !mode.Valid() {
+		return ErrInvalidBatchMode
+	}
+	if size < 1 || size > models.MaxBatchSize {
+		return ErrInvalidBatchSize
+	}
+	return nil
+}
+
+// abortBatch marca con ErrBatchAborted los elementos sin error de un lote atómico que no se aplicó
+func abortBatch(results []models.BatchResult) {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].User = nil
+			results[i].Err = ErrBatchAborted
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"helloworld/models"
+)
+
+// UserRowReader entrega una a una las filas de una importación
+type UserRowReader interface {
+	// Next retorna la siguiente fila, io.EOF al terminar o un error que aborta la importación.
+	// Las filas ilegibles se retornan con ImportRow.Err y la importación continúa.
+	Next() (models.ImportRow, error)
+}
+
+// ExportUsers recorre los usuarios que cumplen filter con el repositorio, sin paginar.
+// Un orden vacío usa models.DefaultUserSort. Los errores de fn se retornan sin envolver.
+func (s *userService) ExportUsers(ctx context.Context, filter models.UserFilter, sort models.UserSort, fn func(*models.User) error) error {
+	if sort.Field == "" {
+		sort = models.DefaultUserSort
+	}
+	if !sort.Field.Valid() {
+		return models.ErrInvalidSort
+	}
+	filter, err := prepareFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	var fnErr error
+	err = s.repo.Stream(ctx, filter, sort, func(user *models.User) error {
+		fnErr = fn(user)
+		return fnErr
+	})
+	if err != nil {
+		if fnErr != nil {
+			return fnErr
+		}
+		return fmt.Errorf("error al exportar usuarios: %w", err)
+	}
+	return nil
+}
+
+// ImportUsers crea los usuarios de rows en lotes de hasta models.MaxBatchSize filas, validando cada
+// una como CreateUser. Las filas inválidas se informan y no impiden crear las demás; un email
+// repetido dentro del archivo se rechaza desde su segunda aparición.
+//
+// Antes de escribir se lee y valida el archivo completo (su tamaño ya está acotado por el
+// handler): un error de lectura, como un cuerpo demasiado grande o un CSV mal formado, aborta
+// la importación sin haber creado ningún usuario.
+func (s *userService) ImportUsers(ctx context.Context, rows UserRowReader, dryRun bool) (*models.ImportResult, error) {
+	result := &models.ImportResult{DryRun: dryRun}
+	reqs := make([]models.CreateUserRequest, 0, models.MaxBatchSize)
+	lines := make([]int, 0, models.MaxBatchSize)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		row, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		result.Rows++
+		if row.Err != nil {
+			result.Failures = append(result.Failures, models.ImportFailure{Line: row.Line, Err: row.Err})
+			continue
+		}
+		reqs = append(reqs, row.User)
+		lines = append(lines, row.Line)
+	}
+
+	// importBatch es un lote validado, pendiente de escribirse
+	type importBatch struct {
+		lines   []int
+		results []models.BatchResult
+		users   []*models.User
+		indexes []int
+	}
+	batches := make([]importBatch, 0, (len(reqs)+models.MaxBatchSize-1)/models.MaxBatchSize)
+	emails := make(map[string]bool, len(reqs))
+	for start := 0; start < len(reqs); start += models.MaxBatchSize {
+		end := min(start+models.MaxBatchSize, len(reqs))
+		results, users, indexes, err := s.prepareCreate(ctx, reqs[start:end], emails)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, importBatch{lines: lines[start:end], results: results, users: users, indexes: indexes})
+	}
+
+	for _, batch := range batches {
+		if !dryRun && len(batch.users) > 0 {
+			if err := s.createPrepared(ctx, models.BatchModePartial, batch.users, batch.indexes, batch.results); err != nil {
+				return nil, err
+			}
+		}
+		for i, r := range batch.results {
+			if r.Err != nil {
+				result.Failures = append(result.Failures, models.ImportFailure{Line: batch.lines[i], Err: r.Err})
+				continue
+			}
+			result.Imported++
+		}
+	}
+
+	// Las filas ilegibles se registran al leerlas y las inválidas al procesar su lote
+	sort.SliceStable(result.Failures, func(i, j int) bool {
+		return result.Failures[i].Line < result.Failures[j].Line
+	})
+	return result, nil
+}
@@ -1,138 +1,355 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"helloworld/models"
 	"helloworld/repositories"
 )
 
 var (
-	ErrInvalidEmail = errors.New("email inválido")
-	ErrInvalidAge   = errors.New("la edad debe ser mayor a 0")
-	ErrInvalidName  = errors.New("el nombre no puede estar vacío")
+	ErrInvalidEmail     = errors.New("email inválido")
+	ErrInvalidAge       = fmt.Errorf("la edad debe estar entre %d y %d", MinAge, MaxAge)
+	ErrInvalidName      = errors.New("el nombre no puede estar vacío")
+	ErrInvalidLimit     = fmt.Errorf("el límite debe estar entre 1 y %d", models.MaxPageLimit)
+	ErrInvalidOffset    = errors.New("el offset debe ser mayor o igual a 0 y no puede combinarse con cursor")
+	ErrInvalidAgeRange  = errors.New("min_age no puede ser mayor que max_age")
+	ErrInvalidDateRange = errors.New("created_after debe ser anterior a created_before")
+	ErrInvalidBatchSize = fmt.Errorf("el lote debe tener entre 1 y %d elementos", models.MaxBatchSize)
+	ErrInvalidBatchMode = errors.New("mode debe ser atomic o partial")
+	// ErrBatchAborted es el resultado de los elementos válidos de un lote atómico que no se aplicó
+	ErrBatchAborted = errors.New("no se aplicó porque otro elemento del lote falló")
 )
 
-// UserService maneja la lógica de negocio relacionada con usuarios
+// UserService maneja la lógica de negocio relacionada con usuarios.
+// ctx se propaga al repositorio para que la cancelación aborte el trabajo en la base de datos.
+// ReplaceUser, PatchUser y DeleteUser reciben la versión esperada del usuario (models.AnyVersion
+// para no verificarla) y retornan repositories.ErrVersionConflict si no coincide.
 type UserService interface {
-	CreateUser(req models.CreateUserRequest) (*models.User, error)
-	GetUserByID(id string) (*models.User, error)
-	GetAllUsers() ([]*models.User, error)
-	UpdateUser(id string, req models.UpdateUserRequest) (*models.User, error)
-	DeleteUser(id string) error
+	CreateUser(ctx context.Context, req models.CreateUserRequest) (*models.User, error)
+	// GetUserByID y GetAllUsers (con query.Fields) leen solo los campos seleccionados cuando el
+	// repositorio lo permite; fields vacía lee todos
+	GetUserByID(ctx context.Context, id string, fields models.UserFields) (*models.User, error)
+	GetAllUsers(ctx context.Context, query models.UserQuery) (*models.UserPage, error)
+	ReplaceUser(ctx context.Context, id string, version int64, req models.ReplaceUserRequest) (*models.User, error)
+	PatchUser(ctx context.Context, id string, version int64, patch UserPatch) (*models.User, error)
+	DeleteUser(ctx context.Context, id string, version int64) error
+	RestoreUser(ctx context.Context, id string) (*models.User, error)
+	PurgeDeletedUsers(ctx context.Context) (int64, error)
+	// Las operaciones por lotes retornan un resultado por elemento, en el mismo orden.
+	// En modo atómico, si algún elemento falla no se aplica ninguno y el resto recibe ErrBatchAborted.
+	CreateUsers(ctx context.Context, mode models.BatchMode, reqs []models.CreateUserRequest) ([]models.BatchResult, error)
+	ReplaceUsers(ctx context.Context, mode models.BatchMode, items []models.ReplaceUserItem) ([]models.BatchResult, error)
+	DeleteUsers(ctx context.Context, mode models.BatchMode, refs []models.UserRef) ([]models.BatchResult, error)
+	// ExportUsers llama a fn con cada usuario que cumple filter, en el orden sort, sin cargarlos en memoria
+	ExportUsers(ctx context.Context, filter models.UserFilter, sort models.UserSort, fn func(*models.User) error) error
+	// ImportUsers crea los usuarios válidos leídos de rows y retorna el resultado por fila;
+	// con dryRun solo los valida
+	ImportUsers(ctx context.Context, rows UserRowReader, dryRun bool) (*models.ImportResult, error)
 }
 
+// UserPatch calcula los datos completos de un usuario a partir de su estado actual.
+// Sus errores se retornan sin envolver para que quien construyó el patch pueda clasificarlos.
+type UserPatch func(current models.User) (models.ReplaceUserRequest, error)
+
+// DefaultPurgeRetention es el tiempo que un usuario eliminado se conserva antes de poder purgarse
+const DefaultPurgeRetention = 30 * 24 * time.Hour
+
 type userService struct {
-	repo repositories.UserRepository
+	repo           repositories.UserRepository
+	purgeRetention time.Duration
+	blockedDomains map[string]struct{}
+}
+
+// Option configura el servicio de usuarios
+type Option func(*userService)
+
+// WithPurgeRetention define cuánto tiempo se conservan los usuarios eliminados antes de purgarlos
+func WithPurgeRetention(retention time.Duration) Option {
+	return func(s *userService) {
+		s.purgeRetention = retention
+	}
+}
+
+// WithEmailBlocklist rechaza los emails de los dominios indicados y de sus subdominios.
+// Los dominios deben estar en minúsculas y en ASCII, como los retorna LoadEmailBlocklist.
+func WithEmailBlocklist(domains map[string]struct{}) Option {
+	return func(s *userService) {
+		s.blockedDomains = domains
+	}
 }
 
 // NewUserService crea una nueva instancia del servicio de usuarios
-func NewUserService(repo repositories.UserRepository) UserService {
-	return &userService{
-		repo: repo,
+func NewUserService(repo repositories.UserRepository, opts ...Option) UserService {
+	s := &userService{
+		repo:           repo,
+		purgeRetention: DefaultPurgeRetention,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // CreateUser crea un nuevo usuario con validación
-func (s *userService) CreateUser(req models.CreateUserRequest) (*models.User, error) {
-	if err := s.validateCreateRequest(req); err != nil {
+func (s *userService) CreateUser(ctx context.Context, req models.CreateUserRequest) (*models.User, error) {
+	user, err := s.newUser(ctx, req)
+	if err != nil {
 		return nil, err
 	}
 
-	user := models.NewUser(req)
-	if err := s.repo.Create(user); err != nil {
+	if err := s.repo.Create(ctx, user); err != nil {
 		return nil, fmt.Errorf("error al crear usuario: %w", err)
 	}
 
 	return user, nil
 }
 
-// GetUserByID obtiene un usuario por su ID
-func (s *userService) GetUserByID(id string) (*models.User, error) {
-	user, err := s.repo.GetByID(id)
+// newUser valida req y construye el usuario a crear, con sus datos normalizados
+func (s *userService) newUser(ctx context.Context, req models.CreateUserRequest) (*models.User, error) {
+	user, err := s.buildUser(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureEmailAvailable(ctx, user.Email, ""); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// buildUser valida req y construye el usuario con sus datos normalizados, sin verificar
+// que el email esté disponible
+func (s *userService) buildUser(req models.CreateUserRequest) (*models.User, error) {
+	if err := s.validateCreateRequest(req); err != nil {
+		return nil, err
+	}
+	// La validación garantiza que el email puede normalizarse
+	req.Email, _ = normalizeEmail(req.Email) // nolint:errcheck // Validado en validateCreateRequest
+
+	req.Name = strings.TrimSpace(req.Name)
+	return models.NewUser(req), nil
+}
+
+// GetUserByID obtiene los campos fields de un usuario por su ID
+func (s *userService) GetUserByID(ctx context.Context, id string, fields models.UserFields) (*models.User, error) {
+	if !fields.Valid() {
+		return nil, models.ErrInvalidFields
+	}
+	user, err := s.repo.GetFieldsByID(ctx, id, fields)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener usuario: %w", err)
 	}
 	return user, nil
 }
 
-// GetAllUsers obtiene todos los usuarios
-func (s *userService) GetAllUsers() ([]*models.User, error) {
-	users, err := s.repo.GetAll()
+// GetAllUsers obtiene una página de usuarios filtrada y ordenada.
+// Un límite 0 usa models.DefaultPageLimit y un orden vacío usa models.DefaultUserSort.
+func (s *userService) GetAllUsers(ctx context.Context, query models.UserQuery) (*models.UserPage, error) {
+	if query.Limit == 0 {
+		query.Limit = models.DefaultPageLimit
+	}
+	if query.Limit < 0 || query.Limit > models.MaxPageLimit {
+		return nil, ErrInvalidLimit
+	}
+	if query.Offset < 0 || (query.Offset > 0 && query.Cursor != nil) {
+		return nil, ErrInvalidOffset
+	}
+	if query.Sort.Field == "" {
+		query.Sort = models.DefaultUserSort
+	}
+	if !query.Sort.Field.Valid() {
+		return nil, models.ErrInvalidSort
+	}
+	// Un cursor solo es válido con el mismo orden con el que fue generado
+	if query.Cursor != nil && query.Cursor.Sort != query.Sort.String() {
+		return nil, models.ErrInvalidCursor
+	}
+	if !query.Fields.Valid() {
+		return nil, models.ErrInvalidFields
+	}
+	filter, err := prepareFilter(query.Filter)
+	if err != nil {
+		return nil, err
+	}
+	query.Filter = filter
+
+	page, err := s.repo.GetAll(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener usuarios: %w", err)
 	}
-	return users, nil
+
+	page.Limit = query.Limit
+	page.Offset = query.Offset
+	return page, nil
+}
+
+// prepareFilter valida filter y lo adapta a cómo se guardan los datos
+func prepareFilter(filter models.UserFilter) (models.UserFilter, error) {
+	if err := validateFilter(filter); err != nil {
+		return filter, err
+	}
+	// Los emails se guardan normalizados; un filtro que no es un email válido no coincidirá con ninguno
+	if normalized, err := normalizeEmail(filter.Email); err == nil {
+		filter.Email = normalized
+	}
+	return filter, nil
+}
+
+// ReplaceUser reemplaza los datos de un usuario existente. La escritura es condicional a la versión
+// leída, por lo que una modificación concurrente entre la lectura y la escritura no se pierde.
+func (s *userService) ReplaceUser(ctx context.Context, id string, version int64, req models.ReplaceUserRequest) (*models.User, error) {
+	if err := s.validateReplaceRequest(req); err != nil {
+		return nil, err
+	}
+
+	existingUser, err := s.getForWrite(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	return s.replace(ctx, existingUser, req)
 }
 
-// UpdateUser actualiza un usuario existente
-func (s *userService) UpdateUser(id string, req models.UpdateUserRequest) (*models.User, error) {
-	existingUser, err := s.repo.GetByID(id)
+// PatchUser aplica patch al estado actual de un usuario y guarda el resultado tras validarlo
+// con las mismas reglas que ReplaceUser
+func (s *userService) PatchUser(ctx context.Context, id string, version int64, patch UserPatch) (*models.User, error) {
+	existingUser, err := s.getForWrite(ctx, id, version)
 	if err != nil {
-		return nil, fmt.Errorf("error al obtener usuario: %w", err)
+		return nil, err
 	}
 
-	// Aplicar actualizaciones parciales
-	if req.Name != nil {
-		if *req.Name == "" {
-			return nil, ErrInvalidName
-		}
-		existingUser.Name = *req.Name
+	req, err := patch(*existingUser)
+	if err != nil {
+		return nil, err
 	}
-	if req.Email != nil {
-		if !s.isValidEmail(*req.Email) {
-			return nil, ErrInvalidEmail
-		}
-		existingUser.Email = *req.Email
+	if err := s.validateReplaceRequest(req); err != nil {
+		return nil, err
 	}
-	if req.Age != nil {
-		if *req.Age <= 0 {
-			return nil, ErrInvalidAge
-		}
-		existingUser.Age = *req.Age
+	return s.replace(ctx, existingUser, req)
+}
+
+// getForWrite obtiene el usuario a modificar y verifica que tenga la versión esperada
+func (s *userService) getForWrite(ctx context.Context, id string, version int64) (*models.User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener usuario: %w", err)
+	}
+	if version != models.AnyVersion && user.Version != version {
+		return nil, repositories.ErrVersionConflict
+	}
+	return user, nil
+}
+
+// replace guarda req sobre user, condicionado a la versión con la que se leyó. req debe estar validado.
+func (s *userService) replace(ctx context.Context, user *models.User, req models.ReplaceUserRequest) (*models.User, error) {
+	if err := s.applyReplace(ctx, user, req); err != nil {
+		return nil, err
 	}
 
-	if err := s.repo.Update(id, existingUser); err != nil {
+	if err := s.repo.Update(ctx, user.ID, user); err != nil {
 		return nil, fmt.Errorf("error al actualizar usuario: %w", err)
 	}
 
-	return existingUser, nil
+	return user, nil
+}
+
+// applyReplace copia en user los datos normalizados de req, verificando que el email esté disponible
+func (s *userService) applyReplace(ctx context.Context, user *models.User, req models.ReplaceUserRequest) error {
+	email, _ := normalizeEmail(req.Email) // nolint:errcheck // Validado en validateReplaceRequest
+	if email != user.Email {
+		if err := s.ensureEmailAvailable(ctx, email, user.ID); err != nil {
+			return err
+		}
+	}
+
+	user.Name = strings.TrimSpace(req.Name)
+	user.Email = email
+	user.Age = req.Age
+	return nil
 }
 
-// DeleteUser elimina un usuario
-func (s *userService) DeleteUser(id string) error {
-	if err := s.repo.Delete(id); err != nil {
+// DeleteUser elimina lógicamente un usuario; puede restaurarse hasta que se purgue
+func (s *userService) DeleteUser(ctx context.Context, id string, version int64) error {
+	if err := s.repo.Delete(ctx, id, version); err != nil {
 		return fmt.Errorf("error al eliminar usuario: %w", err)
 	}
 	return nil
 }
 
-// validateCreateRequest valida los datos de creación de usuario
-func (s *userService) validateCreateRequest(req models.CreateUserRequest) error {
-	if req.Name == "" {
-		return ErrInvalidName
+// RestoreUser revierte la eliminación lógica de un usuario y lo retorna
+func (s *userService) RestoreUser(ctx context.Context, id string) (*models.User, error) {
+	if err := s.repo.Restore(ctx, id); err != nil {
+		return nil, fmt.Errorf("error al restaurar usuario: %w", err)
 	}
-	if !s.isValidEmail(req.Email) {
-		return ErrInvalidEmail
+	return s.GetUserByID(ctx, id, nil)
+}
+
+// PurgeDeletedUsers elimina definitivamente los usuarios eliminados hace más que la retención configurada
+func (s *userService) PurgeDeletedUsers(ctx context.Context) (int64, error) {
+	purged, err := s.repo.Purge(ctx, time.Now().Add(-s.purgeRetention))
+	if err != nil {
+		return 0, fmt.Errorf("error al purgar usuarios: %w", err)
 	}
-	if req.Age <= 0 {
-		return ErrInvalidAge
+	return purged, nil
+}
+
+// ensureEmailAvailable verifica que ningún usuario distinto de exceptID use el email,
+// sin distinguir mayúsculas. El índice único de la base de datos mantiene la garantía
+// ante escrituras concurrentes; esta verificación permite responder antes de escribir.
+// Los usuarios eliminados lógicamente conservan su email hasta ser purgados.
+func (s *userService) ensureEmailAvailable(ctx context.Context, email, exceptID string) error {
+	filter := models.UserFilter{Email: email, IncludeDeleted: true}
+	page, err := s.repo.GetAll(ctx, models.UserQuery{
+		Filter:    filter,
+		Limit:     2,
+		Fields:    models.UserFields{models.UserFieldID},
+		SkipTotal: true,
+	})
+	if err != nil {
+		return fmt.Errorf("error al verificar email: %w", err)
+	}
+	for _, user := range page.Users {
+		if user.ID != exceptID {
+			return repositories.ErrEmailAlreadyExists
+		}
 	}
 	return nil
 }
 
-// isValidEmail realiza una validación básica de email
-func (s *userService) isValidEmail(email string) bool {
-	return email != "" && contains(email, "@")
+// takenEmails retorna cuáles de emails ya usa algún usuario (incluidos los eliminados
+// lógicamente), en minúsculas, consultándolos todos con una única lectura
+func (s *userService) takenEmails(ctx context.Context, emails []string) (map[string]bool, error) {
+	taken := make(map[string]bool)
+	if len(emails) == 0 {
+		return taken, nil
+	}
+
+	// El índice único permite a lo sumo un usuario por email
+	page, err := s.repo.GetAll(ctx, models.UserQuery{
+		Filter:    models.UserFilter{Emails: emails, IncludeDeleted: true},
+		Limit:     len(emails),
+		Fields:    models.UserFields{models.UserFieldEmail},
+		SkipTotal: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error al verificar emails: %w", err)
+	}
+	for _, user := range page.Users {
+		taken[strings.ToLower(user.Email)] = true
+	}
+	return taken, nil
 }
 
-// contains verifica si un string contiene un substring
-func contains(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
+// validateFilter verifica que los rangos del filtro sean coherentes
+func validateFilter(filter models.UserFilter) error {
+	if filter.MinAge != nil && filter.MaxAge != nil && *filter.MinAge > *filter.MaxAge {
+		return ErrInvalidAgeRange
 	}
-	return false
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && !filter.CreatedAfter.Before(*filter.CreatedBefore) {
+		return ErrInvalidDateRange
+	}
+	return nil
 }
@@ -1,62 +1,246 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	"helloworld/migrations"
 	"helloworld/models"
 	"helloworld/repositories"
+	"helloworld/validation"
 )
 
 // mockRepository es un mock del repositorio para testing
 type mockRepository struct {
-	users map[string]*models.User
+	users   map[string]*models.User
+	deleted map[string]time.Time
 }
 
 func newMockRepository() *mockRepository {
 	return &mockRepository{
-		users: make(map[string]*models.User),
+		users:   make(map[string]*models.User),
+		deleted: make(map[string]time.Time),
 	}
 }
 
-func (m *mockRepository) Create(user *models.User) error {
-	m.users[user.ID] = user
+func (m *mockRepository) Create(ctx context.Context, user *models.User) error {
+	user.Version = 1
+	stored := *user
+	m.users[user.ID] = &stored
 	return nil
 }
 
-func (m *mockRepository) GetByID(id string) (*models.User, error) {
+func (m *mockRepository) GetByID(ctx context.Context, id string) (*models.User, error) {
 	user, exists := m.users[id]
-	if !exists {
+	if _, deleted := m.deleted[id]; !exists || deleted {
 		return nil, repositories.ErrUserNotFound
 	}
-	return user, nil
+	found := *user
+	return &found, nil
 }
 
-func (m *mockRepository) GetAll() ([]*models.User, error) {
+func (m *mockRepository) GetFieldsByID(ctx context.Context, id string, fields models.UserFields) (*models.User, error) {
+	return m.GetByID(ctx, id)
+}
+
+func (m *mockRepository) GetAll(ctx context.Context, query models.UserQuery) (*models.UserPage, error) {
 	users := make([]*models.User, 0, len(m.users))
-	for _, user := range m.users {
+	for id, user := range m.users {
+		if _, deleted := m.deleted[id]; deleted && !query.Filter.IncludeDeleted {
+			continue
+		}
+		if query.Filter.Email != "" && !strings.EqualFold(user.Email, query.Filter.Email) {
+			continue
+		}
+		if len(query.Filter.Emails) > 0 && !containsEmail(query.Filter.Emails, user.Email) {
+			continue
+		}
 		users = append(users, user)
 	}
-	return users, nil
+	return &models.UserPage{Users: users, Total: len(users)}, nil
 }
 
-func (m *mockRepository) Update(id string, user *models.User) error {
-	if _, exists := m.users[id]; !exists {
-		return repositories.ErrUserNotFound
+func containsEmail(emails []string, email string) bool {
+	for _, e := range emails {
+		if strings.EqualFold(e, email) {
+			return true
+		}
 	}
-	m.users[id] = user
+	return false
+}
+
+func (m *mockRepository) Update(ctx context.Context, id string, user *models.User) error {
+	current, err := m.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current.Version != user.Version {
+		return repositories.ErrVersionConflict
+	}
+	user.Version++
+	stored := *user
+	m.users[id] = &stored
 	return nil
 }
 
-func (m *mockRepository) Delete(id string) error {
-	if _, exists := m.users[id]; !exists {
+func (m *mockRepository) Delete(ctx context.Context, id string, version int64) error {
+	current, err := m.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if version != models.AnyVersion && current.Version != version {
+		return repositories.ErrVersionConflict
+	}
+	m.users[id].Version++
+	m.deleted[id] = time.Now()
+	return nil
+}
+
+func (m *mockRepository) Restore(ctx context.Context, id string) error {
+	if _, deleted := m.deleted[id]; !deleted {
 		return repositories.ErrUserNotFound
 	}
-	delete(m.users, id)
+	delete(m.deleted, id)
+	return nil
+}
+
+func (m *mockRepository) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	var purged int64
+	for id, deletedAt := range m.deleted {
+		if deletedAt.Before(deletedBefore) {
+			delete(m.users, id)
+			delete(m.deleted, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+func (m *mockRepository) CreateBatch(ctx context.Context, users []*models.User) error {
+	emails := make(map[string]bool, len(users))
+	for _, user := range users {
+		page, err := m.GetAll(ctx, models.UserQuery{Filter: models.UserFilter{Email: user.Email, IncludeDeleted: true}})
+		if err != nil {
+			return err
+		}
+		if emails[user.Email] || len(page.Users) > 0 {
+			return repositories.ErrEmailAlreadyExists
+		}
+		emails[user.Email] = true
+	}
+	for _, user := range users {
+		if err := m.Create(ctx, user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockRepository) UpdateBatch(ctx context.Context, users []*models.User) error {
+	return m.applyBatch(len(users), func(i int) error {
+		return m.Update(ctx, users[i].ID, users[i])
+	})
+}
+
+func (m *mockRepository) DeleteBatch(ctx context.Context, refs []models.UserRef) error {
+	return m.applyBatch(len(refs), func(i int) error {
+		return m.Delete(ctx, refs[i].ID, refs[i].Version)
+	})
+}
+
+func (m *mockRepository) Stream(ctx context.Context, filter models.UserFilter, order models.UserSort, fn func(*models.User) error) error {
+	page, err := m.GetAll(ctx, models.UserQuery{Filter: filter, Sort: order})
+	if err != nil {
+		return err
+	}
+	for _, user := range page.Users {
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyBatch aplica cada elemento y, si alguno falla, restaura el estado previo
+func (m *mockRepository) applyBatch(n int, apply func(i int) error) error {
+	users := make(map[string]*models.User, len(m.users))
+	for id, user := range m.users {
+		stored := *user
+		users[id] = &stored
+	}
+	deleted := make(map[string]time.Time, len(m.deleted))
+	for id, deletedAt := range m.deleted {
+		deleted[id] = deletedAt
+	}
+
+	for i := 0; i < n; i++ {
+		if err := apply(i); err != nil {
+			m.users, m.deleted = users, deleted
+			return &repositories.BatchError{Index: i, Err: err}
+		}
+	}
 	return nil
 }
 
+// repositoryFactories define los repositorios contra los que se ejecutan los tests del servicio
+var repositoryFactories = []struct {
+	name string
+	new  func(t *testing.T) repositories.UserRepository
+}{
+	{
+		name: "mock",
+		new: func(t *testing.T) repositories.UserRepository {
+			return newMockRepository()
+		},
+	},
+	{
+		name: "memory",
+		new: func(t *testing.T) repositories.UserRepository {
+			return repositories.NewMemoryUserRepository()
+		},
+	},
+	{
+		name: "sqlite",
+		new: func(t *testing.T) repositories.UserRepository {
+			repo, err := repositories.NewSQLiteUserRepository(filepath.Join(t.TempDir(), "users.sqlite"))
+			if err != nil {
+				t.Fatalf("Error al crear repositorio SQLite: %v", err)
+			}
+			t.Cleanup(func() { repo.Close() })
+			migrator, err := migrations.New(repo.DB(), migrations.DialectSQLite)
+			if err != nil {
+				t.Fatalf("Error al crear migrador: %v", err)
+			}
+			if _, err := migrator.Up(context.Background()); err != nil {
+				t.Fatalf("Error al aplicar migraciones: %v", err)
+			}
+			return repo
+		},
+	},
+}
+
+// forEachRepository ejecuta fn como subtest para cada repositorio disponible
+func forEachRepository(t *testing.T, fn func(t *testing.T, repo repositories.UserRepository)) {
+	for _, factory := range repositoryFactories {
+		factory := factory
+		t.Run(factory.name, func(t *testing.T) {
+			fn(t, factory.new(t))
+		})
+	}
+}
+
 func TestUserService_CreateUser(t *testing.T) {
-	repo := newMockRepository()
+	forEachRepository(t, testUserServiceCreateUser)
+}
+
+func testUserServiceCreateUser(t *testing.T, repo repositories.UserRepository) {
 	service := NewUserService(repo)
 
 	tests := []struct {
@@ -108,13 +292,13 @@ func TestUserService_CreateUser(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			user, err := service.CreateUser(tt.req)
+			user, err := service.CreateUser(context.Background(), tt.req)
 			if tt.wantErr {
 				if err == nil {
 					t.Errorf("CreateUser() esperaba error, pero no obtuvo ninguno")
 					return
 				}
-				if err != tt.errType {
+				if !errors.Is(err, tt.errType) {
 					t.Errorf("CreateUser() error = %v, esperaba %v", err, tt.errType)
 				}
 			} else {
@@ -135,7 +319,10 @@ func TestUserService_CreateUser(t *testing.T) {
 }
 
 func TestUserService_GetUserByID(t *testing.T) {
-	repo := newMockRepository()
+	forEachRepository(t, testUserServiceGetUserByID)
+}
+
+func testUserServiceGetUserByID(t *testing.T, repo repositories.UserRepository) {
 	service := NewUserService(repo)
 
 	// Crear un usuario de prueba
@@ -144,7 +331,7 @@ func TestUserService_GetUserByID(t *testing.T) {
 		Email: "test@example.com",
 		Age:   25,
 	}
-	createdUser, err := service.CreateUser(req)
+	createdUser, err := service.CreateUser(context.Background(), req)
 	if err != nil {
 		t.Fatalf("Error al crear usuario de prueba: %v", err)
 	}
@@ -168,7 +355,7 @@ func TestUserService_GetUserByID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			user, err := service.GetUserByID(tt.id)
+			user, err := service.GetUserByID(context.Background(), tt.id, nil)
 			if tt.wantErr {
 				if err == nil {
 					t.Errorf("GetUserByID() no retornó error como se esperaba")
@@ -187,7 +374,10 @@ func TestUserService_GetUserByID(t *testing.T) {
 }
 
 func TestUserService_GetAllUsers(t *testing.T) {
-	repo := newMockRepository()
+	forEachRepository(t, testUserServiceGetAllUsers)
+}
+
+func testUserServiceGetAllUsers(t *testing.T, repo repositories.UserRepository) {
 	service := NewUserService(repo)
 
 	// Crear algunos usuarios
@@ -197,19 +387,497 @@ func TestUserService_GetAllUsers(t *testing.T) {
 	}
 
 	for _, req := range users {
-		_, err := service.CreateUser(req)
+		_, err := service.CreateUser(context.Background(), req)
 		if err != nil {
 			t.Fatalf("Error al crear usuario: %v", err)
 		}
 	}
 
-	allUsers, err := service.GetAllUsers()
+	page, err := service.GetAllUsers(context.Background(), models.UserQuery{})
 	if err != nil {
 		t.Errorf("GetAllUsers() error = %v, no esperaba error", err)
 		return
 	}
 
-	if len(allUsers) != len(users) {
-		t.Errorf("GetAllUsers() retornó %d usuarios, esperaba %d", len(allUsers), len(users))
+	if len(page.Users) != len(users) {
+		t.Errorf("GetAllUsers() retornó %d usuarios, esperaba %d", len(page.Users), len(users))
+	}
+	if page.Total != len(users) {
+		t.Errorf("GetAllUsers() total = %d, esperaba %d", page.Total, len(users))
+	}
+	if page.Limit != models.DefaultPageLimit {
+		t.Errorf("GetAllUsers() límite = %d, esperaba %d", page.Limit, models.DefaultPageLimit)
+	}
+}
+
+func TestUserService_GetAllUsersPagination(t *testing.T) {
+	forEachRepository(t, testUserServiceGetAllUsersPagination)
+}
+
+func testUserServiceGetAllUsersPagination(t *testing.T, repo repositories.UserRepository) {
+	service := NewUserService(repo)
+	ctx := context.Background()
+
+	minAge, maxAge := 40, 30
+	after := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		query   models.UserQuery
+		errType error
+	}{
+		{name: "orden inválido", query: models.UserQuery{Sort: models.UserSort{Field: "password"}}, errType: models.ErrInvalidSort},
+		{
+			name:    "cursor de otro orden",
+			query:   models.UserQuery{Sort: models.UserSort{Field: models.SortByName}, Cursor: &models.Cursor{Sort: "-created_at", ID: "x"}},
+			errType: models.ErrInvalidCursor,
+		},
+		{name: "rango de edad invertido", query: models.UserQuery{Filter: models.UserFilter{MinAge: &minAge, MaxAge: &maxAge}}, errType: ErrInvalidAgeRange},
+		{
+			name:    "rango de fechas invertido",
+			query:   models.UserQuery{Filter: models.UserFilter{CreatedAfter: &after, CreatedBefore: &before}},
+			errType: ErrInvalidDateRange,
+		},
+		{name: "límite negativo", query: models.UserQuery{Limit: -1}, errType: ErrInvalidLimit},
+		{name: "límite excesivo", query: models.UserQuery{Limit: models.MaxPageLimit + 1}, errType: ErrInvalidLimit},
+		{name: "offset negativo", query: models.UserQuery{Offset: -1}, errType: ErrInvalidOffset},
+		{
+			name:    "offset con cursor",
+			query:   models.UserQuery{Offset: 1, Cursor: &models.Cursor{Sort: "-created_at", ID: "x"}},
+			errType: ErrInvalidOffset,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.GetAllUsers(ctx, tt.query); err != tt.errType {
+				t.Errorf("GetAllUsers() error = %v, esperaba %v", err, tt.errType)
+			}
+		})
+	}
+}
+
+func TestUserService_UniqueEmail(t *testing.T) {
+	forEachRepository(t, testUserServiceUniqueEmail)
+}
+
+func testUserServiceUniqueEmail(t *testing.T, repo repositories.UserRepository) {
+	service := NewUserService(repo)
+	ctx := context.Background()
+
+	first, err := service.CreateUser(ctx, models.CreateUserRequest{Name: "Ana", Email: "ana@example.com", Age: 30})
+	if err != nil {
+		t.Fatalf("Error al crear usuario: %v", err)
+	}
+	second, err := service.CreateUser(ctx, models.CreateUserRequest{Name: "Bruno", Email: "bruno@example.com", Age: 30})
+	if err != nil {
+		t.Fatalf("Error al crear usuario: %v", err)
+	}
+
+	_, err = service.CreateUser(ctx, models.CreateUserRequest{Name: "Otra", Email: "Ana@Example.com", Age: 20})
+	if !errors.Is(err, repositories.ErrEmailAlreadyExists) {
+		t.Errorf("CreateUser() error = %v, esperaba %v", err, repositories.ErrEmailAlreadyExists)
+	}
+
+	taken := models.ReplaceUserRequest{Name: "Bruno", Email: "ANA@example.com", Age: 30}
+	if _, err := service.ReplaceUser(ctx, second.ID, models.AnyVersion, taken); !errors.Is(err, repositories.ErrEmailAlreadyExists) {
+		t.Errorf("ReplaceUser() error = %v, esperaba %v", err, repositories.ErrEmailAlreadyExists)
+	}
+
+	// Un usuario puede conservar su propio email
+	if _, err := service.ReplaceUser(ctx, first.ID, models.AnyVersion, taken); err != nil {
+		t.Errorf("ReplaceUser() del propio email error = %v", err)
+	}
+}
+
+func TestUserService_SoftDelete(t *testing.T) {
+	forEachRepository(t, testUserServiceSoftDelete)
+}
+
+func testUserServiceSoftDelete(t *testing.T, repo repositories.UserRepository) {
+	service := NewUserService(repo)
+	ctx := context.Background()
+
+	user, err := service.CreateUser(ctx, models.CreateUserRequest{Name: "Ana", Email: "ana@example.com", Age: 30})
+	if err != nil {
+		t.Fatalf("Error al crear usuario: %v", err)
+	}
+	if err := service.DeleteUser(ctx, user.ID, models.AnyVersion); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	if _, err := service.GetUserByID(ctx, user.ID, nil); !errors.Is(err, repositories.ErrUserNotFound) {
+		t.Errorf("GetUserByID() error = %v, esperaba %v", err, repositories.ErrUserNotFound)
+	}
+
+	// El email queda reservado mientras el usuario pueda restaurarse
+	_, err = service.CreateUser(ctx, models.CreateUserRequest{Name: "Otra", Email: "ana@example.com", Age: 20})
+	if !errors.Is(err, repositories.ErrEmailAlreadyExists) {
+		t.Errorf("CreateUser() error = %v, esperaba %v", err, repositories.ErrEmailAlreadyExists)
+	}
+
+	// Con la retención por defecto el usuario recién eliminado no se purga
+	if purged, err := service.PurgeDeletedUsers(ctx); err != nil || purged != 0 {
+		t.Errorf("PurgeDeletedUsers() = %d, %v, esperaba 0", purged, err)
+	}
+
+	restored, err := service.RestoreUser(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("RestoreUser() error = %v", err)
+	}
+	if restored.ID != user.ID || restored.DeletedAt != nil {
+		t.Errorf("RestoreUser() = %+v, esperaba el usuario %s activo", restored, user.ID)
+	}
+	if _, err := service.RestoreUser(ctx, user.ID); !errors.Is(err, repositories.ErrUserNotFound) {
+		t.Errorf("RestoreUser() de activo error = %v, esperaba %v", err, repositories.ErrUserNotFound)
+	}
+}
+
+func TestUserService_ReplaceUserVersion(t *testing.T) {
+	forEachRepository(t, testUserServiceReplaceUserVersion)
+}
+
+func testUserServiceReplaceUserVersion(t *testing.T, repo repositories.UserRepository) {
+	service := NewUserService(repo)
+	ctx := context.Background()
+
+	user, err := service.CreateUser(ctx, models.CreateUserRequest{Name: "Ana", Email: "ana@example.com", Age: 30})
+	if err != nil {
+		t.Fatalf("Error al crear usuario: %v", err)
+	}
+
+	req := models.ReplaceUserRequest{Name: "Ana", Email: "ana@example.com", Age: 31}
+	updated, err := service.ReplaceUser(ctx, user.ID, user.Version, req)
+	if err != nil {
+		t.Fatalf("ReplaceUser() error = %v", err)
+	}
+	if updated.Version != user.Version+1 {
+		t.Errorf("ReplaceUser() versión = %d, esperaba %d", updated.Version, user.Version+1)
+	}
+
+	// La versión original ya no es la actual
+	req.Age = 40
+	if _, err := service.ReplaceUser(ctx, user.ID, user.Version, req); !errors.Is(err, repositories.ErrVersionConflict) {
+		t.Errorf("ReplaceUser() con versión vieja error = %v, esperaba %v", err, repositories.ErrVersionConflict)
+	}
+	if err := service.DeleteUser(ctx, user.ID, user.Version); !errors.Is(err, repositories.ErrVersionConflict) {
+		t.Errorf("DeleteUser() con versión vieja error = %v, esperaba %v", err, repositories.ErrVersionConflict)
+	}
+	if err := service.DeleteUser(ctx, user.ID, updated.Version); err != nil {
+		t.Errorf("DeleteUser() con versión actual error = %v", err)
+	}
+}
+
+func TestUserService_ValidationCollectsAllFields(t *testing.T) {
+	service := NewUserService(newMockRepository())
+	ctx := context.Background()
+
+	_, err := service.CreateUser(ctx, models.CreateUserRequest{Name: "   ", Email: "sin-arroba", Age: 200})
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("CreateUser() error = %v, esperaba validation.Errors", err)
+	}
+	got := make([]string, 0, len(errs))
+	for _, violation := range errs {
+		got = append(got, violation.Field+"/"+violation.Rule)
+	}
+	if want := "name/required email/format age/max"; strings.Join(got, " ") != want {
+		t.Errorf("violaciones = %v, esperaba %v", got, want)
+	}
+
+	user, err := service.CreateUser(ctx, models.CreateUserRequest{Name: "  Ana  ", Email: "ana@example.com", Age: 30})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if user.Name != "Ana" {
+		t.Errorf("CreateUser() nombre = %q, esperaba el nombre sin espacios", user.Name)
+	}
+
+	long := strings.Repeat("a", MaxNameLength+1)
+	_, err = service.ReplaceUser(ctx, user.ID, models.AnyVersion, models.ReplaceUserRequest{Name: long, Age: 30})
+	if !errors.As(err, &errs) || len(errs) != 2 || errs[0].Rule != validation.RuleMaxLength || errs[1].Rule != validation.RuleRequired {
+		t.Errorf("ReplaceUser() error = %v, esperaba max_length en name y required en email", err)
+	}
+
+	// El dominio se guarda en punycode, así que el límite se mide sobre la dirección normalizada
+	idn := "ana@" + strings.Repeat("ñandú.", 30) + "com"
+	_, err = service.CreateUser(ctx, models.CreateUserRequest{Name: "Ana", Email: idn, Age: 30})
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != "email" || errs[0].Rule != validation.RuleMaxLength {
+		t.Errorf("CreateUser() error = %v, esperaba max_length en email", err)
+	}
+}
+
+func TestUserService_PatchUser(t *testing.T) {
+	service := NewUserService(newMockRepository())
+	ctx := context.Background()
+
+	user, err := service.CreateUser(ctx, models.CreateUserRequest{Name: "Ana", Email: "ana@example.com", Age: 30})
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	// El patch recibe el estado actual y el resultado se valida como un reemplazo completo
+	patched, err := service.PatchUser(ctx, user.ID, user.Version, func(current models.User) (models.ReplaceUserRequest, error) {
+		return models.ReplaceUserRequest{Name: current.Name, Email: " ANA@Example.com ", Age: current.Age + 1}, nil
+	})
+	if err != nil {
+		t.Fatalf("PatchUser() error = %v", err)
+	}
+	if patched.Age != 31 || patched.Email != "ana@example.com" || patched.Version != user.Version+1 {
+		t.Errorf("PatchUser() = %+v, esperaba edad 31, email normalizado y versión %d", patched, user.Version+1)
+	}
+
+	var errs validation.Errors
+	_, err = service.PatchUser(ctx, user.ID, models.AnyVersion, func(current models.User) (models.ReplaceUserRequest, error) {
+		return models.ReplaceUserRequest{Email: current.Email, Age: current.Age}, nil
+	})
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Field != "name" {
+		t.Errorf("PatchUser() error = %v, esperaba required en name", err)
+	}
+
+	errPatch := errors.New("patch inválido")
+	_, err = service.PatchUser(ctx, user.ID, models.AnyVersion, func(models.User) (models.ReplaceUserRequest, error) {
+		return models.ReplaceUserRequest{}, errPatch
+	})
+	if err != errPatch {
+		t.Errorf("PatchUser() error = %v, esperaba el error del patch sin envolver", err)
+	}
+
+	if _, err := service.PatchUser(ctx, user.ID, user.Version, nil); !errors.Is(err, repositories.ErrVersionConflict) {
+		t.Errorf("PatchUser() con versión vieja error = %v, esperaba %v", err, repositories.ErrVersionConflict)
+	}
+}
+
+func TestUserService_Batch(t *testing.T) {
+	forEachRepository(t, testUserServiceBatch)
+}
+
+func testUserServiceBatch(t *testing.T, repo repositories.UserRepository) {
+	service := NewUserService(repo)
+	ctx := context.Background()
+
+	reqs := []models.CreateUserRequest{
+		{Name: "Ana", Email: "ana@example.com", Age: 30},
+		{Name: "", Email: "sin-nombre@example.com", Age: 30},
+		{Name: "Ana bis", Email: "ANA@example.com", Age: 30},
+		{Name: "Bruno", Email: "bruno@example.com", Age: 40},
+	}
+
+	// En modo atómico un elemento inválido impide crear los demás
+	results, err := service.CreateUsers(ctx, models.BatchModeAtomic, reqs)
+	if err != nil {
+		t.Fatalf("CreateUsers() error = %v", err)
+	}
+	wantErrs := []error{ErrBatchAborted, ErrInvalidName, repositories.ErrEmailAlreadyExists, ErrBatchAborted}
+	for i, want := range wantErrs {
+		if !errors.Is(results[i].Err, want) {
+			t.Errorf("CreateUsers(atomic)[%d] error = %v, esperaba %v", i, results[i].Err, want)
+		}
+	}
+	if page, _ := service.GetAllUsers(ctx, models.UserQuery{}); page.Total != 0 {
+		t.Errorf("total tras lote atómico fallido = %d, esperaba 0", page.Total)
+	}
+
+	// En modo parcial se crean los válidos
+	results, err = service.CreateUsers(ctx, models.BatchModePartial, reqs)
+	if err != nil {
+		t.Fatalf("CreateUsers() error = %v", err)
+	}
+	if results[0].User == nil || results[3].User == nil || results[1].Err == nil || results[2].Err == nil {
+		t.Fatalf("CreateUsers(partial) = %+v, esperaba crear los elementos 0 y 3", results)
+	}
+	ana, bruno := results[0].User, results[3].User
+
+	items := []models.ReplaceUserItem{
+		{ID: ana.ID, Version: ana.Version, ReplaceUserRequest: models.ReplaceUserRequest{Name: "Ana", Email: "ana@example.com", Age: 31}},
+		{ID: bruno.ID, Version: bruno.Version + 1, ReplaceUserRequest: models.ReplaceUserRequest{Name: "Bruno", Email: "bruno@example.com", Age: 41}},
+	}
+	results, err = service.ReplaceUsers(ctx, "", items)
+	if err != nil {
+		t.Fatalf("ReplaceUsers() error = %v", err)
+	}
+	if !errors.Is(results[0].Err, ErrBatchAborted) || !errors.Is(results[1].Err, repositories.ErrVersionConflict) {
+		t.Errorf("ReplaceUsers() = %+v, esperaba conflicto de versión en el elemento 1", results)
+	}
+
+	items[1].Version = bruno.Version
+	results, err = service.ReplaceUsers(ctx, models.BatchModeAtomic, items)
+	if err != nil || results[0].Err != nil || results[1].User.Age != 41 {
+		t.Fatalf("ReplaceUsers() = %+v, %v, esperaba reemplazar ambos", results, err)
+	}
+
+	results, err = service.DeleteUsers(ctx, models.BatchModeAtomic, []models.UserRef{{ID: ana.ID}, {ID: "inexistente"}})
+	if err != nil {
+		t.Fatalf("DeleteUsers() error = %v", err)
+	}
+	if !errors.Is(results[0].Err, ErrBatchAborted) || !errors.Is(results[1].Err, repositories.ErrUserNotFound) {
+		t.Errorf("DeleteUsers() = %+v, esperaba usuario inexistente en el elemento 1", results)
+	}
+	results, _ = service.DeleteUsers(ctx, models.BatchModePartial, []models.UserRef{{ID: ana.ID}, {ID: "inexistente"}})
+	if results[0].Err != nil || results[1].Err == nil {
+		t.Errorf("DeleteUsers(partial) = %+v, esperaba eliminar solo el elemento 0", results)
+	}
+
+	if _, err := service.CreateUsers(ctx, "todo", reqs); !errors.Is(err, ErrInvalidBatchMode) {
+		t.Errorf("CreateUsers() con modo inválido error = %v, esperaba %v", err, ErrInvalidBatchMode)
+	}
+	if _, err := service.DeleteUsers(ctx, models.BatchModeAtomic, nil); !errors.Is(err, ErrInvalidBatchSize) {
+		t.Errorf("DeleteUsers() vacío error = %v, esperaba %v", err, ErrInvalidBatchSize)
+	}
+}
+
+func TestUserService_CreateUsersSingleEmailLookup(t *testing.T) {
+	ctx := context.Background()
+	var lookups int
+	repo := repositories.Instrument(repositories.NewMemoryUserRepository(), func(method string, _ time.Duration, _ error) {
+		if method == "GetAll" {
+			lookups++
+		}
+	})
+	service := NewUserService(repo)
+	if _, err := service.CreateUser(ctx, models.CreateUserRequest{Name: "Existente", Email: "existente@example.com", Age: 50}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	reqs := make([]models.CreateUserRequest, 0, 50)
+	for i := 0; i < cap(reqs); i++ {
+		reqs = append(reqs, models.CreateUserRequest{Name: "Usuario", Email: fmt.Sprintf("usuario%d@example.com", i), Age: 30})
+	}
+	reqs = append(reqs, models.CreateUserRequest{Name: "Otra", Email: "Existente@example.com", Age: 40})
+
+	lookups = 0
+	results, err := service.CreateUsers(ctx, models.BatchModePartial, reqs)
+	if err != nil {
+		t.Fatalf("CreateUsers() error = %v", err)
+	}
+	if lookups != 1 {
+		t.Errorf("CreateUsers() consultó el repositorio %d veces, esperaba 1 para todo el lote", lookups)
+	}
+	if last := results[len(results)-1]; !errors.Is(last.Err, repositories.ErrEmailAlreadyExists) {
+		t.Errorf("CreateUsers() con email registrado error = %v, esperaba %v", last.Err, repositories.ErrEmailAlreadyExists)
+	}
+	for i, result := range results[:len(results)-1] {
+		if result.Err != nil {
+			t.Errorf("CreateUsers()[%d] error = %v", i, result.Err)
+		}
+	}
+}
+
+// sliceRowReader entrega filas fijas a ImportUsers
+type sliceRowReader struct {
+	rows []models.ImportRow
+}
+
+func (r *sliceRowReader) Next() (models.ImportRow, error) {
+	if len(r.rows) == 0 {
+		return models.ImportRow{}, io.EOF
+	}
+	row := r.rows[0]
+	r.rows = r.rows[1:]
+	return row, nil
+}
+
+// failingRowReader entrega filas válidas y luego un error de lectura
+type failingRowReader struct {
+	rows int
+	err  error
+	line int
+}
+
+func (r *failingRowReader) Next() (models.ImportRow, error) {
+	if r.line == r.rows {
+		return models.ImportRow{}, r.err
+	}
+	r.line++
+	email := fmt.Sprintf("usuario%d@example.com", r.line)
+	return models.ImportRow{Line: r.line + 1, User: models.CreateUserRequest{Name: "Usuario", Email: email, Age: 30}}, nil
+}
+
+func TestUserService_ImportUsersReadError(t *testing.T) {
+	ctx := context.Background()
+	repo := repositories.NewMemoryUserRepository()
+	service := NewUserService(repo)
+
+	// El error llega después de un lote completo: no debe haberse creado ningún usuario
+	errRead := errors.New("cuerpo demasiado grande")
+	_, err := service.ImportUsers(ctx, &failingRowReader{rows: models.MaxBatchSize + 1, err: errRead}, false)
+	if !errors.Is(err, errRead) {
+		t.Fatalf("ImportUsers() error = %v, esperaba %v", err, errRead)
+	}
+	page, err := repo.GetAll(ctx, models.UserQuery{})
+	if err != nil {
+		t.Fatalf("GetAll() error = %v", err)
+	}
+	if page.Total != 0 {
+		t.Errorf("usuarios creados tras el error de lectura = %d, esperaba 0", page.Total)
+	}
+}
+
+func TestUserService_ImportUsers(t *testing.T) {
+	forEachRepository(t, testUserServiceImportUsers)
+}
+
+func testUserServiceImportUsers(t *testing.T, repo repositories.UserRepository) {
+	service := NewUserService(repo)
+	ctx := context.Background()
+	if _, err := service.CreateUser(ctx, models.CreateUserRequest{Name: "Existente", Email: "existente@example.com", Age: 50}); err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	errUnreadable := errors.New("fila ilegible")
+	rows := func() UserRowReader {
+		return &sliceRowReader{rows: []models.ImportRow{
+			{Line: 2, User: models.CreateUserRequest{Name: "Ana", Email: "Ana@Example.com", Age: 30}},
+			{Line: 3, Err: errUnreadable},
+			{Line: 4, User: models.CreateUserRequest{Name: "", Email: "vacio@example.com", Age: 30}},
+			{Line: 5, User: models.CreateUserRequest{Name: "Ana bis", Email: "ana@example.com", Age: 31}},
+			{Line: 6, User: models.CreateUserRequest{Name: "Otra", Email: "EXISTENTE@example.com", Age: 40}},
+			{Line: 7, User: models.CreateUserRequest{Name: "Bruno", Email: "bruno@example.com", Age: 40}},
+		}}
+	}
+	wantFailures := []struct {
+		line int
+		err  error
+	}{
+		{3, errUnreadable},
+		{4, ErrInvalidName},
+		{5, repositories.ErrEmailAlreadyExists},
+		{6, repositories.ErrEmailAlreadyExists},
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		result, err := service.ImportUsers(ctx, rows(), dryRun)
+		if err != nil {
+			t.Fatalf("ImportUsers(dryRun=%v) error = %v", dryRun, err)
+		}
+		if result.Rows != 6 || result.Imported != 2 || len(result.Failures) != len(wantFailures) {
+			t.Fatalf("ImportUsers(dryRun=%v) = %+v, esperaba 6 filas, 2 importadas y %d errores", dryRun, result, len(wantFailures))
+		}
+		for i, want := range wantFailures {
+			failure := result.Failures[i]
+			if failure.Line != want.line || !errors.Is(failure.Err, want.err) {
+				t.Errorf("ImportUsers(dryRun=%v) error %d = línea %d: %v, esperaba línea %d: %v", dryRun, i, failure.Line, failure.Err, want.line, want.err)
+			}
+		}
+
+		var emails []string
+		if err := service.ExportUsers(ctx, models.UserFilter{}, models.UserSort{Field: models.SortByEmail}, func(user *models.User) error {
+			emails = append(emails, user.Email)
+			return nil
+		}); err != nil {
+			t.Fatalf("ExportUsers() error = %v", err)
+		}
+		sort.Strings(emails)
+		want := "existente@example.com"
+		if !dryRun {
+			want = "ana@example.com,bruno@example.com,existente@example.com"
+		}
+		if got := strings.Join(emails, ","); got != want {
+			t.Errorf("usuarios tras ImportUsers(dryRun=%v) = %v, esperaba %v", dryRun, got, want)
+		}
+	}
+
+	if err := service.ExportUsers(ctx, models.UserFilter{}, models.UserSort{Field: "edad"}, func(*models.User) error { return nil }); !errors.Is(err, models.ErrInvalidSort) {
+		t.Errorf("ExportUsers() con orden inválido error = %v, esperaba %v", err, models.ErrInvalidSort)
 	}
 }
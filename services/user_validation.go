@@ -0,0 +1,60 @@
+package services
+
+import (
+	"helloworld/models"
+	"helloworld/validation"
+)
+
+// Límites de los datos de un usuario
+const (
+	MaxNameLength  = 100
+	MaxEmailLength = 254 // Longitud máxima de una dirección según RFC 5321
+	MinAge         = 1
+	MaxAge         = 150
+)
+
+// ruleBlockedDomain es la regla incumplida por los emails de dominios bloqueados
+const ruleBlockedDomain = "blocked_domain"
+
+// validateCreateRequest valida los datos de creación de usuario.
+// Retorna validation.Errors con todas las reglas incumplidas.
+func (s *userService) validateCreateRequest(req models.CreateUserRequest) error {
+	return s.validateUserData(req.Name, req.Email, req.Age)
+}
+
+// validateReplaceRequest valida los datos completos con los que se reemplaza un usuario,
+// tanto los recibidos por PUT como los resultantes de aplicar un patch
+func (s *userService) validateReplaceRequest(req models.ReplaceUserRequest) error {
+	return s.validateUserData(req.Name, req.Email, req.Age)
+}
+
+func (s *userService) validateUserData(name, email string, age int) error {
+	var v validation.Validator
+	s.validateName(&v, name)
+	s.validateEmail(&v, email)
+	s.validateAge(&v, age)
+	return v.Err()
+}
+
+func (s *userService) validateName(v *validation.Validator, name string) {
+	v.Field("name", ErrInvalidName).Required(name).MaxLength(name, MaxNameLength)
+}
+
+func (s *userService) validateEmail(v *validation.Validator, email string) {
+	normalized, err := normalizeEmail(email)
+	// El límite se aplica a la dirección que se guarda, con el dominio en punycode, que puede ser
+	// más larga que la recibida
+	stored := email
+	if err == nil {
+		stored = normalized
+	}
+	v.Field("email", ErrInvalidEmail).
+		Required(email).
+		MaxLength(stored, MaxEmailLength).
+		Check(err == nil, validation.RuleFormat, "no es un email válido").
+		Check(!s.isBlockedDomain(emailDomain(normalized)), ruleBlockedDomain, "el dominio del email no está permitido")
+}
+
+func (s *userService) validateAge(v *validation.Validator, age int) {
+	v.Field("age", ErrInvalidAge).Min(age, MinAge).Max(age, MaxAge)
+}
@@ -0,0 +1,91 @@
+// Package tracing configura OpenTelemetry: el proveedor de trazas global, el exportador y la
+// propagación W3C Trace Context (header traceparent)
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+// Exportadores soportados
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// DefaultServiceName es el nombre del servicio en las trazas si no se define OTEL_SERVICE_NAME
+const DefaultServiceName = "users-api"
+
+// Options configura las trazas
+type Options struct {
+	// Exporter es el destino de los spans: ExporterNone, ExporterStdout o ExporterOTLP.
+	// OTLP/HTTP lee el endpoint y los headers de las variables OTEL_EXPORTER_OTLP_*.
+	Exporter string
+	// SampleRatio es la fracción de las trazas iniciadas por la API que se registran; las que
+	// llegan con traceparent respetan la decisión de quien las inició
+	SampleRatio float64
+	// Output es el destino del exportador stdout; por defecto os.Stdout
+	Output io.Writer
+}
+
+// Shutdown envía los spans pendientes y libera el exportador
+type Shutdown func(ctx context.Context) error
+
+// Setup instala el propagador W3C (traceparent y baggage) y, salvo con ExporterNone, un
+// proveedor de trazas global que exporta los spans en lotes. Sin exportador el traceparent
+// recibido se propaga igualmente, pero no se registran spans.
+func Setup(ctx context.Context, opts Options) (Shutdown, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch opts.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		output := opts.Output
+		if output == nil {
+			output = os.Stdout
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(output), stdouttrace.WithPrettyPrint())
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("exportador de trazas desconocido: %s", opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error al crear el exportador de trazas %s: %w", opts.Exporter, err)
+	}
+
+	// OTEL_SERVICE_NAME y OTEL_RESOURCE_ATTRIBUTES tienen prioridad sobre el nombre por defecto
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(semconv.ServiceName(DefaultServiceName)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error al describir el servicio de las trazas: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
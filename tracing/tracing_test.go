@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// restoreGlobals restaura el proveedor y el propagador globales al terminar el test
+func restoreGlobals(t *testing.T) {
+	provider, propagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+		otel.SetTextMapPropagator(propagator)
+	})
+}
+
+func TestSetup_Stdout(t *testing.T) {
+	restoreGlobals(t)
+	t.Setenv("OTEL_SERVICE_NAME", "users-api-test")
+
+	var output bytes.Buffer
+	shutdown, err := Setup(context.Background(), Options{Exporter: ExporterStdout, SampleRatio: 1, Output: &output})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+
+	_, span := otel.Tracer("test").Start(context.Background(), "operación")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	for _, want := range []string{`"Name": "operación"`, "users-api-test"} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("la salida no contiene %q:\n%s", want, output.String())
+		}
+	}
+}
+
+func TestSetup_None(t *testing.T) {
+	restoreGlobals(t)
+
+	shutdown, err := Setup(context.Background(), Options{Exporter: ExporterNone})
+	if err != nil {
+		t.Fatalf("Setup() error = %v", err)
+	}
+	defer shutdown(context.Background()) // nolint:errcheck // Sin exportador no hay nada que enviar
+
+	// Sin exportador el traceparent recibido se propaga igualmente
+	carrier := propagation.MapCarrier{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+	injected := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, injected)
+	if injected["traceparent"] != carrier["traceparent"] {
+		t.Errorf("traceparent = %q, esperaba %q", injected["traceparent"], carrier["traceparent"])
+	}
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	restoreGlobals(t)
+
+	if _, err := Setup(context.Background(), Options{Exporter: "zipkin"}); err == nil {
+		t.Error("Setup() con un exportador desconocido no retornó error")
+	}
+}
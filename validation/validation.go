@@ -0,0 +1,110 @@
+// Package validation acumula las reglas incumplidas por una petición para
+// informarlas todas juntas en lugar de detenerse en la primera.
+package validation
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Nombres de las reglas, expuestos a los clientes junto a cada violación
+const (
+	RuleRequired  = "required"
+	RuleMaxLength = "max_length"
+	RuleMin       = "min"
+	RuleMax       = "max"
+	RuleFormat    = "format"
+	RuleType      = "type"
+	RuleReadOnly  = "read_only"
+	RuleUnknown   = "unknown_field"
+)
+
+// Violation describe una regla incumplida por un campo
+type Violation struct {
+	Field   string
+	Rule    string
+	Message string
+	// Err es el error de dominio asociado al campo; permite usar errors.Is sobre Errors
+	Err error
+}
+
+// Errors es el conjunto de violaciones de una petición
+type Errors []Violation
+
+// Error implementa la interfaz error
+func (e Errors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, violation := range e {
+		messages = append(messages, violation.Field+": "+violation.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap expone los errores de dominio de cada violación para errors.Is
+func (e Errors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, violation := range e {
+		if violation.Err != nil {
+			errs = append(errs, violation.Err)
+		}
+	}
+	return errs
+}
+
+// Validator acumula violaciones
+type Validator struct {
+	errs Errors
+}
+
+// Field inicia la validación de un campo; err es el error de dominio que se asocia a sus violaciones
+func (v *Validator) Field(name string, err error) *FieldValidator {
+	return &FieldValidator{validator: v, name: name, err: err}
+}
+
+// Err retorna las violaciones acumuladas como Errors, o nil si no hubo ninguna
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// FieldValidator aplica reglas a un campo. Tras la primera regla incumplida las siguientes
+// se omiten, para no informar por ejemplo "formato inválido" de un campo vacío.
+type FieldValidator struct {
+	validator *Validator
+	name      string
+	err       error
+	failed    bool
+}
+
+// Check registra una violación de rule si ok es falso
+func (f *FieldValidator) Check(ok bool, rule, message string) *FieldValidator {
+	if f.failed || ok {
+		return f
+	}
+	f.failed = true
+	f.validator.errs = append(f.validator.errs, Violation{Field: f.name, Rule: rule, Message: message, Err: f.err})
+	return f
+}
+
+// Required exige un valor que no quede vacío al quitar los espacios
+func (f *FieldValidator) Required(value string) *FieldValidator {
+	return f.Check(strings.TrimSpace(value) != "", RuleRequired, "es obligatorio")
+}
+
+// MaxLength limita la cantidad de caracteres (no bytes) del valor
+func (f *FieldValidator) MaxLength(value string, max int) *FieldValidator {
+	return f.Check(utf8.RuneCountInString(value) <= max, RuleMaxLength, fmt.Sprintf("debe tener como máximo %d caracteres", max))
+}
+
+// Min exige un valor mayor o igual a min
+func (f *FieldValidator) Min(value, min int) *FieldValidator {
+	return f.Check(value >= min, RuleMin, fmt.Sprintf("debe ser mayor o igual a %d", min))
+}
+
+// Max exige un valor menor o igual a max
+func (f *FieldValidator) Max(value, max int) *FieldValidator {
+	return f.Check(value <= max, RuleMax, fmt.Sprintf("debe ser menor o igual a %d", max))
+}
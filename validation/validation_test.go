@@ -0,0 +1,41 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidator(t *testing.T) {
+	errName := errors.New("nombre inválido")
+	errAge := errors.New("edad inválida")
+
+	var v Validator
+	v.Field("name", errName).Required("   ").MaxLength("   ", 2)
+	v.Field("age", errAge).Min(200, 1).Max(200, 150)
+	v.Field("email", nil).Required("a@b.c")
+
+	err := v.Err()
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Err() = %v, esperaba Errors", err)
+	}
+
+	want := []struct{ field, rule string }{{"name", RuleRequired}, {"age", RuleMax}}
+	if len(errs) != len(want) {
+		t.Fatalf("Err() = %v, esperaba %d violaciones", errs, len(want))
+	}
+	for i, w := range want {
+		if errs[i].Field != w.field || errs[i].Rule != w.rule {
+			t.Errorf("violación %d = %s/%s, esperaba %s/%s", i, errs[i].Field, errs[i].Rule, w.field, w.rule)
+		}
+	}
+
+	if !errors.Is(err, errName) || !errors.Is(err, errAge) {
+		t.Errorf("errors.Is() debe encontrar los errores de dominio de cada campo")
+	}
+
+	var empty Validator
+	if err := empty.Err(); err != nil {
+		t.Errorf("Err() sin violaciones = %v, esperaba nil", err)
+	}
+}